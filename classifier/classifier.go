@@ -0,0 +1,380 @@
+package classifier
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ryv-api/locale"
+	"ryv-api/models"
+	"ryv-api/textutil"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// minTrainingArticles é o mínimo de artigos categorizados para usar o modelo
+	minTrainingArticles = 5
+	// minTrainingCategories é o mínimo de categorias com artigos para usar o modelo
+	minTrainingCategories = 2
+	// smoothing é a suavização de Laplace das frequências dos termos
+	smoothing = 1.0
+	// maxSuggestedTags limita as tags sugeridas
+	maxSuggestedTags = 5
+)
+
+// Peso de cada campo na representação do artigo
+const (
+	titleWeight   = 2
+	tagsWeight    = 2
+	excerptWeight = 1
+	contentWeight = 1
+)
+
+// ErrNotTrained indica que ainda não há artigos suficientes para treinar o modelo
+var ErrNotTrained = errors.New("artigos categorizados insuficientes para treinar o classificador")
+
+// CategoryScore é a probabilidade de o texto pertencer a uma categoria
+type CategoryScore struct {
+	Category    string  `json:"category"`
+	Probability float64 `json:"probability"`
+}
+
+// Result é a classificação de um texto
+type Result struct {
+	Category      string          `json:"category"`
+	Probabilities []CategoryScore `json:"probabilities"` // da mais provável para a menos provável
+	Tags          []string        `json:"tags"`          // tags já usadas em artigos que aparecem no texto
+	Method        string          `json:"method"`        // naive_bayes ou keywords (modelo ainda não treinado)
+}
+
+// Stats resume o último treino
+type Stats struct {
+	Trained    bool           `json:"trained"`
+	Articles   int            `json:"articles"`
+	Categories map[string]int `json:"categories"` // artigos de cada categoria
+	Vocabulary int            `json:"vocabulary"`
+	Accuracy   float64        `json:"accuracy"` // acerto deixando cada artigo de fora do treino
+	TrainedAt  time.Time      `json:"trained_at"`
+}
+
+// Classifier categoriza textos com um naive Bayes multinomial treinado nos artigos já
+// categorizados, com os termos normalizados como na busca (minúsculas, sem acentos, sem
+// stopwords e reduzidos ao radical)
+type Classifier struct {
+	db *gorm.DB
+
+	mu    sync.RWMutex
+	model *model
+	stats Stats
+}
+
+func New(db *gorm.DB) *Classifier {
+	return &Classifier{db: db}
+}
+
+// model guarda as contagens do naive Bayes
+type model struct {
+	categories []string
+	docs       map[string]int            // artigos por categoria
+	terms      map[string]map[string]int // frequência de cada termo por categoria
+	totals     map[string]int            // total de termos por categoria
+	vocabulary map[string]int            // total de cada termo em todas as categorias
+	articles   int
+	tags       []tagEntry
+}
+
+// tagEntry é uma tag usada nos artigos, com os termos que a compõem
+type tagEntry struct {
+	name  string
+	terms []string
+	uses  int
+}
+
+// document é um artigo de treino já convertido em termos
+type document struct {
+	category string
+	terms    map[string]int
+}
+
+// Train recalcula o modelo com os artigos categorizados e as categorias cadastradas
+func (c *Classifier) Train() (Stats, error) {
+	var articles []models.Article
+	if err := c.db.Select("id, title, excerpt, content, tags, category").
+		Where("category <> '' AND locale = ?", locale.Default).Find(&articles).Error; err != nil {
+		return Stats{}, err
+	}
+	var categories []models.Category
+	if err := c.db.Order("name").Find(&categories).Error; err != nil {
+		return Stats{}, err
+	}
+
+	m := &model{
+		docs:       make(map[string]int),
+		terms:      make(map[string]map[string]int),
+		totals:     make(map[string]int),
+		vocabulary: make(map[string]int),
+	}
+	addCategory := func(name string) {
+		if _, exists := m.terms[name]; !exists {
+			m.categories = append(m.categories, name)
+			m.terms[name] = make(map[string]int)
+		}
+	}
+	for _, category := range categories {
+		addCategory(category.Name)
+	}
+
+	// As palavras-chave entram como um documento a mais de cada categoria, para que as
+	// categorias sem artigos ainda possam ser sugeridas
+	for category, keywords := range seedKeywords {
+		if _, exists := m.terms[category]; !exists {
+			continue
+		}
+		for _, term := range textutil.Tokenize(strings.Join(keywords, " ")) {
+			m.add(category, term, 1)
+		}
+	}
+
+	documents := make([]document, 0, len(articles))
+	tagUses := make(map[string]*tagEntry)
+	for _, article := range articles {
+		category := strings.TrimSpace(article.Category)
+		addCategory(category)
+
+		doc := document{category: category, terms: articleTerms(article.Title, article.Excerpt, article.Content, article.Tags)}
+		documents = append(documents, doc)
+		m.docs[category]++
+		m.articles++
+		for term, count := range doc.terms {
+			m.add(category, term, count)
+		}
+
+		for _, tag := range strings.Split(article.Tags, ",") {
+			tag = strings.TrimSpace(tag)
+			key := textutil.FoldAccents(strings.ToLower(tag))
+			if key == "" {
+				continue
+			}
+			if entry, exists := tagUses[key]; exists {
+				entry.uses++
+				continue
+			}
+			if terms := textutil.Tokenize(tag); len(terms) > 0 {
+				tagUses[key] = &tagEntry{name: tag, terms: terms, uses: 1}
+			}
+		}
+	}
+	sort.Strings(m.categories)
+	for _, entry := range tagUses {
+		m.tags = append(m.tags, *entry)
+	}
+	sort.Slice(m.tags, func(i, j int) bool {
+		if m.tags[i].uses != m.tags[j].uses {
+			return m.tags[i].uses > m.tags[j].uses
+		}
+		return m.tags[i].name < m.tags[j].name
+	})
+
+	stats := Stats{
+		Articles:   m.articles,
+		Categories: make(map[string]int, len(m.categories)),
+		Vocabulary: len(m.vocabulary),
+		TrainedAt:  time.Now(),
+	}
+	withArticles := 0
+	for _, category := range m.categories {
+		stats.Categories[category] = m.docs[category]
+		if m.docs[category] > 0 {
+			withArticles++
+		}
+	}
+	stats.Trained = m.articles >= minTrainingArticles && withArticles >= minTrainingCategories
+
+	// Acerto estimado deixando cada artigo de fora do treino
+	if stats.Trained {
+		correct := 0
+		for _, doc := range documents {
+			scores := m.score(doc.terms, &doc)
+			if scores[0].Category == doc.category {
+				correct++
+			}
+		}
+		stats.Accuracy = float64(correct) / float64(len(documents))
+	}
+
+	c.mu.Lock()
+	c.model = m
+	c.stats = stats
+	c.mu.Unlock()
+
+	if !stats.Trained {
+		return stats, ErrNotTrained
+	}
+	return stats, nil
+}
+
+// Stats retorna o resumo do último treino
+func (c *Classifier) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats
+}
+
+// Classify calcula a probabilidade de cada categoria para o texto (HTML é aceito no
+// conteúdo) e sugere tags. Enquanto o modelo não tem artigos suficientes, a categoria vem
+// das palavras-chave.
+func (c *Classifier) Classify(title, excerpt, content string) Result {
+	c.mu.RLock()
+	m, trained := c.model, c.stats.Trained
+	c.mu.RUnlock()
+
+	terms := articleTerms(title, excerpt, content, "")
+	result := Result{Method: "keywords", Probabilities: []CategoryScore{}, Tags: []string{}}
+	if m != nil {
+		result.Tags = m.suggestTags(terms)
+	}
+	if !trained {
+		result.Category = KeywordCategory(title, excerpt+" "+textutil.StripHTML(content))
+		return result
+	}
+
+	result.Method = "naive_bayes"
+	result.Probabilities = m.score(terms, nil)
+	result.Category = result.Probabilities[0].Category
+	return result
+}
+
+// Categorize retorna só a categoria mais provável do texto
+func (c *Classifier) Categorize(title, content string) string {
+	return c.Classify(title, "", content).Category
+}
+
+// articleTerms converte os campos do artigo em frequências de termos, com peso por campo
+func articleTerms(title, excerpt, content, tags string) map[string]int {
+	terms := make(map[string]int)
+	add := func(text string, weight int) {
+		for _, term := range textutil.Tokenize(text) {
+			terms[term] += weight
+		}
+	}
+	add(title, titleWeight)
+	add(tags, tagsWeight)
+	add(excerpt, excerptWeight)
+	add(textutil.StripHTML(content), contentWeight)
+	return terms
+}
+
+func (m *model) add(category, term string, count int) {
+	m.terms[category][term] += count
+	m.totals[category] += count
+	m.vocabulary[term] += count
+}
+
+// score calcula a probabilidade de cada categoria. exclude, se informado, é um documento
+// do treino descontado das contagens (usado na estimativa de acerto).
+func (m *model) score(terms map[string]int, exclude *document) []CategoryScore {
+	// Termos que só aparecem no documento descontado saem do vocabulário
+	known := func(term string) bool {
+		total := m.vocabulary[term]
+		if exclude != nil {
+			total -= exclude.terms[term]
+		}
+		return total > 0
+	}
+	vocabularySize := len(m.vocabulary)
+	articles := m.articles
+	if exclude != nil {
+		articles--
+		for term := range exclude.terms {
+			if !known(term) {
+				vocabularySize--
+			}
+		}
+	}
+	vocabulary := float64(vocabularySize)
+
+	logs := make([]float64, len(m.categories))
+	for i, category := range m.categories {
+		docs, total := m.docs[category], m.totals[category]
+		if exclude != nil && exclude.category == category {
+			docs--
+			for _, count := range exclude.terms {
+				total -= count
+			}
+		}
+
+		// Probabilidade a priori suavizada, para categorias sem artigos não ficarem em zero
+		logProb := math.Log(float64(docs+1) / float64(articles+len(m.categories)))
+		for term, count := range terms {
+			if !known(term) {
+				continue
+			}
+			frequency := m.terms[category][term]
+			if exclude != nil && exclude.category == category {
+				frequency -= exclude.terms[term]
+			}
+			logProb += float64(count) * math.Log((float64(frequency)+smoothing)/(float64(total)+smoothing*vocabulary))
+		}
+		logs[i] = logProb
+	}
+
+	// Normaliza em probabilidades, subtraindo o maior valor para evitar underflow
+	maxLog := math.Inf(-1)
+	for _, value := range logs {
+		maxLog = math.Max(maxLog, value)
+	}
+	sum := 0.0
+	for i := range logs {
+		logs[i] = math.Exp(logs[i] - maxLog)
+		sum += logs[i]
+	}
+
+	scores := make([]CategoryScore, len(m.categories))
+	for i, category := range m.categories {
+		scores[i] = CategoryScore{Category: category, Probability: math.Round(logs[i]/sum*1000) / 1000}
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Probability > scores[j].Probability
+	})
+	return scores
+}
+
+// suggestTags retorna as tags já usadas nos artigos cujos termos aparecem todos no texto,
+// das mais frequentes no texto para as menos
+func (m *model) suggestTags(terms map[string]int) []string {
+	type candidate struct {
+		name  string
+		score float64
+	}
+	var candidates []candidate
+	for _, tag := range m.tags {
+		frequency := 0
+		for _, term := range tag.terms {
+			if terms[term] == 0 {
+				frequency = 0
+				break
+			}
+			frequency += terms[term]
+		}
+		if frequency > 0 {
+			score := float64(frequency) / float64(len(tag.terms)) * math.Log(1+float64(tag.uses))
+			candidates = append(candidates, candidate{name: tag.name, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	tags := make([]string, 0, maxSuggestedTags)
+	for _, candidate := range candidates {
+		if len(tags) == maxSuggestedTags {
+			break
+		}
+		tags = append(tags, candidate.name)
+	}
+	return tags
+}
@@ -0,0 +1,104 @@
+package classifier
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// trainModel monta o modelo com as mesmas contagens do Train, sem o banco
+func trainModel(categories []string, docs []document) *model {
+	m := &model{
+		categories: categories,
+		docs:       make(map[string]int),
+		terms:      make(map[string]map[string]int),
+		totals:     make(map[string]int),
+		vocabulary: make(map[string]int),
+	}
+	for _, category := range categories {
+		m.terms[category] = make(map[string]int)
+	}
+	for _, doc := range docs {
+		m.docs[doc.category]++
+		m.articles++
+		for term, count := range doc.terms {
+			m.add(doc.category, term, count)
+		}
+	}
+	return m
+}
+
+func TestModelScore(t *testing.T) {
+	m := trainModel([]string{"A", "B"}, []document{
+		{category: "A", terms: map[string]int{"ocul": 2}},
+		{category: "B", terms: map[string]int{"ansied": 1}},
+	})
+
+	// A: a priori (1+1)/(2+2), P(ocul|A) = (2+1)/(2+2) → 0,375
+	// B: a priori (1+1)/(2+2), P(ocul|B) = (0+1)/(1+2) → 0,1667
+	want := []CategoryScore{{Category: "A", Probability: 0.692}, {Category: "B", Probability: 0.308}}
+	if got := m.score(map[string]int{"ocul": 1}, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("score = %v, esperado %v", got, want)
+	}
+	// Termos fora do vocabulário não alteram o resultado
+	if got := m.score(map[string]int{"ocul": 1, "desconhec": 5}, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("score com termo desconhecido = %v, esperado %v", got, want)
+	}
+	// Sem termos conhecidos, vale só a probabilidade a priori
+	if got := m.score(map[string]int{}, nil); got[0].Probability != 0.5 || got[1].Probability != 0.5 {
+		t.Errorf("score sem termos = %v, esperado 0,5 para cada categoria", got)
+	}
+}
+
+func TestModelScoreOrdered(t *testing.T) {
+	m := trainModel([]string{"Optometria", "Saúde Mental", "Ótica"}, []document{
+		{category: "Ótica", terms: map[string]int{"ocul": 3, "lent": 2}},
+		{category: "Ótica", terms: map[string]int{"armaca": 2, "ocul": 1}},
+		{category: "Optometria", terms: map[string]int{"miop": 2, "exam": 1}},
+		{category: "Saúde Mental", terms: map[string]int{"ansied": 2, "terap": 1}},
+	})
+
+	scores := m.score(map[string]int{"ocul": 1, "lent": 1}, nil)
+	if scores[0].Category != "Ótica" {
+		t.Errorf("categoria = %s, esperado Ótica", scores[0].Category)
+	}
+	sum := 0.0
+	for i, score := range scores {
+		sum += score.Probability
+		if i > 0 && score.Probability > scores[i-1].Probability {
+			t.Errorf("probabilidades fora de ordem: %v", scores)
+		}
+	}
+	if math.Abs(sum-1) > 0.01 {
+		t.Errorf("soma das probabilidades = %f, esperado 1", sum)
+	}
+}
+
+// TestModelScoreLeaveOneOut confere que descontar um documento equivale a treinar o modelo
+// sem ele, inclusive os termos que só aparecem nesse documento
+func TestModelScoreLeaveOneOut(t *testing.T) {
+	categories := []string{"A", "B"}
+	docs := []document{
+		{category: "A", terms: map[string]int{"ocul": 3, "lent": 1}},
+		{category: "A", terms: map[string]int{"ocul": 1, "armaca": 2}},
+		{category: "A", terms: map[string]int{"lent": 2, "sol": 1}},
+		{category: "B", terms: map[string]int{"ansied": 2, "terap": 1}},
+		{category: "B", terms: map[string]int{"estress": 1, "ocul": 1}},
+		{category: "B", terms: map[string]int{"medit": 2, "ansied": 1, "unic": 4}},
+	}
+	m := trainModel(categories, docs)
+
+	for i, doc := range docs {
+		rest := append(append([]document{}, docs[:i]...), docs[i+1:]...)
+		want := trainModel(categories, rest).score(doc.terms, nil)
+		if got := m.score(doc.terms, &doc); !reflect.DeepEqual(got, want) {
+			t.Errorf("documento %d: score = %v, esperado %v", i, got, want)
+		}
+	}
+
+	// O desconto não altera o modelo
+	full := trainModel(categories, docs)
+	if !reflect.DeepEqual(m, full) {
+		t.Error("score com exclude alterou as contagens do modelo")
+	}
+}
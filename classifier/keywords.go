@@ -0,0 +1,59 @@
+package classifier
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultCategory é a categoria usada quando nada no texto indica outra
+const DefaultCategory = "Dicas de Saúde"
+
+// seedKeywords são as palavras-chave de cada categoria. Servem de categorização enquanto
+// não há artigos suficientes para treinar o modelo e, no treino, de vocabulário inicial
+// das categorias que ainda têm poucos artigos.
+var seedKeywords = map[string][]string{
+	"Saúde Mental": {
+		"ansiedade", "depressão", "estresse", "bem-estar", "psicologia",
+		"terapia", "meditação", "mindfulness", "saúde mental", "emocional",
+	},
+	"Ótica": {
+		"óculos", "lentes", "visão", "olhos", "óptica", "armação",
+		"proteção uv", "óculos de sol", "lentes progressivas",
+	},
+	"Optometria": {
+		"optometria", "exame ocular", "oftalmologia", "presbiopia",
+		"miopia", "astigmatismo", "catarata", "glaucoma",
+	},
+	"Dicas de Saúde": {
+		"saúde", "bem-estar", "qualidade de vida", "hábitos saudáveis",
+		"prevenção", "cuidados", "dicas", "conselhos",
+	},
+}
+
+// KeywordCategory escolhe a categoria com mais palavras-chave presentes no texto
+func KeywordCategory(title, content string) string {
+	text := strings.ToLower(title + " " + content)
+
+	categories := make([]string, 0, len(seedKeywords))
+	for category := range seedKeywords {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	maxScore := 0
+	bestCategory := DefaultCategory
+	for _, category := range categories {
+		score := 0
+		for _, word := range seedKeywords[category] {
+			if strings.Contains(text, word) {
+				score++
+			}
+		}
+		if score > maxScore {
+			maxScore = score
+			bestCategory = category
+		}
+	}
+
+	return bestCategory
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"log"
 	"ryv-api/models"
+	"ryv-api/textutil"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -23,13 +24,32 @@ func InitDatabase() {
 	}
 
 	// Auto migrate das tabelas
-	err = DB.AutoMigrate(&models.Article{}, &models.WhatsAppContact{}, &models.Category{}, &models.User{}, &models.ScrapedArticle{})
+	err = DB.AutoMigrate(&models.Article{}, &models.WhatsAppContact{}, &models.Category{}, &models.User{}, &models.ScrapedArticle{},
+		&models.ArticleView{}, &models.ArticleVisitor{}, &models.ArticleEventVisitor{}, &models.ArticleEngagement{}, &models.ReaderHistory{},
+		&models.ScoringSettings{}, &models.CategoryScoring{}, &models.MotivationPhrase{}, &models.DailyPick{},
+		&models.Experiment{}, &models.ExperimentVariant{}, &models.ExperimentAssignment{}, &models.ScraperSource{}, &models.FetchedPage{},
+		&models.ScheduledJob{}, &models.JobRun{}, &models.CategoryTranslation{}, &models.Media{}, &models.MediaVariant{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	// Criar categorias padrão se não existirem
 	createDefaultCategories()
+
+	// Criar configuração padrão da recomendação se não existir
+	createDefaultScoring()
+
+	// Criar nomes das categorias e frases motivacionais em inglês e espanhol se não existirem
+	createDefaultTranslations()
+
+	// Criar fontes padrão do scraper se não existirem
+	createDefaultScraperSources()
+
+	// Calcular contagem de palavras e tempo de leitura dos artigos antigos
+	backfillReadingStats()
+
+	// Calcular URL canônica e impressão digital de sugestões e artigos antigos
+	backfillFingerprints()
 	
 	log.Println("Database connected and migrated successfully")
 }
@@ -71,4 +91,263 @@ func createDefaultCategories() {
 			}
 		}
 	}
-} 
\ No newline at end of file
+} 
+
+// createDefaultScoring cria a configuração inicial da pontuação de recomendação,
+// com os mesmos pesos, palavras e frases usados antes de serem configuráveis
+func createDefaultScoring() {
+	var settingsCount int64
+	DB.Model(&models.ScoringSettings{}).Count(&settingsCount)
+	if settingsCount == 0 {
+		DB.Create(&models.ScoringSettings{
+			CuriosityWords:    "como, por que, quando, descubra, revele, secreto",
+			CuriosityBonus:    0.3,
+			EmotionalWords:    "transformar, conectar, bem-estar, saúde, vida, felicidade",
+			EmotionalBonus:    0.2,
+			WeekBonus:         0.5,
+			MonthBonus:        0.3,
+			ViewWeight:        0.01,
+			EngagementWeight:  1.0,
+			RandomWeight:      0.5,
+			DefaultMotivation: "🌟 Descubra insights valiosos para sua vida",
+			NoRepeatDays:      7,
+		})
+	}
+
+	weights := map[string]float64{
+		"Saúde Mental":   1.5, // Alto engajamento emocional
+		"Ótica":          1.2, // Interesse prático
+		"Optometria":     1.3, // Conhecimento técnico
+		"Dicas de Saúde": 1.4, // Aplicação prática
+	}
+	for category, weight := range weights {
+		var existing models.CategoryScoring
+		if err := DB.Where("category = ?", category).First(&existing).Error; err == gorm.ErrRecordNotFound {
+			DB.Create(&models.CategoryScoring{Category: category, Weight: weight})
+		}
+	}
+
+	var phrasesCount int64
+	DB.Model(&models.MotivationPhrase{}).Count(&phrasesCount)
+	if phrasesCount > 0 {
+		return
+	}
+
+	motivations := map[string][]string{
+		"Saúde Mental": {
+			"💡 Desbloqueie insights poderosos sobre sua mente",
+			"🧠 Conecte-se com seu bem-estar emocional",
+			"🌟 Transforme sua perspectiva sobre saúde mental",
+			"❤️ Cuide da sua mente como cuida do seu corpo",
+		},
+		"Ótica": {
+			"👁️ Descubra como cuidar da sua visão",
+			"🔍 Veja o mundo com novos olhos",
+			"✨ Tecnologia que transforma sua experiência visual",
+			"🌍 Enxergue a vida com mais clareza",
+		},
+		"Optometria": {
+			"🔬 Ciência avançada para sua saúde ocular",
+			"📊 Dados que revelam a verdade sobre sua visão",
+			"🎯 Soluções precisas para problemas visuais",
+			"⚡ Conhecimento que ilumina seu caminho",
+		},
+		"Dicas de Saúde": {
+			"💪 Pequenas mudanças, grandes resultados",
+			"🌱 Cultive hábitos que transformam sua vida",
+			"⚡ Energia e vitalidade ao seu alcance",
+			"🚀 Acelere seu potencial de bem-estar",
+		},
+	}
+	for category, phrases := range motivations {
+		for _, phrase := range phrases {
+			DB.Create(&models.MotivationPhrase{Category: category, Phrase: phrase})
+		}
+	}
+}
+
+// createDefaultTranslations cria os nomes das categorias padrão e as frases motivacionais
+// em inglês e espanhol, para os leitores que pedem esses idiomas
+func createDefaultTranslations() {
+	categoryNames := map[string]map[string][2]string{
+		"en": {
+			"Saúde Mental":   {"Mental Health", "Articles about mental health and well-being"},
+			"Ótica":          {"Eyewear", "Tips and information about glasses and lenses"},
+			"Optometria":     {"Optometry", "Technical information about eye health"},
+			"Dicas de Saúde": {"Health Tips", "General health and well-being tips"},
+		},
+		"es": {
+			"Saúde Mental":   {"Salud Mental", "Artículos sobre salud mental y bienestar"},
+			"Ótica":          {"Óptica", "Consejos e información sobre gafas y lentes"},
+			"Optometria":     {"Optometría", "Información técnica sobre salud ocular"},
+			"Dicas de Saúde": {"Consejos de Salud", "Consejos generales de salud y bienestar"},
+		},
+	}
+	for locale, names := range categoryNames {
+		for category, name := range names {
+			var existing models.CategoryTranslation
+			if err := DB.Where("category = ? AND locale = ?", category, locale).First(&existing).Error; err == gorm.ErrRecordNotFound {
+				DB.Create(&models.CategoryTranslation{Category: category, Locale: locale, Name: name[0], Description: name[1]})
+			}
+		}
+	}
+
+	motivations := map[string]map[string][]string{
+		"en": {
+			"Saúde Mental": {
+				"💡 Unlock powerful insights about your mind",
+				"🧠 Connect with your emotional well-being",
+				"❤️ Care for your mind the way you care for your body",
+			},
+			"Ótica": {
+				"👁️ Discover how to take care of your vision",
+				"🔍 See the world with new eyes",
+				"🌍 See life more clearly",
+			},
+			"Optometria": {
+				"🔬 Advanced science for your eye health",
+				"🎯 Precise solutions for vision problems",
+				"⚡ Knowledge that lights your way",
+			},
+			"Dicas de Saúde": {
+				"💪 Small changes, big results",
+				"🌱 Build habits that transform your life",
+				"🚀 Boost your well-being",
+			},
+		},
+		"es": {
+			"Saúde Mental": {
+				"💡 Descubre ideas poderosas sobre tu mente",
+				"🧠 Conéctate con tu bienestar emocional",
+				"❤️ Cuida tu mente como cuidas tu cuerpo",
+			},
+			"Ótica": {
+				"👁️ Descubre cómo cuidar tu visión",
+				"🔍 Mira el mundo con otros ojos",
+				"🌍 Ve la vida con más claridad",
+			},
+			"Optometria": {
+				"🔬 Ciencia avanzada para tu salud ocular",
+				"🎯 Soluciones precisas para problemas de visión",
+				"⚡ Conocimiento que ilumina tu camino",
+			},
+			"Dicas de Saúde": {
+				"💪 Pequeños cambios, grandes resultados",
+				"🌱 Cultiva hábitos que transforman tu vida",
+				"🚀 Impulsa tu bienestar",
+			},
+		},
+	}
+	for locale, categories := range motivations {
+		var count int64
+		DB.Model(&models.MotivationPhrase{}).Where("locale = ?", locale).Count(&count)
+		if count > 0 {
+			continue
+		}
+		for category, phrases := range categories {
+			for _, phrase := range phrases {
+				DB.Create(&models.MotivationPhrase{Category: category, Locale: locale, Phrase: phrase})
+			}
+		}
+	}
+}
+
+// createDefaultScraperSources cria as fontes que antes ficavam fixas no código do scraper
+func createDefaultScraperSources() {
+	var count int64
+	DB.Model(&models.ScraperSource{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	sources := []models.ScraperSource{
+		{Name: "Psychology Today", URL: "https://www.psychologytoday.com/us/blog", ItemSelector: ".blog-post", Category: "Saúde Mental"},
+		{Name: "Verywell Mind", URL: "https://www.verywellmind.com", ItemSelector: ".article-card", Category: "Saúde Mental"},
+		{Name: "All About Vision", URL: "https://www.allaboutvision.com", ItemSelector: ".article", Category: "Ótica"},
+		{Name: "American Optometric Association", URL: "https://www.aoa.org/news", ItemSelector: ".news-item", Category: "Optometria"},
+		{Name: "Healthline", URL: "https://www.healthline.com/health/mental-health", ItemSelector: ".article-card", Category: "Saúde Mental"},
+	}
+	for _, source := range sources {
+		source.TitleSelector = "h1, h2, h3, .title, .headline"
+		source.BodySelector = "p, .content, .body"
+		source.LinkSelector = "a"
+		source.TagsSelector = ".tags, .categories, .keywords"
+		source.Enabled = true
+		source.Schedule = "6h"
+		DB.Create(&source)
+	}
+}
+
+// backfillReadingStats calcula a contagem de palavras e o tempo de leitura dos artigos
+// salvos antes desses campos existirem
+func backfillReadingStats() {
+	var articles []models.Article
+	err := DB.Unscoped().Select("id, content").
+		Where("reading_minutes = 0 AND content <> ''").
+		FindInBatches(&articles, 100, func(tx *gorm.DB, batch int) error {
+			for _, article := range articles {
+				words := textutil.WordCount(article.Content)
+				// UpdateColumns não dispara os hooks nem altera updated_at
+				if err := DB.Unscoped().Model(&article).UpdateColumns(map[string]interface{}{
+					"word_count":      words,
+					"reading_minutes": textutil.ReadingMinutes(words),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Printf("Erro ao calcular tempo de leitura dos artigos: %v", err)
+	}
+}
+
+// backfillFingerprints calcula a impressão digital do conteúdo dos artigos e das sugestões,
+// e a URL canônica das sugestões, salvos antes desses campos existirem. Textos curtos demais
+// continuam sem impressão digital.
+func backfillFingerprints() {
+	var articles []models.Article
+	err := DB.Unscoped().Select("id, content").
+		Where("fingerprint = 0 AND content <> ''").
+		FindInBatches(&articles, 100, func(tx *gorm.DB, batch int) error {
+			for _, article := range articles {
+				fingerprint := int64(textutil.SimHash(textutil.StripHTML(article.Content)))
+				if fingerprint == 0 {
+					continue
+				}
+				if err := DB.Unscoped().Model(&article).UpdateColumn("fingerprint", fingerprint).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Printf("Erro ao calcular impressão digital dos artigos: %v", err)
+	}
+
+	var suggestions []models.ScrapedArticle
+	err = DB.Unscoped().Select("id, content, source_url, canonical_url").
+		Where("(fingerprint = 0 AND content <> '') OR (canonical_url = '' AND source_url <> '')").
+		FindInBatches(&suggestions, 100, func(tx *gorm.DB, batch int) error {
+			for _, suggestion := range suggestions {
+				canonical := suggestion.CanonicalURL
+				if canonical == "" {
+					canonical = textutil.CanonicalURL(suggestion.SourceURL)
+				}
+				fingerprint := int64(textutil.SimHash(textutil.StripHTML(suggestion.Content)))
+				if canonical == suggestion.CanonicalURL && fingerprint == 0 {
+					continue
+				}
+				if err := DB.Unscoped().Model(&suggestion).UpdateColumns(map[string]interface{}{
+					"canonical_url": canonical,
+					"fingerprint":   fingerprint,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Printf("Erro ao calcular impressão digital das sugestões: %v", err)
+	}
+}
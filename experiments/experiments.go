@@ -0,0 +1,294 @@
+package experiments
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"ryv-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Situações de um experimento
+const (
+	StatusDraft   = "draft"
+	StatusRunning = "running"
+	StatusStopped = "stopped"
+)
+
+// Locais onde um experimento pode ser aplicado
+const (
+	TargetDailyRecommendation = "daily_recommendation"
+)
+
+// Estratégias de escolha do artigo do dia
+const (
+	StrategyScoring = "scoring" // pontuação configurada no painel (padrão)
+	StrategyLatest  = "latest"  // artigo publicado mais recentemente
+	StrategyPopular = "popular" // artigo com mais visualizações
+)
+
+var (
+	ErrNoVariants  = errors.New("experimento sem variantes")
+	ErrNotAssigned = errors.New("leitor não participa do experimento")
+)
+
+// ValidTarget verifica se o local do experimento é suportado
+func ValidTarget(target string) bool {
+	return target == TargetDailyRecommendation
+}
+
+// ValidStrategy verifica se a estratégia da variante é suportada
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyScoring, StrategyLatest, StrategyPopular:
+		return true
+	}
+	return false
+}
+
+// Running retorna o experimento em execução no local informado, ou nil se não houver
+func Running(db *gorm.DB, target string) (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := db.Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Where("target = ? AND status = ?", target, StatusRunning).
+		Order("started_at DESC").
+		First(&experiment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// Expose retorna a variante do leitor e registra que ele a viu. Na primeira exposição o
+// leitor é atribuído a uma variante, que se mantém enquanto o experimento durar.
+func Expose(db *gorm.DB, experiment *models.Experiment, readerID string) (models.ExperimentVariant, error) {
+	if len(experiment.Variants) == 0 {
+		return models.ExperimentVariant{}, ErrNoVariants
+	}
+
+	assignment := models.ExperimentAssignment{
+		ExperimentID: experiment.ID,
+		ReaderID:     readerID,
+		VariantID:    pickVariant(experiment, readerID).ID,
+		Impressions:  1,
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "experiment_id"}, {Name: "reader_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"impressions": gorm.Expr("impressions + 1"),
+			"updated_at":  time.Now(),
+		}),
+	}).Create(&assignment).Error
+	if err != nil {
+		return models.ExperimentVariant{}, err
+	}
+
+	// A variante gravada prevalece (ex.: atribuída antes de uma mudança de pesos)
+	var stored models.ExperimentAssignment
+	if err := db.Select("variant_id").
+		Where("experiment_id = ? AND reader_id = ?", experiment.ID, readerID).
+		First(&stored).Error; err != nil {
+		return models.ExperimentVariant{}, err
+	}
+	for _, variant := range experiment.Variants {
+		if variant.ID == stored.VariantID {
+			return variant, nil
+		}
+	}
+	return pickVariant(experiment, readerID), nil
+}
+
+// pickVariant distribui os leitores entre as variantes de acordo com o peso, usando um
+// hash do leitor para que a mesma pessoa caia sempre na mesma variante
+func pickVariant(experiment *models.Experiment, readerID string) models.ExperimentVariant {
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variantWeight(variant)
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(experiment.Key + ":" + readerID))
+	bucket := int(hash.Sum32() % uint32(total))
+
+	for _, variant := range experiment.Variants {
+		bucket -= variantWeight(variant)
+		if bucket < 0 {
+			return variant
+		}
+	}
+	return experiment.Variants[len(experiment.Variants)-1]
+}
+
+func variantWeight(variant models.ExperimentVariant) int {
+	if variant.Weight < 1 {
+		return 1
+	}
+	return variant.Weight
+}
+
+// RecordClick registra um clique do leitor no conteúdo do experimento em execução
+func RecordClick(db *gorm.DB, experimentKey, readerID string) error {
+	var experiment models.Experiment
+	if err := db.Select("id").Where("key = ? AND status = ?", experimentKey, StatusRunning).
+		First(&experiment).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := db.Model(&models.ExperimentAssignment{}).
+		Where("experiment_id = ? AND reader_id = ?", experiment.ID, readerID).
+		Updates(map[string]interface{}{
+			"clicks":     gorm.Expr("clicks + 1"),
+			"clicked_at": gorm.Expr("COALESCE(clicked_at, ?)", now),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotAssigned
+	}
+	return nil
+}
+
+// RecordConversion registra uma conversão (ex.: contato pelo WhatsApp) em todos os
+// experimentos em execução dos quais o leitor participa
+func RecordConversion(db *gorm.DB, readerID string) error {
+	if readerID == "" {
+		return nil
+	}
+
+	running := db.Model(&models.Experiment{}).Select("id").Where("status = ?", StatusRunning)
+	now := time.Now()
+	return db.Model(&models.ExperimentAssignment{}).
+		Where("reader_id = ? AND experiment_id IN (?)", readerID, running).
+		Updates(map[string]interface{}{
+			"conversions":  gorm.Expr("conversions + 1"),
+			"converted_at": gorm.Expr("COALESCE(converted_at, ?)", now),
+			"updated_at":   now,
+		}).Error
+}
+
+// Comparison compara uma taxa da variante com a do controle
+type Comparison struct {
+	Lift        float64 `json:"lift"` // variação relativa em relação ao controle
+	ZScore      float64 `json:"z_score"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// VariantResult resume o desempenho de uma variante
+type VariantResult struct {
+	VariantID      uint        `json:"variant_id"`
+	Key            string      `json:"key"`
+	Name           string      `json:"name"`
+	IsControl      bool        `json:"is_control"`
+	Readers        int         `json:"readers"` // leitores expostos
+	Impressions    int         `json:"impressions"`
+	Clicks         int         `json:"clicks"`
+	Clickers       int         `json:"clickers"`
+	CTR            float64     `json:"ctr"` // leitores que clicaram / leitores expostos
+	Conversions    int         `json:"conversions"`
+	Converters     int         `json:"converters"`
+	ConversionRate float64     `json:"conversion_rate"` // leitores que converteram / leitores expostos
+	CTRTest        *Comparison `json:"ctr_test,omitempty"`
+	ConversionTest *Comparison `json:"conversion_test,omitempty"`
+}
+
+// Results calcula, para cada variante, CTR e conversão e compara com o controle
+func Results(db *gorm.DB, experiment *models.Experiment) ([]VariantResult, error) {
+	type variantTotals struct {
+		VariantID   uint
+		Readers     int
+		Impressions int
+		Clicks      int
+		Clickers    int
+		Conversions int
+		Converters  int
+	}
+	var totals []variantTotals
+	err := db.Model(&models.ExperimentAssignment{}).
+		Select(`variant_id, COUNT(*) AS readers, SUM(impressions) AS impressions,
+			SUM(clicks) AS clicks, SUM(CASE WHEN clicks > 0 THEN 1 ELSE 0 END) AS clickers,
+			SUM(conversions) AS conversions, SUM(CASE WHEN conversions > 0 THEN 1 ELSE 0 END) AS converters`).
+		Where("experiment_id = ?", experiment.ID).
+		Group("variant_id").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	totalsByVariant := make(map[uint]variantTotals, len(totals))
+	for _, t := range totals {
+		totalsByVariant[t.VariantID] = t
+	}
+
+	variants := append([]models.ExperimentVariant(nil), experiment.Variants...)
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+
+	results := make([]VariantResult, 0, len(variants))
+	control := -1
+	for idx, variant := range variants {
+		t := totalsByVariant[variant.ID]
+		result := VariantResult{
+			VariantID:   variant.ID,
+			Key:         variant.Key,
+			Name:        variant.Name,
+			IsControl:   variant.IsControl,
+			Readers:     t.Readers,
+			Impressions: t.Impressions,
+			Clicks:      t.Clicks,
+			Clickers:    t.Clickers,
+			Conversions: t.Conversions,
+			Converters:  t.Converters,
+		}
+		if t.Readers > 0 {
+			result.CTR = float64(t.Clickers) / float64(t.Readers)
+			result.ConversionRate = float64(t.Converters) / float64(t.Readers)
+		}
+		if variant.IsControl && control < 0 {
+			control = idx
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return results, nil
+	}
+	if control < 0 {
+		control = 0
+		results[0].IsControl = true
+	}
+
+	base := results[control]
+	for idx := range results {
+		if idx == control {
+			continue
+		}
+		r := &results[idx]
+		r.CTRTest = compare(base.Clickers, base.Readers, r.Clickers, r.Readers)
+		r.ConversionTest = compare(base.Converters, base.Readers, r.Converters, r.Readers)
+	}
+
+	return results, nil
+}
+
+func compare(controlSuccesses, controlTotal, successes, total int) *Comparison {
+	z, p := TwoProportionZTest(controlSuccesses, controlTotal, successes, total)
+	comparison := &Comparison{
+		ZScore:      z,
+		PValue:      p,
+		Significant: p < SignificanceLevel,
+	}
+	if controlTotal > 0 && total > 0 && controlSuccesses > 0 {
+		controlRate := float64(controlSuccesses) / float64(controlTotal)
+		rate := float64(successes) / float64(total)
+		comparison.Lift = (rate - controlRate) / controlRate
+	}
+	return comparison
+}
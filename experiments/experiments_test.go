@@ -0,0 +1,286 @@
+package experiments
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ryv-api/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB cria um banco SQLite temporário com as tabelas dos experimentos
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "experiments.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Experiment{}, &models.ExperimentVariant{}, &models.ExperimentAssignment{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// createExperiment grava um experimento em execução com as variantes informadas
+func createExperiment(t *testing.T, db *gorm.DB, variants ...models.ExperimentVariant) *models.Experiment {
+	t.Helper()
+
+	now := time.Now()
+	experiment := &models.Experiment{
+		Key:       "daily-strategy",
+		Name:      "Estratégia do artigo do dia",
+		Target:    TargetDailyRecommendation,
+		Status:    StatusRunning,
+		StartedAt: &now,
+		Variants:  variants,
+	}
+	if err := db.Create(experiment).Error; err != nil {
+		t.Fatal(err)
+	}
+	return experiment
+}
+
+func TestPickVariantSticky(t *testing.T) {
+	experiment := &models.Experiment{Key: "daily-strategy", Variants: []models.ExperimentVariant{
+		{ID: 1, Key: "control", Weight: 1},
+		{ID: 2, Key: "latest", Weight: 1},
+		{ID: 3, Key: "popular", Weight: 2},
+	}}
+
+	for i := 0; i < 200; i++ {
+		reader := fmt.Sprintf("leitor-%d", i)
+		first := pickVariant(experiment, reader)
+		for j := 0; j < 5; j++ {
+			if again := pickVariant(experiment, reader); again.ID != first.ID {
+				t.Fatalf("leitor %s: variante %d, depois %d", reader, first.ID, again.ID)
+			}
+		}
+	}
+}
+
+func TestPickVariantWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []models.ExperimentVariant
+		want     []float64 // proporção esperada de cada variante
+	}{
+		{
+			name:     "meio a meio",
+			variants: []models.ExperimentVariant{{ID: 1, Weight: 1}, {ID: 2, Weight: 1}},
+			want:     []float64{0.5, 0.5},
+		},
+		{
+			name:     "pesos diferentes",
+			variants: []models.ExperimentVariant{{ID: 1, Weight: 1}, {ID: 2, Weight: 3}},
+			want:     []float64{0.25, 0.75},
+		},
+		{
+			name:     "peso zero conta como 1",
+			variants: []models.ExperimentVariant{{ID: 1, Weight: 0}, {ID: 2, Weight: 1}, {ID: 3, Weight: 2}},
+			want:     []float64{0.25, 0.25, 0.5},
+		},
+	}
+	const readers = 20000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			experiment := &models.Experiment{Key: "daily-strategy", Variants: tt.variants}
+			counts := make(map[uint]int)
+			for i := 0; i < readers; i++ {
+				counts[pickVariant(experiment, fmt.Sprintf("leitor-%d", i)).ID]++
+			}
+			for i, variant := range tt.variants {
+				share := float64(counts[variant.ID]) / readers
+				if math.Abs(share-tt.want[i]) > 0.02 {
+					t.Errorf("variante %d: %.3f dos leitores, esperado %.3f", variant.ID, share, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExposeKeepsStoredVariant(t *testing.T) {
+	db := openTestDB(t)
+	experiment := createExperiment(t, db,
+		models.ExperimentVariant{Key: "control", Weight: 1, IsControl: true},
+		models.ExperimentVariant{Key: "latest", Weight: 1, Strategy: StrategyLatest},
+	)
+
+	first, err := Expose(db, experiment, "leitor-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Uma mudança de pesos não muda a variante de quem já foi atribuído
+	for i := range experiment.Variants {
+		experiment.Variants[i].Weight = 1
+		if experiment.Variants[i].ID != first.ID {
+			experiment.Variants[i].Weight = 1000
+		}
+	}
+	for i := 0; i < 3; i++ {
+		variant, err := Expose(db, experiment, "leitor-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if variant.ID != first.ID {
+			t.Fatalf("variante = %d, esperado %d", variant.ID, first.ID)
+		}
+	}
+
+	var assignment models.ExperimentAssignment
+	if err := db.Where("experiment_id = ? AND reader_id = ?", experiment.ID, "leitor-1").First(&assignment).Error; err != nil {
+		t.Fatal(err)
+	}
+	if assignment.Impressions != 4 {
+		t.Errorf("impressões = %d, esperado 4", assignment.Impressions)
+	}
+
+	if _, err := Expose(db, &models.Experiment{ID: experiment.ID}, "leitor-2"); err != ErrNoVariants {
+		t.Errorf("sem variantes: erro = %v, esperado ErrNoVariants", err)
+	}
+}
+
+func TestRecordClickAndConversion(t *testing.T) {
+	db := openTestDB(t)
+	experiment := createExperiment(t, db, models.ExperimentVariant{Key: "control", IsControl: true})
+
+	if _, err := Expose(db, experiment, "leitor-1"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := RecordClick(db, experiment.Key, "leitor-1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordConversion(db, "leitor-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordClick(db, experiment.Key, "leitor-2"); err != ErrNotAssigned {
+		t.Errorf("leitor sem variante: erro = %v, esperado ErrNotAssigned", err)
+	}
+
+	var assignment models.ExperimentAssignment
+	if err := db.Where("reader_id = ?", "leitor-1").First(&assignment).Error; err != nil {
+		t.Fatal(err)
+	}
+	if assignment.Clicks != 2 || assignment.Conversions != 2 || assignment.ClickedAt == nil || assignment.ConvertedAt == nil {
+		t.Errorf("cliques = %d, conversões = %d, clicked_at = %v, converted_at = %v; esperado 2, 2 e as datas",
+			assignment.Clicks, assignment.Conversions, assignment.ClickedAt, assignment.ConvertedAt)
+	}
+
+	// Experimentos encerrados não recebem mais eventos
+	if err := db.Model(experiment).Update("status", StatusStopped).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordConversion(db, "leitor-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("reader_id = ?", "leitor-1").First(&assignment).Error; err != nil {
+		t.Fatal(err)
+	}
+	if assignment.Conversions != 2 {
+		t.Errorf("conversões após encerrar = %d, esperado 2", assignment.Conversions)
+	}
+}
+
+func TestResults(t *testing.T) {
+	db := openTestDB(t)
+	// O controle não é a primeira variante, para conferir que a comparação usa o marcado
+	experiment := createExperiment(t, db,
+		models.ExperimentVariant{Key: "latest", Name: "Mais recente", Strategy: StrategyLatest},
+		models.ExperimentVariant{Key: "control", Name: "Pontuação", IsControl: true},
+		models.ExperimentVariant{Key: "popular", Name: "Mais lido", Strategy: StrategyPopular},
+	)
+	latest, control := experiment.Variants[0], experiment.Variants[1]
+
+	var assignments []models.ExperimentAssignment
+	for i := 0; i < 100; i++ {
+		// Controle: 100 leitores com 2 impressões, 10 clicaram duas vezes e 5 converteram
+		assignment := models.ExperimentAssignment{ExperimentID: experiment.ID, ReaderID: fmt.Sprintf("c-%d", i), VariantID: control.ID, Impressions: 2}
+		if i < 10 {
+			assignment.Clicks = 2
+		}
+		if i < 5 {
+			assignment.Conversions = 1
+		}
+		assignments = append(assignments, assignment)
+
+		// Mais recente: 100 leitores, 25 clicaram e os mesmos 5 converteram
+		assignment = models.ExperimentAssignment{ExperimentID: experiment.ID, ReaderID: fmt.Sprintf("l-%d", i), VariantID: latest.ID, Impressions: 1}
+		if i < 25 {
+			assignment.Clicks = 1
+		}
+		if i < 5 {
+			assignment.Conversions = 1
+		}
+		assignments = append(assignments, assignment)
+	}
+	// Atribuições de outro experimento não entram no resultado
+	assignments = append(assignments, models.ExperimentAssignment{ExperimentID: experiment.ID + 1, ReaderID: "x", VariantID: latest.ID, Impressions: 1, Clicks: 1})
+	if err := db.Create(&assignments).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := Results(db, experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("resultados = %d, esperado 3", len(results))
+	}
+
+	latestResult, controlResult, popularResult := results[0], results[1], results[2]
+	if !controlResult.IsControl || controlResult.CTRTest != nil {
+		t.Errorf("controle = %+v, esperado marcado e sem comparação", controlResult)
+	}
+	if controlResult.Readers != 100 || controlResult.Impressions != 200 || controlResult.Clicks != 20 || controlResult.Clickers != 10 ||
+		controlResult.CTR != 0.1 || controlResult.Converters != 5 || controlResult.ConversionRate != 0.05 {
+		t.Errorf("controle = %+v", controlResult)
+	}
+
+	if latestResult.Readers != 100 || latestResult.Impressions != 100 || latestResult.Clickers != 25 || latestResult.CTR != 0.25 {
+		t.Errorf("mais recente = %+v", latestResult)
+	}
+	if test := latestResult.CTRTest; test == nil || math.Abs(test.Lift-1.5) > 1e-9 || math.Abs(test.ZScore-2.791453) > 1e-5 ||
+		math.Abs(test.PValue-0.005247) > 1e-5 || !test.Significant {
+		t.Errorf("teste do CTR = %+v, esperado lift 1,5, z 2,791 e p 0,0052", test)
+	}
+	if test := latestResult.ConversionTest; test == nil || test.Lift != 0 || test.ZScore != 0 || test.PValue != 1 || test.Significant {
+		t.Errorf("teste da conversão = %+v, esperado sem diferença", test)
+	}
+
+	// Variante sem leitores: taxas zeradas e sem significância
+	if popularResult.Readers != 0 || popularResult.CTR != 0 || popularResult.CTRTest == nil || popularResult.CTRTest.PValue != 1 ||
+		popularResult.CTRTest.Lift != 0 {
+		t.Errorf("mais lido = %+v (%+v)", popularResult, popularResult.CTRTest)
+	}
+}
+
+func TestResultsWithoutControl(t *testing.T) {
+	db := openTestDB(t)
+	experiment := createExperiment(t, db, models.ExperimentVariant{Key: "a"}, models.ExperimentVariant{Key: "b"})
+
+	results, err := Results(db, experiment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].IsControl || results[0].CTRTest != nil || results[1].IsControl || results[1].CTRTest == nil {
+		t.Errorf("sem controle marcado, a primeira variante deveria ser o controle: %+v", results)
+	}
+}
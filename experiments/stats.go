@@ -0,0 +1,30 @@
+package experiments
+
+import "math"
+
+// SignificanceLevel é o p-valor abaixo do qual a diferença entre variantes é considerada real
+const SignificanceLevel = 0.05
+
+// minSampleSize é o mínimo de leitores por variante para que o teste seja aplicado;
+// com amostras menores a aproximação normal não é confiável
+const minSampleSize = 30
+
+// TwoProportionZTest compara duas proporções (x1/n1 e x2/n2) com o teste z bicaudal.
+// Retorna o escore z e o p-valor; sem amostra suficiente o p-valor é 1.
+func TwoProportionZTest(x1, n1, x2, n2 int) (float64, float64) {
+	if n1 < minSampleSize || n2 < minSampleSize {
+		return 0, 1
+	}
+
+	p1 := float64(x1) / float64(n1)
+	p2 := float64(x2) / float64(n2)
+	pooled := float64(x1+x2) / float64(n1+n2)
+	standardError := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if standardError == 0 {
+		return 0, 1
+	}
+
+	z := (p2 - p1) / standardError
+	p := math.Erfc(math.Abs(z) / math.Sqrt2)
+	return z, p
+}
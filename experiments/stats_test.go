@@ -0,0 +1,34 @@
+package experiments
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTwoProportionZTest(t *testing.T) {
+	tests := []struct {
+		name           string
+		x1, n1, x2, n2 int
+		z, p           float64
+	}{
+		{"variante melhor", 100, 1000, 150, 1000, 3.380617, 0.000723},
+		{"variante pior", 150, 1000, 100, 1000, -3.380617, 0.000723},
+		{"diferença pequena", 30, 200, 42, 200, 1.561738, 0.118350},
+		{"amostras de tamanhos diferentes", 10, 100, 11, 120, -0.209452, 0.834095},
+		{"no limite da significância", 5, 40, 12, 40, 1.913147, 0.055729},
+		{"taxas iguais", 50, 500, 50, 500, 0, 1},
+		{"taxas iguais com amostras diferentes", 20, 100, 40, 200, 0, 1},
+		{"nenhum sucesso", 0, 100, 0, 100, 0, 1},
+		{"todos com sucesso", 100, 100, 100, 100, 0, 1},
+		{"sem leitores no controle", 0, 0, 10, 100, 0, 1},
+		{"sem leitores na variante", 10, 100, 0, 0, 0, 1},
+		{"amostra menor que o mínimo", 1, 29, 20, 29, 0, 1},
+	}
+	for _, tt := range tests {
+		z, p := TwoProportionZTest(tt.x1, tt.n1, tt.x2, tt.n2)
+		if math.Abs(z-tt.z) > 1e-5 || math.Abs(p-tt.p) > 1e-5 {
+			t.Errorf("%s: TwoProportionZTest(%d, %d, %d, %d) = %.6f, %.6f; esperado %.6f, %.6f",
+				tt.name, tt.x1, tt.n1, tt.x2, tt.n2, z, p, tt.z, tt.p)
+		}
+	}
+}
@@ -0,0 +1,670 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/tracking"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	maxAnalyticsDays     = 365
+	analyticsDateLayout  = "2006-01-02"
+
+	// contactSourceExpr agrupa contatos sem origem informada como acesso direto
+	contactSourceExpr = "COALESCE(NULLIF(TRIM(source), ''), '(direto)')"
+)
+
+type AnalyticsHandler struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsHandler(db *gorm.DB) *AnalyticsHandler {
+	return &AnalyticsHandler{db: db}
+}
+
+// analyticsPeriod representa o intervalo [From, To) analisado e o período anterior de mesmo tamanho
+type analyticsPeriod struct {
+	From         time.Time
+	To           time.Time
+	PreviousFrom time.Time
+	PreviousTo   time.Time
+}
+
+// days conta os dias do calendário no período. As datas são comparadas em UTC porque, no
+// fuso local, um dia com mudança de horário de verão não tem 24 horas.
+func (p analyticsPeriod) days() int {
+	from := time.Date(p.From.Year(), p.From.Month(), p.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(p.To.Year(), p.To.Month(), p.To.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// parseAnalyticsPeriod lê ?from=&to= (YYYY-MM-DD) ou ?days= da query string
+func parseAnalyticsPeriod(c *gin.Context) (analyticsPeriod, bool) {
+	var period analyticsPeriod
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	if from, to := c.Query("from"), c.Query("to"); from != "" || to != "" {
+		fromDate, err := time.ParseInLocation(analyticsDateLayout, from, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'from' inválido. Use o formato YYYY-MM-DD"})
+			return period, false
+		}
+		toDate := today
+		if to != "" {
+			toDate, err = time.ParseInLocation(analyticsDateLayout, to, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'to' inválido. Use o formato YYYY-MM-DD"})
+				return period, false
+			}
+		}
+		// O dia final é inclusivo
+		period.From = fromDate
+		period.To = toDate.AddDate(0, 0, 1)
+	} else {
+		days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultAnalyticsDays)))
+		if err != nil || days < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'days' inválido"})
+			return period, false
+		}
+		period.To = today.AddDate(0, 0, 1)
+		period.From = period.To.AddDate(0, 0, -days)
+	}
+
+	if !period.From.Before(period.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "O início do período deve ser anterior ao fim"})
+		return period, false
+	}
+	if period.days() > maxAnalyticsDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Período máximo de " + strconv.Itoa(maxAnalyticsDays) + " dias"})
+		return period, false
+	}
+
+	period.PreviousTo = period.From
+	period.PreviousFrom = period.From.AddDate(0, 0, -period.days())
+
+	return period, true
+}
+
+func parseAnalyticsLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		return 10
+	}
+	if limit > 100 {
+		return 100
+	}
+	return limit
+}
+
+// percentChange calcula a variação percentual entre o período atual e o anterior
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := (current - previous) / previous * 100
+	return &change
+}
+
+func (h *AnalyticsHandler) contactsBetween(from, to time.Time) *gorm.DB {
+	return h.db.Model(&models.WhatsAppContact{}).Where("created_at >= ? AND created_at < ?", from, to)
+}
+
+// Overview retorna os totais do período comparados com o período anterior
+func (h *AnalyticsHandler) Overview(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	var current, previous int64
+	if err := h.contactsBetween(period.From, period.To).Count(&current).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estatísticas"})
+		return
+	}
+	if err := h.contactsBetween(period.PreviousFrom, period.PreviousTo).Count(&previous).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estatísticas"})
+		return
+	}
+
+	var currentFromArticles, previousFromArticles int64
+	if err := h.contactsBetween(period.From, period.To).Where("article_id IS NOT NULL").Count(&currentFromArticles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estatísticas"})
+		return
+	}
+	if err := h.contactsBetween(period.PreviousFrom, period.PreviousTo).Where("article_id IS NOT NULL").Count(&previousFromArticles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular estatísticas"})
+		return
+	}
+
+	days := float64(period.days())
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":          periodJSON(period.From, period.To),
+		"previous_period": periodJSON(period.PreviousFrom, period.PreviousTo),
+		"contacts": gin.H{
+			"current":        current,
+			"previous":       previous,
+			"change_percent": percentChange(float64(current), float64(previous)),
+		},
+		"contacts_from_articles": gin.H{
+			"current":        currentFromArticles,
+			"previous":       previousFromArticles,
+			"change_percent": percentChange(float64(currentFromArticles), float64(previousFromArticles)),
+		},
+		"daily_average": gin.H{
+			"current":  float64(current) / days,
+			"previous": float64(previous) / days,
+		},
+	})
+}
+
+func periodJSON(from, to time.Time) gin.H {
+	return gin.H{
+		"from": from.Format(analyticsDateLayout),
+		"to":   to.AddDate(0, 0, -1).Format(analyticsDateLayout),
+	}
+}
+
+// ContactsTimeSeries retorna a quantidade de contatos por dia, incluindo dias sem contatos
+func (h *AnalyticsHandler) ContactsTimeSeries(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	current, err := h.contactsPerDay(period.From, period.To)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar contatos"})
+		return
+	}
+	previous, err := h.contactsPerDay(period.PreviousFrom, period.PreviousTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar contatos"})
+		return
+	}
+
+	type point struct {
+		Date             string `json:"date"`
+		Contacts         int    `json:"contacts"`
+		PreviousDate     string `json:"previous_date"`
+		PreviousContacts int    `json:"previous_contacts"`
+	}
+
+	// Alinhar cada dia do período atual com o dia equivalente do período anterior
+	series := make([]point, 0, period.days())
+	for day, prevDay := period.From, period.PreviousFrom; day.Before(period.To); day, prevDay = day.AddDate(0, 0, 1), prevDay.AddDate(0, 0, 1) {
+		date := day.Format(analyticsDateLayout)
+		prevDate := prevDay.Format(analyticsDateLayout)
+		series = append(series, point{
+			Date:             date,
+			Contacts:         current[date],
+			PreviousDate:     prevDate,
+			PreviousContacts: previous[prevDate],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":          periodJSON(period.From, period.To),
+		"previous_period": periodJSON(period.PreviousFrom, period.PreviousTo),
+		"series":          series,
+	})
+}
+
+// contactsPerDay agrupa os contatos por dia no fuso horário local do servidor
+func (h *AnalyticsHandler) contactsPerDay(from, to time.Time) (map[string]int, error) {
+	var createdAts []time.Time
+	if err := h.contactsBetween(from, to).Pluck("created_at", &createdAts).Error; err != nil {
+		return nil, err
+	}
+
+	perDay := make(map[string]int)
+	for _, createdAt := range createdAts {
+		perDay[createdAt.In(time.Local).Format(analyticsDateLayout)]++
+	}
+	return perDay, nil
+}
+
+// ContactsHeatmap retorna os contatos agrupados por dia da semana e hora do dia
+func (h *AnalyticsHandler) ContactsHeatmap(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	var createdAts []time.Time
+	if err := h.contactsBetween(period.From, period.To).Pluck("created_at", &createdAts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar contatos"})
+		return
+	}
+
+	// matrix[dia da semana][hora], com domingo = 0
+	matrix := make([][]int, 7)
+	for i := range matrix {
+		matrix[i] = make([]int, 24)
+	}
+	byHour := make([]int, 24)
+	for _, createdAt := range createdAts {
+		local := createdAt.In(time.Local)
+		matrix[local.Weekday()][local.Hour()]++
+		byHour[local.Hour()]++
+	}
+
+	peakHour := 0
+	for hour, count := range byHour {
+		if count > byHour[peakHour] {
+			peakHour = hour
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":    periodJSON(period.From, period.To),
+		"weekdays":  []string{"domingo", "segunda", "terça", "quarta", "quinta", "sexta", "sábado"},
+		"matrix":    matrix,
+		"by_hour":   byHour,
+		"peak_hour": peakHour,
+		"total":     len(createdAts),
+	})
+}
+
+// TopSources retorna as origens que mais geram contatos, comparadas com o período anterior
+func (h *AnalyticsHandler) TopSources(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+	limit := parseAnalyticsLimit(c)
+
+	type sourceCount struct {
+		Source   string
+		Contacts int64
+	}
+
+	var current []sourceCount
+	if err := h.contactsBetween(period.From, period.To).
+		Select(contactSourceExpr + " AS source, COUNT(*) AS contacts").
+		Group(contactSourceExpr).
+		Order("contacts DESC").
+		Limit(limit).
+		Scan(&current).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar origens"})
+		return
+	}
+
+	var previous []sourceCount
+	if err := h.contactsBetween(period.PreviousFrom, period.PreviousTo).
+		Select(contactSourceExpr + " AS source, COUNT(*) AS contacts").
+		Group(contactSourceExpr).
+		Scan(&previous).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar origens"})
+		return
+	}
+	previousBySource := make(map[string]int64, len(previous))
+	for _, p := range previous {
+		previousBySource[p.Source] = p.Contacts
+	}
+
+	var total int64
+	if err := h.contactsBetween(period.From, period.To).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar origens"})
+		return
+	}
+
+	sources := make([]gin.H, 0, len(current))
+	for _, s := range current {
+		share := 0.0
+		if total > 0 {
+			share = float64(s.Contacts) / float64(total) * 100
+		}
+		sources = append(sources, gin.H{
+			"source":            s.Source,
+			"contacts":          s.Contacts,
+			"share_percent":     share,
+			"previous_contacts": previousBySource[s.Source],
+			"change_percent":    percentChange(float64(s.Contacts), float64(previousBySource[s.Source])),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":  periodJSON(period.From, period.To),
+		"total":   total,
+		"sources": sources,
+	})
+}
+
+// viewsBetween soma as visualizações diárias dos artigos no intervalo [from, to)
+func (h *AnalyticsHandler) viewsBetween(articleIDs []uint, from, to time.Time) (map[uint]int64, error) {
+	viewsByArticle := make(map[uint]int64, len(articleIDs))
+	if len(articleIDs) == 0 {
+		return viewsByArticle, nil
+	}
+
+	type articleViews struct {
+		ArticleID uint
+		Views     int64
+	}
+	var rows []articleViews
+	if err := h.db.Model(&models.ArticleView{}).
+		Select("article_id, SUM(views) AS views").
+		Where("article_id IN ? AND date >= ? AND date < ?", articleIDs, from.Format(analyticsDateLayout), to.Format(analyticsDateLayout)).
+		Group("article_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		viewsByArticle[row.ArticleID] = row.Views
+	}
+	return viewsByArticle, nil
+}
+
+// articleConversion representa os contatos gerados por um artigo e sua taxa de conversão
+type articleConversion struct {
+	ArticleID        uint     `json:"article_id"`
+	Title            string   `json:"title"`
+	Slug             string   `json:"slug"`
+	Category         string   `json:"category"`
+	Contacts         int64    `json:"contacts"`
+	PreviousContacts int64    `json:"previous_contacts"`
+	ChangePercent    *float64 `json:"change_percent"`
+	Views            int64    `json:"views"`
+	ConversionRate   float64  `json:"conversion_rate"`
+}
+
+// articleConversions calcula contatos e conversão (contatos / visualizações) por artigo
+func (h *AnalyticsHandler) articleConversions(period analyticsPeriod) ([]articleConversion, error) {
+	type articleCount struct {
+		ArticleID uint
+		Contacts  int64
+	}
+
+	var current []articleCount
+	if err := h.contactsBetween(period.From, period.To).
+		Select("article_id, COUNT(*) AS contacts").
+		Where("article_id IS NOT NULL").
+		Group("article_id").
+		Scan(&current).Error; err != nil {
+		return nil, err
+	}
+
+	var previous []articleCount
+	if err := h.contactsBetween(period.PreviousFrom, period.PreviousTo).
+		Select("article_id, COUNT(*) AS contacts").
+		Where("article_id IS NOT NULL").
+		Group("article_id").
+		Scan(&previous).Error; err != nil {
+		return nil, err
+	}
+	previousByArticle := make(map[uint]int64, len(previous))
+	for _, p := range previous {
+		previousByArticle[p.ArticleID] = p.Contacts
+	}
+
+	ids := make([]uint, 0, len(current))
+	for _, a := range current {
+		ids = append(ids, a.ArticleID)
+	}
+	var articles []models.Article
+	if len(ids) > 0 {
+		if err := h.db.Unscoped().Select("id, title, slug, category").Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			return nil, err
+		}
+	}
+	articlesByID := make(map[uint]models.Article, len(articles))
+	for _, article := range articles {
+		articlesByID[article.ID] = article
+	}
+
+	viewsByArticle, err := h.viewsBetween(ids, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+
+	conversions := make([]articleConversion, 0, len(current))
+	for _, a := range current {
+		article := articlesByID[a.ArticleID]
+		conversion := articleConversion{
+			ArticleID:        a.ArticleID,
+			Title:            article.Title,
+			Slug:             article.Slug,
+			Category:         article.Category,
+			Contacts:         a.Contacts,
+			PreviousContacts: previousByArticle[a.ArticleID],
+			ChangePercent:    percentChange(float64(a.Contacts), float64(previousByArticle[a.ArticleID])),
+			Views:            viewsByArticle[a.ArticleID],
+		}
+		if conversion.Views > 0 {
+			conversion.ConversionRate = float64(conversion.Contacts) / float64(conversion.Views) * 100
+		}
+		conversions = append(conversions, conversion)
+	}
+
+	return conversions, nil
+}
+
+// TopArticles retorna os artigos que mais geram contatos no período
+func (h *AnalyticsHandler) TopArticles(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	conversions, err := h.articleConversions(period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+		return
+	}
+
+	sort.SliceStable(conversions, func(i, j int) bool {
+		return conversions[i].Contacts > conversions[j].Contacts
+	})
+	if limit := parseAnalyticsLimit(c); len(conversions) > limit {
+		conversions = conversions[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":   periodJSON(period.From, period.To),
+		"articles": conversions,
+	})
+}
+
+// ArticleConversion retorna a taxa de conversão (contatos / visualizações no período) por artigo
+func (h *AnalyticsHandler) ArticleConversion(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	conversions, err := h.articleConversions(period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular conversão"})
+		return
+	}
+
+	sort.SliceStable(conversions, func(i, j int) bool {
+		return conversions[i].ConversionRate > conversions[j].ConversionRate
+	})
+	if limit := parseAnalyticsLimit(c); len(conversions) > limit {
+		conversions = conversions[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":   periodJSON(period.From, period.To),
+		"articles": conversions,
+	})
+}
+
+// ArticleViews retorna as visualizações e visitantes únicos por dia de um artigo
+func (h *AnalyticsHandler) ArticleViews(c *gin.Context) {
+	var article models.Article
+	if err := h.db.Select("id, title, slug, view_count").First(&article, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
+		return
+	}
+
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	var rows []models.ArticleView
+	if err := h.db.Where("article_id = ? AND date >= ? AND date < ?", article.ID,
+		period.PreviousFrom.Format(analyticsDateLayout), period.To.Format(analyticsDateLayout)).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar visualizações"})
+		return
+	}
+	byDate := make(map[string]models.ArticleView, len(rows))
+	for _, row := range rows {
+		byDate[row.Date] = row
+	}
+
+	type point struct {
+		Date           string `json:"date"`
+		Views          int    `json:"views"`
+		UniqueVisitors int    `json:"unique_visitors"`
+	}
+
+	series := make([]point, 0, period.days())
+	var views, uniqueVisitors, previousViews, previousUniqueVisitors int
+	for day := period.PreviousFrom; day.Before(period.To); day = day.AddDate(0, 0, 1) {
+		row := byDate[day.Format(analyticsDateLayout)]
+		if day.Before(period.From) {
+			previousViews += row.Views
+			previousUniqueVisitors += row.UniqueVisitors
+			continue
+		}
+		views += row.Views
+		uniqueVisitors += row.UniqueVisitors
+		series = append(series, point{
+			Date:           day.Format(analyticsDateLayout),
+			Views:          row.Views,
+			UniqueVisitors: row.UniqueVisitors,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"article": gin.H{
+			"id":         article.ID,
+			"title":      article.Title,
+			"slug":       article.Slug,
+			"view_count": article.ViewCount,
+		},
+		"period": periodJSON(period.From, period.To),
+		"series": series,
+		"totals": gin.H{
+			"views":                    views,
+			"unique_visitors":          uniqueVisitors,
+			"previous_views":           previousViews,
+			"previous_unique_visitors": previousUniqueVisitors,
+			"views_change_percent":     percentChange(float64(views), float64(previousViews)),
+		},
+	})
+}
+
+// ArticleEngagement retorna as métricas de engajamento de um artigo e sua evolução diária
+func (h *AnalyticsHandler) ArticleEngagement(c *gin.Context) {
+	var article models.Article
+	if err := h.db.Select("id, title, slug").First(&article, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
+		return
+	}
+
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	ids := []uint{article.ID}
+	current, err := tracking.LoadEngagementMetrics(h.db, ids, period.From, period.To)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular engajamento"})
+		return
+	}
+	previous, err := tracking.LoadEngagementMetrics(h.db, ids, period.PreviousFrom, period.PreviousTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular engajamento"})
+		return
+	}
+
+	var rows []models.ArticleEngagement
+	if err := h.db.Where("article_id = ? AND date >= ? AND date < ?", article.ID,
+		period.From.Format(analyticsDateLayout), period.To.Format(analyticsDateLayout)).
+		Order("date").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar eventos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"article": gin.H{
+			"id":    article.ID,
+			"title": article.Title,
+			"slug":  article.Slug,
+		},
+		"period":   periodJSON(period.From, period.To),
+		"metrics":  current[article.ID],
+		"previous": previous[article.ID],
+		"daily":    rows,
+	})
+}
+
+// Engagement retorna os artigos ordenados pelo score de engajamento no período
+func (h *AnalyticsHandler) Engagement(c *gin.Context) {
+	period, ok := parseAnalyticsPeriod(c)
+	if !ok {
+		return
+	}
+
+	metrics, err := tracking.LoadEngagementMetrics(h.db, nil, period.From, period.To)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular engajamento"})
+		return
+	}
+
+	ids := make([]uint, 0, len(metrics))
+	for id := range metrics {
+		ids = append(ids, id)
+	}
+	var articles []models.Article
+	if len(ids) > 0 {
+		if err := h.db.Select("id, title, slug, category").Where("id IN ?", ids).Find(&articles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+			return
+		}
+	}
+
+	type articleEngagement struct {
+		Title    string `json:"title"`
+		Slug     string `json:"slug"`
+		Category string `json:"category"`
+		tracking.EngagementMetrics
+	}
+
+	result := make([]articleEngagement, 0, len(articles))
+	for _, article := range articles {
+		result = append(result, articleEngagement{
+			Title:             article.Title,
+			Slug:              article.Slug,
+			Category:          article.Category,
+			EngagementMetrics: metrics[article.ID],
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	if limit := parseAnalyticsLimit(c); len(result) > limit {
+		result = result[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"period":   periodJSON(period.From, period.To),
+		"articles": result,
+	})
+}
@@ -3,18 +3,29 @@ package handlers
 import (
 	"net/http"
 	"ryv-api/database"
+	"ryv-api/locale"
 	"ryv-api/models"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetArticles retorna todos os artigos publicados
+// GetArticles retorna todos os artigos publicados no idioma do leitor (?lang= ou
+// Accept-Language). Artigos sem versão nesse idioma aparecem na versão em pt-BR.
 func GetArticles(c *gin.Context) {
 	var articles []models.Article
+	lang := requestLocale(c)
 	
 	query := database.DB.Where("is_published = ?", true).Order("published_at DESC")
 	
+	if lang == locale.Default {
+		query = query.Where("locale = ?", lang)
+	} else {
+		query = query.Where("locale = ? OR (locale = ? AND NOT EXISTS (SELECT 1 FROM articles t WHERE t.locale = ? AND t.is_published = ? AND t.deleted_at IS NULL AND "+
+			translationGroupSQL("t")+" = "+translationGroupSQL("articles")+"))", lang, locale.Default, lang, true)
+	}
+	
 	// Filtro por categoria
 	if category := c.Query("category"); category != "" {
 		query = query.Where("category = ?", category)
@@ -38,8 +49,16 @@ func GetArticles(c *gin.Context) {
 		return
 	}
 	
+	localized, err := localizeArticles(database.DB, articles, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+		return
+	}
+	
+	c.Header("Content-Language", lang)
 	c.JSON(http.StatusOK, gin.H{
-		"articles": articles,
+		"articles": localized,
+		"locale":   lang,
 		"pagination": gin.H{
 			"page":  page,
 			"limit": limit,
@@ -59,20 +78,24 @@ func GetArticle(c *gin.Context) {
 		return
 	}
 	
-	// Incrementar contador de visualizações
-	database.DB.Model(&article).Update("view_count", article.ViewCount+1)
+	// Registrar visualização (incremento atômico, sem bots)
+	recordArticleView(c, article)
 	
 	c.JSON(http.StatusOK, article)
 }
 
-// GetArticleByIDOrSlug retorna um artigo por ID ou slug
+// GetArticleByIDOrSlug retorna um artigo por ID ou slug. Pelo ID, ou com ?lang=, é servida
+// a versão no idioma do leitor e, se não houver, a versão em pt-BR; o slug identifica uma
+// versão específica. A resposta traz as versões em cada idioma (hreflang).
 func GetArticleByIDOrSlug(c *gin.Context) {
 	idOrSlug := c.Param("id_or_slug")
+	lang := requestLocale(c)
 	
 	var article models.Article
 	
 	// Primeiro, tenta buscar por ID (se for um número)
-	if id, err := strconv.Atoi(idOrSlug); err == nil {
+	id, idErr := strconv.Atoi(idOrSlug)
+	if idErr == nil {
 		// É um número, busca por ID
 		if err := database.DB.Where("id = ? AND is_published = ?", id, true).First(&article).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
@@ -86,27 +109,81 @@ func GetArticleByIDOrSlug(c *gin.Context) {
 		}
 	}
 	
-	// Incrementar contador de visualizações
-	database.DB.Model(&article).Update("view_count", article.ViewCount+1)
+	if idErr == nil || c.Query("lang") != "" {
+		if version, ok := articleInLocale(database.DB, article, lang); ok {
+			article = version
+		} else if version, ok := articleInLocale(database.DB, article, locale.Default); ok {
+			article = version
+		}
+	}
 	
-	c.JSON(http.StatusOK, article)
+	localized, err := localizeArticles(database.DB, []models.Article{article}, lang)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigo"})
+		return
+	}
+	
+	// Registrar visualização (incremento atômico, sem bots)
+	recordArticleView(c, article)
+	
+	c.Header("Content-Language", article.Locale)
+	c.JSON(http.StatusOK, localized[0])
 }
 
 // CreateArticle cria um novo artigo
 func CreateArticle(c *gin.Context) {
-	var article models.Article
+	var input articleInput
 	
-	if err := c.ShouldBindJSON(&article); err != nil {
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
 		return
 	}
+	article := input.Article
+	
+	canonical, status, message := checkArticleTranslation(&article)
+	if status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	
+	// Traduções herdam a categoria do artigo canônico; os demais artigos sem categoria são
+	// categorizados pelo conteúdo
+	if strings.TrimSpace(article.Category) == "" {
+		if canonical != nil {
+			article.Category = canonical.Category
+		} else if articleClassifier != nil {
+			article.Category = articleClassifier.Classify(article.Title, article.Excerpt, article.Content).Category
+		}
+	}
+	
+	// Markdown é convertido em HTML e o HTML passa pela política de sanitização; o que foi
+	// retirado volta na resposta
+	report, err := prepareContent(&article, input.ContentSource, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	if err := database.DB.Create(&article).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar artigo"})
 		return
 	}
 	
-	c.JSON(http.StatusCreated, article)
+	refreshRelated()
+	
+	c.JSON(http.StatusCreated, newAdminArticle(article, report))
+}
+
+// GetAdminArticle retorna um artigo para edição no painel, inclusive rascunhos e o
+// Markdown de origem
+func GetAdminArticle(c *gin.Context) {
+	var article models.Article
+	if err := database.DB.First(&article, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, newAdminArticle(article, nil))
 }
 
 // UpdateArticle atualiza um artigo existente
@@ -119,17 +196,33 @@ func UpdateArticle(c *gin.Context) {
 		return
 	}
 	
-	if err := c.ShouldBindJSON(&article); err != nil {
+	stored := article.Content
+	input := articleInput{Article: article}
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos"})
 		return
 	}
+	article = input.Article
+	
+	if _, status, message := checkArticleTranslation(&article); status != 0 {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	
+	report, err := prepareContent(&article, input.ContentSource, stored)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	
 	if err := database.DB.Save(&article).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar artigo"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, article)
+	refreshRelated()
+	
+	c.JSON(http.StatusOK, newAdminArticle(article, report))
 }
 
 // DeleteArticle remove um artigo
@@ -141,17 +234,128 @@ func DeleteArticle(c *gin.Context) {
 		return
 	}
 	
+	refreshRelated()
+	
 	c.JSON(http.StatusOK, gin.H{"message": "Artigo deletado com sucesso"})
 }
 
-// GetCategories retorna todas as categorias
+// checkArticleTranslation normaliza o idioma do artigo e, se ele for a tradução de outro,
+// confere o artigo canônico. Nos dois casos, confere que o grupo de traduções ainda não tem
+// outra versão no mesmo idioma. Retorna o artigo canônico, ou o status HTTP e a mensagem
+// do erro.
+func checkArticleTranslation(article *models.Article) (*models.Article, int, string) {
+	if strings.TrimSpace(article.Locale) == "" {
+		article.Locale = locale.Default
+	}
+	normalized, ok := locale.Normalize(article.Locale)
+	if !ok {
+		return nil, http.StatusBadRequest, "Idioma não suportado; use " + strings.Join(locale.Supported, ", ")
+	}
+	article.Locale = normalized
+	
+	if article.TranslationOfID == nil {
+		// Um artigo canônico que muda de idioma não pode repetir o de uma das traduções
+		if article.ID != 0 {
+			if status, message := checkGroupLocale(article, article.ID); status != 0 {
+				return nil, status, message
+			}
+		}
+		return nil, 0, ""
+	}
+	
+	if article.ID != 0 {
+		var translations int64
+		if err := database.DB.Model(&models.Article{}).Where("translation_of_id = ?", article.ID).Count(&translations).Error; err != nil {
+			return nil, http.StatusInternalServerError, "Erro ao verificar traduções do artigo"
+		}
+		if translations > 0 {
+			return nil, http.StatusBadRequest, "O artigo é canônico de outras traduções e não pode ser tradução de outro"
+		}
+	}
+	
+	var canonical models.Article
+	if err := database.DB.First(&canonical, *article.TranslationOfID).Error; err != nil {
+		return nil, http.StatusBadRequest, "Artigo canônico não encontrado"
+	}
+	// Uma tradução de tradução fica ligada ao mesmo artigo canônico
+	group := translationGroup(canonical)
+	if group == article.ID {
+		return nil, http.StatusBadRequest, "Um artigo não pode ser tradução de si mesmo"
+	}
+	if group != canonical.ID {
+		canonical = models.Article{}
+		if err := database.DB.First(&canonical, group).Error; err != nil {
+			return nil, http.StatusBadRequest, "Artigo canônico não encontrado"
+		}
+	}
+	article.TranslationOfID = &group
+	
+	if status, message := checkGroupLocale(article, group); status != 0 {
+		return nil, status, message
+	}
+	
+	return &canonical, 0, ""
+}
+
+// checkGroupLocale confere que nenhum outro artigo do grupo de traduções está no idioma do
+// artigo. Retorna o status HTTP e a mensagem do erro.
+func checkGroupLocale(article *models.Article, group uint) (int, string) {
+	var count int64
+	query := database.DB.Model(&models.Article{}).
+		Where("locale = ? AND "+translationGroupSQL("articles")+" = ?", article.Locale, group)
+	if article.ID != 0 {
+		query = query.Where("id <> ?", article.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return http.StatusInternalServerError, "Erro ao verificar traduções do artigo"
+	}
+	if count > 0 {
+		return http.StatusConflict, "O artigo já tem uma versão em " + article.Locale
+	}
+	return 0, ""
+}
+
+// localizedCategory é a categoria com o nome e a descrição no idioma do leitor. name
+// continua em português, porque é o valor usado no filtro de artigos.
+type localizedCategory struct {
+	models.Category
+	LocalizedName        string `json:"localized_name"`
+	LocalizedDescription string `json:"localized_description"`
+}
+
+// GetCategories retorna todas as categorias, com o nome no idioma do leitor
 func GetCategories(c *gin.Context) {
 	var categories []models.Category
+	lang := requestLocale(c)
 	
 	if err := database.DB.Find(&categories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar categorias"})
 		return
 	}
 	
-	c.JSON(http.StatusOK, categories)
+	var translations []models.CategoryTranslation
+	if lang != locale.Default {
+		if err := database.DB.Where("locale = ?", lang).Find(&translations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar categorias"})
+			return
+		}
+	}
+	translated := make(map[string]models.CategoryTranslation, len(translations))
+	for _, translation := range translations {
+		translated[translation.Category] = translation
+	}
+	
+	localized := make([]localizedCategory, len(categories))
+	for i, category := range categories {
+		localized[i] = localizedCategory{Category: category, LocalizedName: category.Name, LocalizedDescription: category.Description}
+		if translation, ok := translated[category.Name]; ok {
+			localized[i].LocalizedName = translation.Name
+			if translation.Description != "" {
+				localized[i].LocalizedDescription = translation.Description
+			}
+		}
+	}
+	
+	c.Header("Content-Language", lang)
+	c.JSON(http.StatusOK, localized)
 } 
\ No newline at end of file
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ryv-api/classifier"
+
+	"github.com/gin-gonic/gin"
+)
+
+// articleClassifier categoriza artigos criados sem categoria
+var articleClassifier *classifier.Classifier
+
+// SetClassifier define o classificador usado na criação de artigos
+func SetClassifier(c *classifier.Classifier) {
+	articleClassifier = c
+}
+
+type ClassifierHandler struct {
+	classifier *classifier.Classifier
+}
+
+func NewClassifierHandler(c *classifier.Classifier) *ClassifierHandler {
+	return &ClassifierHandler{classifier: c}
+}
+
+// ClassifyRequest é o texto a classificar; o conteúdo pode ser HTML
+type ClassifyRequest struct {
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt"`
+	Content string `json:"content"`
+}
+
+// ClassifyArticle retorna a probabilidade de cada categoria e as tags sugeridas para o texto
+func (h *ClassifierHandler) ClassifyArticle(c *gin.Context) {
+	var req ClassifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Title+req.Excerpt+req.Content) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Informe título, resumo ou conteúdo"})
+		return
+	}
+
+	result := h.classifier.Classify(req.Title, req.Excerpt, req.Content)
+	c.JSON(http.StatusOK, gin.H{
+		"category":      result.Category,
+		"probabilities": result.Probabilities,
+		"tags":          result.Tags,
+		"method":        result.Method,
+		"model":         h.classifier.Stats(),
+	})
+}
+
+// RetrainClassifier treina de novo o classificador com os artigos categorizados atuais
+func (h *ClassifierHandler) RetrainClassifier(c *gin.Context) {
+	stats, err := h.classifier.Train()
+	if err != nil && !errors.Is(err, classifier.ErrNotTrained) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao treinar classificador"})
+		return
+	}
+
+	response := gin.H{"model": stats}
+	if err != nil {
+		// O modelo fica registrado, mas a categorização continua pelas palavras-chave
+		response["warning"] = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+
+	"ryv-api/markdown"
+	"ryv-api/models"
+	"ryv-api/sanitize"
+)
+
+// articleInput é o corpo de criação e edição de artigos. Em artigos em Markdown, o texto
+// fica em content_source e content é sempre o HTML gerado; sem content_source, um content
+// novo (na criação ou diferente do HTML gravado) é aceito como Markdown.
+type articleInput struct {
+	models.Article
+	ContentSource *string `json:"content_source"`
+}
+
+// adminArticle é o artigo como o painel o vê: o Markdown de origem, o HTML gerado, o texto
+// original das traduções e o relatório do que a sanitização retirou
+type adminArticle struct {
+	models.Article
+	ContentSource   string           `json:"content_source,omitempty"`
+	OriginalTitle   string           `json:"original_title,omitempty"`
+	OriginalContent string           `json:"original_content,omitempty"`
+	Sanitized       *sanitize.Report `json:"sanitized,omitempty"`
+}
+
+func newAdminArticle(article models.Article, report *sanitize.Report) adminArticle {
+	return adminArticle{
+		Article:         article,
+		ContentSource:   article.ContentSource,
+		OriginalTitle:   article.OriginalTitle,
+		OriginalContent: article.OriginalContent,
+		Sanitized:       report,
+	}
+}
+
+// prepareContent gera o HTML dos artigos em Markdown, com âncoras nos títulos e o sumário,
+// e aplica a política de sanitização ao resultado. stored é o HTML já gravado do artigo
+// (vazio na criação): um content diferente dele, sem content_source, é o novo Markdown.
+func prepareContent(article *models.Article, source *string, stored string) (*sanitize.Report, error) {
+	format, ok := markdown.NormalizeFormat(article.ContentFormat)
+	if !ok {
+		return nil, fmt.Errorf("Formato de conteúdo inválido: use %s ou %s", markdown.FormatHTML, markdown.FormatMarkdown)
+	}
+	article.ContentFormat = format
+
+	if format == markdown.FormatHTML {
+		if source != nil && *source != "" {
+			return nil, fmt.Errorf("content_source só é aceito em artigos em %s", markdown.FormatMarkdown)
+		}
+		article.ContentSource = ""
+		article.TableOfContents = nil
+		return sanitizeArticle(article), nil
+	}
+
+	switch {
+	case source != nil:
+		article.ContentSource = *source
+	case article.ContentSource == "" || article.Content != stored:
+		// Sem isso, a edição de content seria desfeita pelo Markdown antigo
+		article.ContentSource = article.Content
+	}
+	content, headings, err := markdown.Render(article.ContentSource)
+	if err != nil {
+		return nil, fmt.Errorf("Erro ao converter Markdown: %w", err)
+	}
+	article.Content = content
+	article.TableOfContents = headings
+	return sanitizeArticle(article), nil
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ryv-api/experiments"
+	"ryv-api/locale"
+	"ryv-api/middleware"
+	"ryv-api/models"
+	"ryv-api/tracking"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultDailyPicksDays = 30
+	maxDailyPicksDays     = 365
+)
+
+var errNoPublishedArticles = errors.New("nenhum artigo publicado")
+
+// dailySeed gera uma semente estável a partir da data e de outras chaves
+func dailySeed(parts ...string) int64 {
+	hash := fnv.New64a()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return int64(hash.Sum64())
+}
+
+// dailyRand cria um gerador próprio da requisição, com a mesma sequência para as mesmas chaves.
+// Não usamos o gerador global: rand.Seed é compartilhado entre goroutines e está obsoleto.
+func dailyRand(parts ...string) *rand.Rand {
+	return rand.New(rand.NewSource(dailySeed(parts...)))
+}
+
+// stableFloat retorna um número entre 0 e 1 determinado pelas chaves informadas
+func stableFloat(parts ...string) float64 {
+	return float64(uint64(dailySeed(parts...))>>11) / (1 << 53)
+}
+
+// dailyPick retorna o artigo do dia. Na primeira chamada do dia o artigo é escolhido pela
+// pontuação, sem repetir os artigos dos últimos NoRepeatDays dias, e gravado no histórico.
+func (h *RecommendationHandler) dailyPick(date string, config scoringConfig) (models.Article, error) {
+	var article models.Article
+
+	var pick models.DailyPick
+	err := h.db.Where("date = ?", date).First(&pick).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return article, err
+	}
+	existing := err == nil
+	if existing {
+		err = h.db.Where("id = ? AND is_published = ?", pick.ArticleID, true).First(&article).Error
+		if err == nil {
+			return article, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return article, err
+		}
+		// O artigo escolhido foi despublicado ou removido: escolhe outro para o dia
+	}
+
+	var articles []models.Article
+	if err := h.db.Where("is_published = ? AND locale = ?", true, locale.Default).Order("id").Find(&articles).Error; err != nil {
+		return article, err
+	}
+	if len(articles) == 0 {
+		return article, errNoPublishedArticles
+	}
+
+	candidates, err := h.withoutRecentPicks(articles, date, config.Settings.NoRepeatDays)
+	if err != nil {
+		return article, err
+	}
+
+	engagement, err := h.recentEngagement()
+	if err != nil {
+		return article, err
+	}
+
+	article = h.selectIntelligentRecommendation(candidates, engagement, config, date)
+
+	if existing {
+		err := h.db.Model(&pick).Updates(map[string]interface{}{
+			"article_id": article.ID,
+			"pinned":     false,
+		}).Error
+		return article, err
+	}
+
+	// Requisições simultâneas podem escolher ao mesmo tempo; vale a primeira gravada
+	pick = models.DailyPick{Date: date, ArticleID: article.ID}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&pick).Error; err != nil {
+		return article, err
+	}
+	if err := h.db.Where("date = ?", date).First(&pick).Error; err != nil {
+		return article, err
+	}
+	if pick.ArticleID != article.ID {
+		// Outra requisição gravou primeiro: todas devem ver o mesmo artigo do dia
+		var stored models.Article
+		if err := h.db.First(&stored, pick.ArticleID).Error; err != nil {
+			return article, err
+		}
+		return stored, nil
+	}
+
+	return article, nil
+}
+
+// dailyExperimentVariant retorna o experimento da recomendação diária em execução e a
+// variante do leitor. Falhas no experimento não impedem a recomendação padrão.
+func (h *RecommendationHandler) dailyExperimentVariant(c *gin.Context) (*models.Experiment, models.ExperimentVariant) {
+	readerID := middleware.GetReaderID(c)
+	if readerID == "" {
+		return nil, models.ExperimentVariant{}
+	}
+
+	experiment, err := experiments.Running(h.db, experiments.TargetDailyRecommendation)
+	if err != nil || experiment == nil {
+		if err != nil {
+			log.Printf("Erro ao buscar experimento da recomendação diária: %v", err)
+		}
+		return nil, models.ExperimentVariant{}
+	}
+
+	variant, err := experiments.Expose(h.db, experiment, readerID)
+	if err != nil {
+		log.Printf("Erro ao atribuir variante do experimento %s: %v", experiment.Key, err)
+		return nil, models.ExperimentVariant{}
+	}
+	return experiment, variant
+}
+
+// recommendationForVariant escolhe o artigo do dia de acordo com a estratégia da variante.
+// Sem experimento, ou na estratégia padrão, vale o artigo do dia guardado no histórico.
+func (h *RecommendationHandler) recommendationForVariant(date string, config scoringConfig, variant models.ExperimentVariant) (models.Article, error) {
+	var article models.Article
+
+	query := h.db.Where("is_published = ? AND locale = ?", true, locale.Default)
+	switch variant.Strategy {
+	case experiments.StrategyLatest:
+		query = query.Order("published_at IS NULL, published_at DESC, id DESC")
+	case experiments.StrategyPopular:
+		query = query.Order("view_count DESC, id DESC")
+	default:
+		return h.dailyPick(date, config)
+	}
+
+	err := query.First(&article).Error
+	if err == gorm.ErrRecordNotFound {
+		return article, errNoPublishedArticles
+	}
+	return article, err
+}
+
+// withoutRecentPicks remove os artigos que já foram do dia nos últimos days dias.
+// Se todos já tiverem sido escolhidos, a lista completa é mantida.
+func (h *RecommendationHandler) withoutRecentPicks(articles []models.Article, date string, days int) ([]models.Article, error) {
+	if days <= 0 {
+		return articles, nil
+	}
+
+	day, err := time.ParseInLocation(tracking.DateLayout, date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	from := day.AddDate(0, 0, -days).Format(tracking.DateLayout)
+
+	var recentIDs []uint
+	if err := h.db.Model(&models.DailyPick{}).
+		Where("date >= ? AND date < ?", from, date).
+		Pluck("article_id", &recentIDs).Error; err != nil {
+		return nil, err
+	}
+	recent := make(map[uint]bool, len(recentIDs))
+	for _, id := range recentIDs {
+		recent[id] = true
+	}
+
+	candidates := make([]models.Article, 0, len(articles))
+	for _, article := range articles {
+		if !recent[article.ID] {
+			candidates = append(candidates, article)
+		}
+	}
+	if len(candidates) == 0 {
+		return articles, nil
+	}
+	return candidates, nil
+}
+
+// dailyPickResponse representa um dia do histórico de recomendações no painel
+type dailyPickResponse struct {
+	Date      string `json:"date"`
+	ArticleID uint   `json:"article_id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Pinned    bool   `json:"pinned"`
+}
+
+// ListDailyPicks retorna o histórico dos artigos do dia e os dias já fixados no futuro
+func (h *RecommendationHandler) ListDailyPicks(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(defaultDailyPicksDays)))
+	if err != nil || days < 1 {
+		days = defaultDailyPicksDays
+	}
+	if days > maxDailyPicksDays {
+		days = maxDailyPicksDays
+	}
+	from := time.Now().AddDate(0, 0, -days+1).Format(tracking.DateLayout)
+
+	var picks []models.DailyPick
+	if err := h.db.Where("date >= ?", from).Order("date DESC").Find(&picks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico de recomendações"})
+		return
+	}
+
+	articleIDs := make([]uint, 0, len(picks))
+	for _, pick := range picks {
+		articleIDs = append(articleIDs, pick.ArticleID)
+	}
+	var articles []models.Article
+	if len(articleIDs) > 0 {
+		if err := h.db.Unscoped().Select("id, title, slug").Where("id IN ?", articleIDs).Find(&articles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+			return
+		}
+	}
+	articlesByID := make(map[uint]models.Article, len(articles))
+	for _, article := range articles {
+		articlesByID[article.ID] = article
+	}
+
+	response := make([]dailyPickResponse, 0, len(picks))
+	for _, pick := range picks {
+		article := articlesByID[pick.ArticleID]
+		response = append(response, dailyPickResponse{
+			Date:      pick.Date,
+			ArticleID: pick.ArticleID,
+			Title:     article.Title,
+			Slug:      article.Slug,
+			Pinned:    pick.Pinned,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"picks": response,
+	})
+}
+
+// PinDailyPickRequest estrutura para fixar o artigo de um dia
+type PinDailyPickRequest struct {
+	ArticleID uint `json:"article_id" binding:"required"`
+}
+
+// PinDailyPick fixa um artigo publicado como recomendação de uma data (hoje ou futura)
+func (h *RecommendationHandler) PinDailyPick(c *gin.Context) {
+	date, ok := parsePickDate(c)
+	if !ok {
+		return
+	}
+
+	var req PinDailyPickRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	var article models.Article
+	if err := h.db.Where("id = ? AND is_published = ?", req.ArticleID, true).First(&article).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Artigo não encontrado ou não publicado"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigo"})
+		return
+	}
+
+	pick := models.DailyPick{Date: date, ArticleID: article.ID, Pinned: true}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"article_id": article.ID,
+			"pinned":     true,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&pick).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao fixar recomendação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dailyPickResponse{
+		Date:      date,
+		ArticleID: article.ID,
+		Title:     article.Title,
+		Slug:      article.Slug,
+		Pinned:    true,
+	})
+}
+
+// UnpinDailyPick remove o artigo fixado de uma data; a recomendação volta a ser calculada
+func (h *RecommendationHandler) UnpinDailyPick(c *gin.Context) {
+	date, ok := parsePickDate(c)
+	if !ok {
+		return
+	}
+
+	result := h.db.Where("date = ? AND pinned = ?", date, true).Delete(&models.DailyPick{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover recomendação fixada"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Nenhuma recomendação fixada nesta data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Recomendação fixada removida"})
+}
+
+// parsePickDate valida a data da URL (YYYY-MM-DD), que não pode estar no passado
+func parsePickDate(c *gin.Context) (string, bool) {
+	date := c.Param("date")
+	if _, err := time.ParseInLocation(tracking.DateLayout, date, time.Local); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data inválida, use YYYY-MM-DD"})
+		return "", false
+	}
+	if date < time.Now().Format(tracking.DateLayout) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não é possível alterar a recomendação de dias anteriores"})
+		return "", false
+	}
+	return date, true
+}
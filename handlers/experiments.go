@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ryv-api/experiments"
+	"ryv-api/middleware"
+	"ryv-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var experimentKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type ExperimentHandler struct {
+	db *gorm.DB
+}
+
+func NewExperimentHandler(db *gorm.DB) *ExperimentHandler {
+	return &ExperimentHandler{db: db}
+}
+
+// ExperimentVariantRequest estrutura de uma variante na criação do experimento
+type ExperimentVariantRequest struct {
+	Key            string `json:"key" binding:"required"`
+	Name           string `json:"name"`
+	Weight         int    `json:"weight" binding:"omitempty,min=1,max=100"`
+	IsControl      bool   `json:"is_control"`
+	Strategy       string `json:"strategy"`
+	HideMotivation bool   `json:"hide_motivation"`
+	Motivation     string `json:"motivation"`
+}
+
+// CreateExperimentRequest estrutura para criação de um experimento
+type CreateExperimentRequest struct {
+	Key         string                     `json:"key" binding:"required"`
+	Name        string                     `json:"name" binding:"required"`
+	Description string                     `json:"description"`
+	Target      string                     `json:"target"`
+	Variants    []ExperimentVariantRequest `json:"variants" binding:"required,min=2,dive"`
+}
+
+// ListExperiments lista os experimentos com suas variantes
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	var list []models.Experiment
+	if err := h.db.Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		Order("created_at DESC").Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar experimentos"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"experiments": list,
+	})
+}
+
+// CreateExperiment cria um experimento em rascunho; ele só é aplicado depois de iniciado
+func (h *ExperimentHandler) CreateExperiment(c *gin.Context) {
+	var req CreateExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	experiment := models.Experiment{
+		Key:         strings.ToLower(strings.TrimSpace(req.Key)),
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Target:      req.Target,
+		Status:      experiments.StatusDraft,
+	}
+	if experiment.Target == "" {
+		experiment.Target = experiments.TargetDailyRecommendation
+	}
+	if !experimentKeyPattern.MatchString(experiment.Key) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Chave inválida, use letras minúsculas, números, - e _"})
+		return
+	}
+	if !experiments.ValidTarget(experiment.Target) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Local do experimento inválido"})
+		return
+	}
+
+	keys := make(map[string]bool, len(req.Variants))
+	controls := 0
+	for _, v := range req.Variants {
+		variant := models.ExperimentVariant{
+			Key:            strings.ToLower(strings.TrimSpace(v.Key)),
+			Name:           strings.TrimSpace(v.Name),
+			Weight:         v.Weight,
+			IsControl:      v.IsControl,
+			Strategy:       v.Strategy,
+			HideMotivation: v.HideMotivation,
+			Motivation:     strings.TrimSpace(v.Motivation),
+		}
+		if variant.Weight == 0 {
+			variant.Weight = 1
+		}
+		if variant.Strategy == "" {
+			variant.Strategy = experiments.StrategyScoring
+		}
+		if !experimentKeyPattern.MatchString(variant.Key) || keys[variant.Key] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Chave de variante inválida ou repetida: " + v.Key})
+			return
+		}
+		if !experiments.ValidStrategy(variant.Strategy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Estratégia inválida: " + variant.Strategy})
+			return
+		}
+		if variant.IsControl {
+			controls++
+		}
+		keys[variant.Key] = true
+		experiment.Variants = append(experiment.Variants, variant)
+	}
+	if controls > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Apenas uma variante pode ser o controle"})
+		return
+	}
+	// Sem controle definido, a primeira variante é a base de comparação
+	if controls == 0 {
+		experiment.Variants[0].IsControl = true
+	}
+
+	var count int64
+	h.db.Model(&models.Experiment{}).Where("key = ?", experiment.Key).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Já existe um experimento com esta chave"})
+		return
+	}
+
+	if err := h.db.Create(&experiment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar experimento"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, experiment)
+}
+
+// UpdateExperimentStatusRequest estrutura para iniciar ou encerrar um experimento
+type UpdateExperimentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=running stopped"`
+}
+
+// UpdateExperimentStatus inicia (running) ou encerra (stopped) um experimento
+func (h *ExperimentHandler) UpdateExperimentStatus(c *gin.Context) {
+	experiment, ok := h.findExperiment(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateExperimentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	now := time.Now()
+	switch req.Status {
+	case experiments.StatusRunning:
+		if experiment.Status != experiments.StatusDraft {
+			c.JSON(http.StatusConflict, gin.H{"error": "Apenas experimentos em rascunho podem ser iniciados"})
+			return
+		}
+		// Dois experimentos no mesmo local disputariam os mesmos leitores
+		var running int64
+		h.db.Model(&models.Experiment{}).
+			Where("target = ? AND status = ?", experiment.Target, experiments.StatusRunning).
+			Count(&running)
+		if running > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Já existe um experimento em execução neste local"})
+			return
+		}
+		experiment.StartedAt = &now
+	case experiments.StatusStopped:
+		if experiment.Status != experiments.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "Apenas experimentos em execução podem ser encerrados"})
+			return
+		}
+		experiment.EndedAt = &now
+	}
+	experiment.Status = req.Status
+
+	if err := h.db.Model(&experiment).Select("status", "started_at", "ended_at").Updates(&experiment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar experimento"})
+		return
+	}
+
+	c.JSON(http.StatusOK, experiment)
+}
+
+// ExperimentResults retorna CTR e conversão de cada variante, com a significância
+// estatística da diferença em relação ao controle
+func (h *ExperimentHandler) ExperimentResults(c *gin.Context) {
+	experiment, ok := h.findExperiment(c)
+	if !ok {
+		return
+	}
+
+	results, err := experiments.Results(h.db, &experiment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular resultados"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"experiment":         experiment,
+		"variants":           results,
+		"significance_level": experiments.SignificanceLevel,
+	})
+}
+
+func (h *ExperimentHandler) findExperiment(c *gin.Context) (models.Experiment, bool) {
+	var experiment models.Experiment
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return experiment, false
+	}
+
+	if err := h.db.Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).
+		First(&experiment, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Experimento não encontrado"})
+			return experiment, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar experimento"})
+		return experiment, false
+	}
+
+	return experiment, true
+}
+
+// RecordExperimentClick registra o clique do leitor no conteúdo exibido pelo experimento
+func (h *ExperimentHandler) RecordExperimentClick(c *gin.Context) {
+	readerID := middleware.GetReaderID(c)
+	if readerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Leitor não identificado"})
+		return
+	}
+
+	err := experiments.RecordClick(h.db, c.Param("key"), readerID)
+	switch err {
+	case nil:
+		c.JSON(http.StatusAccepted, gin.H{"message": "Clique registrado"})
+	case gorm.ErrRecordNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Experimento não encontrado ou encerrado"})
+	case experiments.ErrNotAssigned:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar clique"})
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/scheduler"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultJobRunsLimit = 20
+	maxJobRunsLimit     = 100
+	recentJobRuns       = 10
+)
+
+// jobRunColumns são as colunas do histórico sem o log, que só vem no detalhe da execução
+var jobRunColumns = []string{"id", "job_name", "trigger", "instance", "status", "started_at", "finished_at", "duration_ms", "items", "error", "created_at"}
+
+type JobHandler struct {
+	db        *gorm.DB
+	scheduler *scheduler.Scheduler
+}
+
+func NewJobHandler(db *gorm.DB, scheduler *scheduler.Scheduler) *JobHandler {
+	return &JobHandler{db: db, scheduler: scheduler}
+}
+
+// ListJobs retorna as tarefas periódicas com a agenda e o resultado da última execução
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	var jobs []models.ScheduledJob
+	if err := h.db.Order("name").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar tarefas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob retorna uma tarefa e as execuções mais recentes
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, ok := h.findJob(c)
+	if !ok {
+		return
+	}
+
+	var runs []models.JobRun
+	if err := h.db.Select(jobRunColumns).Where("job_name = ?", job.Name).
+		Order("started_at DESC").Limit(recentJobRuns).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar execuções"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job, "runs": runs})
+}
+
+// UpdateJobRequest estrutura para alterar a agenda de uma tarefa; campos omitidos são mantidos
+type UpdateJobRequest struct {
+	Schedule *string `json:"schedule"`
+	Enabled  *bool   `json:"enabled"`
+}
+
+// UpdateJob altera a agenda ou a habilitação de uma tarefa e recalcula a próxima execução
+func (h *JobHandler) UpdateJob(c *gin.Context) {
+	var req UpdateJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	job, ok := h.findJob(c)
+	if !ok {
+		return
+	}
+
+	if req.Schedule != nil {
+		job.Schedule = strings.TrimSpace(*req.Schedule)
+	}
+	if req.Enabled != nil {
+		job.Enabled = *req.Enabled
+	}
+	schedule, err := scheduler.ParseSchedule(job.Schedule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Agenda inválida: " + err.Error()})
+		return
+	}
+	next := schedule.Next(time.Now())
+	job.NextRunAt = &next
+
+	if err := h.db.Model(&job).Updates(map[string]interface{}{
+		"schedule":    job.Schedule,
+		"enabled":     job.Enabled,
+		"next_run_at": job.NextRunAt,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar tarefa"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// RunJob dispara agora uma execução da tarefa, em segundo plano
+func (h *JobHandler) RunJob(c *gin.Context) {
+	run, err := h.scheduler.Trigger(c.Param("name"))
+	if err != nil {
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tarefa não encontrada"})
+		case errors.Is(err, scheduler.ErrJobLocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao iniciar tarefa"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListJobRuns retorna o histórico de execuções de uma tarefa. Filtro: status.
+func (h *JobHandler) ListJobRuns(c *gin.Context) {
+	job, ok := h.findJob(c)
+	if !ok {
+		return
+	}
+
+	query := h.db.Model(&models.JobRun{}).Where("job_name = ?", job.Name)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultJobRunsLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultJobRunsLimit
+	}
+	if limit > maxJobRunsLimit {
+		limit = maxJobRunsLimit
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar execuções"})
+		return
+	}
+
+	var runs []models.JobRun
+	if err := query.Select(jobRunColumns).Order("started_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar execuções"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs": runs,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (int(total) + limit - 1) / limit,
+		},
+	})
+}
+
+// GetJobRun retorna uma execução com o log completo
+func (h *JobHandler) GetJobRun(c *gin.Context) {
+	var run models.JobRun
+	if err := h.db.Where("job_name = ?", c.Param("name")).First(&run, c.Param("run_id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Execução não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+func (h *JobHandler) findJob(c *gin.Context) (models.ScheduledJob, bool) {
+	var job models.ScheduledJob
+	if err := h.db.Where("name = ?", c.Param("name")).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tarefa não encontrada"})
+		return job, false
+	}
+	return job, true
+}
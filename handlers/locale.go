@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"ryv-api/locale"
+	"ryv-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// articleAlternate é uma versão do artigo em um idioma, para as tags hreflang do frontend
+type articleAlternate struct {
+	Hreflang string `json:"hreflang"` // idioma ou x-default (versão canônica em pt-BR)
+	Locale   string `json:"locale"`
+	ID       uint   `json:"id"`
+	Slug     string `json:"slug"`
+}
+
+// localizedArticle é o artigo com o nome da categoria no idioma do leitor e as versões em
+// outros idiomas
+type localizedArticle struct {
+	models.Article
+	CategoryName string             `json:"category_name"`
+	Alternates   []articleAlternate `json:"alternates"`
+	Image        *responsiveImage   `json:"image,omitempty"` // capa enviada à biblioteca de mídia, com as versões para o srcset
+}
+
+// requestLocale escolhe o idioma da resposta pelo parâmetro lang ou pelo cabeçalho
+// Accept-Language, com pt-BR como padrão
+func requestLocale(c *gin.Context) string {
+	c.Header("Vary", "Accept-Language")
+	return locale.Negotiate(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// translationGroup é o artigo canônico que reúne as versões do artigo em cada idioma
+func translationGroup(article models.Article) uint {
+	if article.TranslationOfID != nil {
+		return *article.TranslationOfID
+	}
+	return article.ID
+}
+
+// translationGroupSQL é a mesma regra de translationGroup em SQL, para a tabela informada
+func translationGroupSQL(table string) string {
+	return "COALESCE(" + table + ".translation_of_id, " + table + ".id)"
+}
+
+// articleInLocale procura a versão publicada do artigo no idioma pedido
+func articleInLocale(db *gorm.DB, article models.Article, lang string) (models.Article, bool) {
+	if article.Locale == lang {
+		return article, true
+	}
+
+	var translation models.Article
+	group := translationGroup(article)
+	err := db.Where("is_published = ? AND locale = ?", true, lang).
+		Where(translationGroupSQL("articles")+" = ?", group).
+		First(&translation).Error
+	return translation, err == nil
+}
+
+// categoryNames retorna o nome de cada categoria no idioma pedido. Categorias sem tradução
+// ficam com o nome em português.
+func categoryNames(db *gorm.DB, lang string) (map[string]string, error) {
+	names := make(map[string]string)
+	if lang == locale.Default {
+		return names, nil
+	}
+
+	var translations []models.CategoryTranslation
+	if err := db.Where("locale = ?", lang).Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	for _, translation := range translations {
+		names[translation.Category] = translation.Name
+	}
+	return names, nil
+}
+
+// localizeArticles acrescenta aos artigos o nome da categoria no idioma pedido, as versões
+// publicadas em cada idioma e o srcset da imagem de capa
+func localizeArticles(db *gorm.DB, articles []models.Article, lang string) ([]localizedArticle, error) {
+	names, err := categoryNames(db, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]uint, 0, len(articles))
+	imageURLs := make([]string, 0, len(articles))
+	for _, article := range articles {
+		groups = append(groups, translationGroup(article))
+		if article.ImageURL != "" {
+			imageURLs = append(imageURLs, article.ImageURL)
+		}
+	}
+	images, err := responsiveImages(db, imageURLs)
+	if err != nil {
+		return nil, err
+	}
+	var versions []models.Article
+	if len(groups) > 0 {
+		if err := db.Select("id, slug, locale, translation_of_id").
+			Where("is_published = ?", true).
+			Where(translationGroupSQL("articles")+" IN ?", groups).
+			Find(&versions).Error; err != nil {
+			return nil, err
+		}
+	}
+	alternates := make(map[uint][]articleAlternate)
+	for _, supported := range locale.Supported {
+		for _, version := range versions {
+			if version.Locale != supported {
+				continue
+			}
+			group := translationGroup(version)
+			alternates[group] = append(alternates[group], articleAlternate{Hreflang: version.Locale, Locale: version.Locale, ID: version.ID, Slug: version.Slug})
+			if version.Locale == locale.Default {
+				alternates[group] = append(alternates[group], articleAlternate{Hreflang: "x-default", Locale: version.Locale, ID: version.ID, Slug: version.Slug})
+			}
+		}
+	}
+
+	localized := make([]localizedArticle, len(articles))
+	for i, article := range articles {
+		name := names[article.Category]
+		if name == "" {
+			name = article.Category
+		}
+		articleAlternates := alternates[translationGroup(article)]
+		if articleAlternates == nil {
+			articleAlternates = []articleAlternate{}
+		}
+		localized[i] = localizedArticle{Article: article, CategoryName: name, Alternates: articleAlternates, Image: images[article.ImageURL]}
+	}
+	return localized, nil
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"ryv-api/imaging"
+	"ryv-api/models"
+	"ryv-api/storage"
+
+	"github.com/gin-gonic/gin"
+	_ "golang.org/x/image/webp"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultMaxMediaSize é o tamanho máximo de um arquivo enviado, se MEDIA_MAX_SIZE não for definido
+	DefaultMaxMediaSize = 10 << 20
+	// maxMediaPixels recusa imagens grandes demais para processar (acima de ~7000x7000)
+	maxMediaPixels    = 50_000_000
+	defaultMediaLimit = 30
+	maxMediaLimit     = 100
+)
+
+// mediaTypes são os formatos de imagem aceitos, com a extensão usada no arquivo gravado
+var mediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type MediaHandler struct {
+	db          *gorm.DB
+	storage     storage.Storage
+	maxSize     int64
+	resizeSlots chan struct{}
+}
+
+func NewMediaHandler(db *gorm.DB, store storage.Storage, maxSize int64) *MediaHandler {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMediaSize
+	}
+	return &MediaHandler{db: db, storage: store, maxSize: maxSize, resizeSlots: make(chan struct{}, resizeConcurrency)}
+}
+
+// mediaReference é um artigo que usa o arquivo, como imagem de capa ou no conteúdo
+type mediaReference struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+// UploadMedia recebe uma imagem (multipart, campo "file", com "alt_text" opcional), grava
+// no armazenamento e registra na biblioteca
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	// Folga para os demais campos do formulário
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+1<<20)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Arquivo maior que " + formatBytes(h.maxSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Envie a imagem no campo file"})
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Arquivo maior que " + formatBytes(h.maxSize)})
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao ler arquivo"})
+		return
+	}
+	if int64(len(data)) > h.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Arquivo maior que " + formatBytes(h.maxSize)})
+		return
+	}
+
+	// O tipo vem do conteúdo do arquivo, não do nome nem do cabeçalho enviado
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Formato não suportado: envie JPEG, PNG, GIF ou WebP"})
+		return
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Imagem inválida ou corrompida"})
+		return
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxMediaPixels {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dimensões da imagem não suportadas"})
+		return
+	}
+
+	key, err := newMediaKey(ext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gravar arquivo"})
+		return
+	}
+	if err := h.storage.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		log.Printf("Erro ao gravar mídia %s: %v", key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao gravar arquivo no armazenamento"})
+		return
+	}
+
+	media := models.Media{
+		Key:         key,
+		Storage:     h.storage.Name(),
+		URL:         h.storage.URL(key),
+		FileName:    filepath.Base(header.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+		AltText:     strings.TrimSpace(c.PostForm("alt_text")),
+	}
+	if userID := c.GetUint("user_id"); userID != 0 {
+		media.UploadedBy = &userID
+	}
+	if err := h.db.Create(&media).Error; err != nil {
+		// Sem o registro, o arquivo ficaria órfão no armazenamento
+		if err := h.storage.Delete(c.Request.Context(), key); err != nil {
+			log.Printf("Erro ao apagar mídia %s: %v", key, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar mídia"})
+		return
+	}
+
+	// Versões menores para o srcset; sem elas, a imagem continua disponível no tamanho original
+	if img, err := imaging.Decode(bytes.NewReader(data)); err != nil {
+		log.Printf("Erro ao ler mídia %s para gerar versões: %v", key, err)
+	} else {
+		h.generateVariants(c.Request.Context(), &media, img)
+	}
+
+	c.JSON(http.StatusCreated, media)
+}
+
+// ListMedia lista a biblioteca, dos arquivos mais recentes para os mais antigos. q busca
+// no nome do arquivo e no texto alternativo.
+func (h *MediaHandler) ListMedia(c *gin.Context) {
+	query := h.db.Model(&models.Media{})
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		query = query.Where("file_name LIKE ? OR alt_text LIKE ?", "%"+search+"%", "%"+search+"%")
+	}
+	if contentType := c.Query("content_type"); contentType != "" {
+		query = query.Where("content_type = ?", contentType)
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultMediaLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultMediaLimit
+	}
+	if limit > maxMediaLimit {
+		limit = maxMediaLimit
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar mídia"})
+		return
+	}
+
+	var media []models.Media
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar mídia"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media": media,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (int(total) + limit - 1) / limit,
+		},
+	})
+}
+
+// GetMedia retorna um arquivo da biblioteca com os artigos que o usam
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	media, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	references, err := h.references(media)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos que usam a mídia"})
+		return
+	}
+	if err := h.db.Where("media_id = ?", media.ID).Order("srcset DESC, format, width").Find(&media.Variants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar mídia"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": media, "articles": references})
+}
+
+// UpdateMedia altera o texto alternativo do arquivo
+func (h *MediaHandler) UpdateMedia(c *gin.Context) {
+	media, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AltText *string `json:"alt_text"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+	if req.AltText != nil {
+		media.AltText = strings.TrimSpace(*req.AltText)
+	}
+
+	if err := h.db.Save(&media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar mídia"})
+		return
+	}
+
+	c.JSON(http.StatusOK, media)
+}
+
+// DeleteMedia apaga o arquivo e as suas versões do armazenamento e da biblioteca. Arquivos usados por algum
+// artigo não são apagados (409, com a lista dos artigos).
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	media, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	references, err := h.references(media)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos que usam a mídia"})
+		return
+	}
+	if len(references) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "Mídia usada em artigos; troque a imagem nesses artigos antes de apagá-la",
+			"articles": references,
+		})
+		return
+	}
+
+	if err := h.deleteVariants(media); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover versões da mídia"})
+		return
+	}
+	if err := h.storage.Delete(c.Request.Context(), media.Key); err != nil {
+		log.Printf("Erro ao apagar mídia %s: %v", media.Key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao apagar arquivo do armazenamento"})
+		return
+	}
+	if err := h.db.Delete(&media).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover mídia"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Mídia removida com sucesso"})
+}
+
+// references busca os artigos (inclusive rascunhos) que usam o arquivo como imagem de capa
+// ou no conteúdo, pelo endereço do original, de uma das versões ou de /media/:id
+func (h *MediaHandler) references(media models.Media) ([]mediaReference, error) {
+	var urls []string
+	if err := h.db.Model(&models.MediaVariant{}).Where("media_id = ? AND url <> ''", media.ID).Pluck("url", &urls).Error; err != nil {
+		return nil, err
+	}
+	if media.URL != "" {
+		urls = append(urls, media.URL)
+	}
+
+	// O LIKE de /media/:id também pega outros ids com o mesmo início (/media/12 em
+	// /media/123); a conferência exata é feita abaixo
+	mediaPath := "/media/" + strconv.FormatUint(uint64(media.ID), 10)
+	patterns := []string{"%" + escapeLike(mediaPath) + "%"}
+	for _, url := range urls {
+		patterns = append(patterns, "%"+escapeLike(url)+"%")
+	}
+	conditions := h.db.Where("image_url IN ?", urls)
+	for _, pattern := range patterns {
+		for _, column := range []string{"image_url", "content", "content_source", "original_content"} {
+			conditions = conditions.Or(column+" LIKE ? ESCAPE '\\'", pattern)
+		}
+	}
+
+	var candidates []struct {
+		ID              uint
+		Title           string
+		Slug            string
+		ImageURL        string
+		Content         string
+		ContentSource   string
+		OriginalContent string
+	}
+	if err := h.db.Model(&models.Article{}).Select("id, title, slug, image_url, content, content_source, original_content").
+		Where(conditions).Order("id").Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	mediaPathPattern := regexp.MustCompile(regexp.QuoteMeta(mediaPath) + `([^0-9]|$)`)
+	references := []mediaReference{}
+	for _, candidate := range candidates {
+		fields := []string{candidate.ImageURL, candidate.Content, candidate.ContentSource, candidate.OriginalContent}
+		used := false
+		for _, field := range fields {
+			if mediaPathPattern.MatchString(field) || slices.ContainsFunc(urls, func(url string) bool { return strings.Contains(field, url) }) {
+				used = true
+				break
+			}
+		}
+		if used {
+			references = append(references, mediaReference{ID: candidate.ID, Title: candidate.Title, Slug: candidate.Slug})
+		}
+	}
+	return references, nil
+}
+
+func (h *MediaHandler) findMedia(c *gin.Context) (models.Media, bool) {
+	var media models.Media
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return media, false
+	}
+
+	if err := h.db.First(&media, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Mídia não encontrada"})
+			return media, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar mídia"})
+		return media, false
+	}
+	return media, true
+}
+
+// newMediaKey gera o caminho de um arquivo novo, agrupado por mês ("2026/10/9f86d0...jpg")
+func newMediaKey(ext string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return time.Now().Format("2006/01/") + hex.EncodeToString(random) + ext, nil
+}
+
+// escapeLike protege os curingas do LIKE (usado com ESCAPE '\')
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// formatBytes descreve um tamanho em MB, para mensagens de erro
+func formatBytes(size int64) string {
+	return strconv.FormatFloat(float64(size)/(1<<20), 'f', -1, 64) + " MB"
+}
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"ryv-api/imaging"
+	"ryv-api/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// mediaCacheControl permite que navegadores e CDNs guardem as imagens por um ano: o
+	// arquivo de uma mídia nunca muda, e cada tamanho tem o seu endereço
+	mediaCacheControl = "public, max-age=31536000, immutable"
+	// resizeConcurrency limita os redimensionamentos simultâneos em /media/:id
+	resizeConcurrency = 2
+)
+
+// responsiveImage é a imagem de capa de um artigo pronta para <picture> e srcset
+type responsiveImage struct {
+	MediaID uint          `json:"media_id"`
+	Src     string        `json:"src"` // arquivo original, para o <img>
+	Width   int           `json:"width"`
+	Height  int           `json:"height"`
+	Alt     string        `json:"alt"`
+	Sources []imageSource `json:"sources"` // WebP antes de JPEG, na ordem em que o <picture> deve oferecê-los
+}
+
+// imageSource são as larguras disponíveis em um formato
+type imageSource struct {
+	Type   string `json:"type"`
+	Srcset string `json:"srcset"` // "url 320w, url 640w, ..."
+}
+
+// variantName identifica uma versão pelas medidas, enquadramento e formato pedidos
+func variantName(width, height int, fit, format string) string {
+	return fmt.Sprintf("w%d-h%d-%s.%s", width, height, fit, format)
+}
+
+// variantKey guarda as versões ao lado do original, com um sufixo aleatório para que duas
+// gravações da mesma versão não dividam o arquivo ("2026/10/abc.png" ->
+// "2026/10/abc/w640-h0-contain-9f86d081.webp")
+func variantKey(mediaKey, name string) (string, error) {
+	random := make([]byte, 4)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	ext := path.Ext(name)
+	return strings.TrimSuffix(mediaKey, path.Ext(mediaKey)) + "/" + strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(random) + ext, nil
+}
+
+// generateVariants grava as versões do srcset nas larguras menores que a original: sempre
+// em JPEG e em WebP quando o WebP fica menor. Se o WebP perde na menor largura (fotos), as
+// demais não são tentadas.
+func (h *MediaHandler) generateVariants(ctx context.Context, media *models.Media, img image.Image) {
+	tryWebP := true
+	for _, width := range imaging.DefaultWidths {
+		if width >= media.Width {
+			break
+		}
+		resized := imaging.Resize(img, width, 0, imaging.FitContain)
+
+		var jpegData, webpData bytes.Buffer
+		if err := imaging.Encode(&jpegData, resized, imaging.FormatJPEG); err != nil {
+			log.Printf("Erro ao gerar versão de %s: %v", media.Key, err)
+			return
+		}
+		if tryWebP {
+			if err := imaging.Encode(&webpData, resized, imaging.FormatWebP); err != nil {
+				log.Printf("Erro ao gerar versão WebP de %s: %v", media.Key, err)
+				webpData.Reset()
+			}
+			tryWebP = webpData.Len() > 0 && webpData.Len() < jpegData.Len()
+		}
+
+		for _, encoded := range []struct {
+			format string
+			data   *bytes.Buffer
+		}{{imaging.FormatJPEG, &jpegData}, {imaging.FormatWebP, &webpData}} {
+			if encoded.format == imaging.FormatWebP && !tryWebP {
+				continue
+			}
+			name := variantName(width, 0, imaging.FitContain, encoded.format)
+			variant, err := h.storeVariant(ctx, *media, name, encoded.format, encoded.data.Bytes(), resized.Bounds().Size(), true)
+			if err != nil {
+				log.Printf("Erro ao gravar versão %s de %s: %v", name, media.Key, err)
+				return
+			}
+			media.Variants = append(media.Variants, variant)
+		}
+	}
+}
+
+// storeVariant grava o arquivo da versão e o registro
+func (h *MediaHandler) storeVariant(ctx context.Context, media models.Media, name, format string, data []byte, size image.Point, srcset bool) (models.MediaVariant, error) {
+	key, err := variantKey(media.Key, name)
+	if err != nil {
+		return models.MediaVariant{}, err
+	}
+	if err := h.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), imaging.ContentType(format)); err != nil {
+		return models.MediaVariant{}, err
+	}
+	variant := models.MediaVariant{
+		MediaID: media.ID,
+		Name:    name,
+		Key:     key,
+		URL:     h.storage.URL(key),
+		Format:  format,
+		Width:   size.X,
+		Height:  size.Y,
+		Size:    int64(len(data)),
+		Srcset:  srcset,
+	}
+	if err := h.db.Create(&variant).Error; err != nil {
+		// O arquivo é só desta gravação: se outra requisição registrou a mesma versão
+		// antes, o registro dela aponta para o próprio arquivo
+		h.deleteFile(key)
+		return models.MediaVariant{}, err
+	}
+	return variant, nil
+}
+
+// deleteVariants apaga os arquivos e os registros das versões da mídia
+func (h *MediaHandler) deleteVariants(media models.Media) error {
+	var variants []models.MediaVariant
+	if err := h.db.Where("media_id = ?", media.ID).Find(&variants).Error; err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		h.deleteFile(variant.Key)
+	}
+	return h.db.Where("media_id = ?", media.ID).Delete(&models.MediaVariant{}).Error
+}
+
+func (h *MediaHandler) deleteFile(key string) {
+	if err := h.storage.Delete(context.Background(), key); err != nil {
+		log.Printf("Erro ao apagar mídia %s: %v", key, err)
+	}
+}
+
+// ServeMedia serve a imagem em outro tamanho: w e h em pixels, entre as larguras do srcset
+// (sem ampliar), fit=contain (padrão) ou cover, e format=jpeg ou webp (sem format, WebP
+// para navegadores que o aceitam, quando ele compensa para a imagem). Cada tamanho é
+// gerado uma vez e guardado; sem parâmetros, serve o arquivo original.
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	media, ok := h.findMedia(c)
+	if !ok {
+		return
+	}
+
+	width, widthOK := dimensionParam(c.Query("w"))
+	height, heightOK := dimensionParam(c.Query("h"))
+	if !widthOK || !heightOK {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "w e h devem ser uma destas medidas: " + mediaSizesText()})
+		return
+	}
+	fit, ok := imaging.NormalizeFit(c.Query("fit"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fit deve ser contain ou cover"})
+		return
+	}
+	// Com uma das medidas livre, cover é igual a contain; um nome só evita versões repetidas
+	if width == 0 || height == 0 {
+		fit = imaging.FitContain
+	}
+
+	var format string
+	if requested := c.Query("format"); requested != "" {
+		if format, ok = imaging.NormalizeFormat(requested); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format deve ser jpeg ou webp"})
+			return
+		}
+	} else if width == 0 && height == 0 {
+		h.serveFile(c, media.Key, media.ContentType, media.Size, fmt.Sprintf(`"m%d"`, media.ID))
+		return
+	} else {
+		c.Header("Vary", "Accept")
+		format = imaging.FormatJPEG
+		if strings.Contains(c.GetHeader("Accept"), "image/webp") && h.prefersWebP(media) {
+			format = imaging.FormatWebP
+		}
+	}
+
+	name := variantName(width, height, fit, format)
+	etag := fmt.Sprintf(`"m%d-%s"`, media.ID, name)
+
+	variant, found, err := h.findVariant(media.ID, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar imagem"})
+		return
+	}
+	if found {
+		h.serveFile(c, variant.Key, imaging.ContentType(variant.Format), variant.Size, etag)
+		return
+	}
+
+	select {
+	case h.resizeSlots <- struct{}{}:
+		defer func() { <-h.resizeSlots }()
+	case <-c.Request.Context().Done():
+		return
+	}
+	// Outra requisição pode ter gerado a mesma versão enquanto esta esperava
+	if variant, found, err = h.findVariant(media.ID, name); err == nil && found {
+		h.serveFile(c, variant.Key, imaging.ContentType(variant.Format), variant.Size, etag)
+		return
+	}
+
+	data, err := h.renderVariant(c.Request.Context(), media, width, height, fit, format)
+	if err != nil {
+		log.Printf("Erro ao redimensionar mídia %d: %v", media.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao redimensionar imagem"})
+		return
+	}
+
+	// As medidas aceitas limitam as versões de cada imagem, então todas são guardadas
+	if _, err := h.storeVariant(c.Request.Context(), media, name, format, data.bytes, data.size, false); err != nil {
+		log.Printf("Erro ao guardar versão %s da mídia %d: %v", name, media.ID, err)
+	}
+
+	c.Header("Cache-Control", mediaCacheControl)
+	c.Header("ETag", etag)
+	c.Data(http.StatusOK, imaging.ContentType(format), data.bytes)
+}
+
+// renderedVariant é uma versão gerada, ainda não gravada
+type renderedVariant struct {
+	bytes []byte
+	size  image.Point
+}
+
+// renderVariant lê a original do armazenamento e gera a versão pedida
+func (h *MediaHandler) renderVariant(ctx context.Context, media models.Media, width, height int, fit, format string) (renderedVariant, error) {
+	original, err := h.storage.Open(ctx, media.Key)
+	if err != nil {
+		return renderedVariant{}, err
+	}
+	defer original.Close()
+
+	img, err := imaging.Decode(original)
+	if err != nil {
+		return renderedVariant{}, err
+	}
+	resized := imaging.Resize(img, width, height, fit)
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, resized, format); err != nil {
+		return renderedVariant{}, err
+	}
+	return renderedVariant{bytes: buf.Bytes(), size: resized.Bounds().Size()}, nil
+}
+
+// prefersWebP indica se vale servir WebP: o WebP gerado é sem perdas, então fotos em JPEG
+// só usam WebP se ele ficou menor nas versões do srcset
+func (h *MediaHandler) prefersWebP(media models.Media) bool {
+	if media.ContentType != "image/jpeg" {
+		return true
+	}
+	var count int64
+	h.db.Model(&models.MediaVariant{}).Where("media_id = ? AND srcset = ? AND format = ?", media.ID, true, imaging.FormatWebP).Count(&count)
+	return count > 0
+}
+
+func (h *MediaHandler) findVariant(mediaID uint, name string) (models.MediaVariant, bool, error) {
+	var variant models.MediaVariant
+	err := h.db.Where("media_id = ? AND name = ?", mediaID, name).First(&variant).Error
+	if err == gorm.ErrRecordNotFound {
+		return variant, false, nil
+	}
+	return variant, err == nil, err
+}
+
+// serveFile envia um arquivo do armazenamento com os cabeçalhos de cache
+func (h *MediaHandler) serveFile(c *gin.Context, key, contentType string, size int64, etag string) {
+	if c.GetHeader("If-None-Match") == etag {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", mediaCacheControl)
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	file, err := h.storage.Open(c.Request.Context(), key)
+	if err != nil {
+		log.Printf("Erro ao ler mídia %s: %v", key, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao ler imagem do armazenamento"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Cache-Control": mediaCacheControl,
+		"ETag":          etag,
+	})
+}
+
+// dimensionParam lê w ou h; vazio é 0 (medida livre). Só as larguras do srcset são aceitas,
+// para que ninguém force a geração de um tamanho novo a cada requisição.
+func dimensionParam(value string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(imaging.DefaultWidths, n) {
+		return 0, false
+	}
+	return n, true
+}
+
+// mediaSizesText lista as medidas aceitas em w e h, para mensagens de erro
+func mediaSizesText() string {
+	sizes := make([]string, len(imaging.DefaultWidths))
+	for i, size := range imaging.DefaultWidths {
+		sizes[i] = strconv.Itoa(size)
+	}
+	return strings.Join(sizes, ", ")
+}
+
+// responsiveImages monta o srcset das imagens de capa enviadas à biblioteca, pelo endereço
+// usado em image_url. Imagens de fora da biblioteca não aparecem no resultado.
+func responsiveImages(db *gorm.DB, urls []string) (map[string]*responsiveImage, error) {
+	images := make(map[string]*responsiveImage)
+	if len(urls) == 0 {
+		return images, nil
+	}
+
+	var media []models.Media
+	if err := db.Where("url IN ?", urls).
+		Preload("Variants", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("srcset = ?", true).Order("width")
+		}).
+		Find(&media).Error; err != nil {
+		return nil, err
+	}
+
+	for _, m := range media {
+		srcsets := make(map[string][]string)
+		for _, variant := range m.Variants {
+			srcsets[variant.Format] = append(srcsets[variant.Format], fmt.Sprintf("%s %dw", variant.URL, variant.Width))
+		}
+		// A original entra como a maior largura do seu formato
+		if format, ok := imaging.NormalizeFormat(strings.TrimPrefix(m.ContentType, "image/")); ok {
+			srcsets[format] = append(srcsets[format], fmt.Sprintf("%s %dw", m.URL, m.Width))
+		}
+
+		img := &responsiveImage{MediaID: m.ID, Src: m.URL, Width: m.Width, Height: m.Height, Alt: m.AltText, Sources: []imageSource{}}
+		for _, format := range []string{imaging.FormatWebP, imaging.FormatJPEG} {
+			if len(srcsets[format]) > 0 {
+				img.Sources = append(img.Sources, imageSource{Type: imaging.ContentType(format), Srcset: strings.Join(srcsets[format], ", ")})
+			}
+		}
+		images[m.URL] = img
+	}
+	return images, nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ryv-api/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "handlers.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Media{}, &models.MediaVariant{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDimensionParam(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+		ok    bool
+	}{
+		{"", 0, true},
+		{"320", 320, true},
+		{"1920", 1920, true},
+		// Só as larguras do srcset
+		{"500", 0, false},
+		{"4000", 0, false},
+		{"0", 0, false},
+		{"-320", 0, false},
+		{"abc", 0, false},
+		{"320px", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := dimensionParam(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("dimensionParam(%q) = %d, %v, esperado %d, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResponsiveImages(t *testing.T) {
+	db := openTestDB(t)
+
+	photo := models.Media{Key: "2026/10/foto.jpg", URL: "/uploads/2026/10/foto.jpg", ContentType: "image/jpeg", Width: 1600, Height: 900, AltText: "Óculos"}
+	drawing := models.Media{Key: "2026/10/desenho.png", URL: "/uploads/2026/10/desenho.png", ContentType: "image/png", Width: 800, Height: 800}
+	for _, m := range []*models.Media{&photo, &drawing} {
+		if err := db.Create(m).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	variants := []models.MediaVariant{
+		// Gravadas fora de ordem: o srcset sai da menor para a maior
+		{MediaID: photo.ID, Name: "w640-h0-contain.jpeg", Key: "a", URL: "/v/640.jpg", Format: "jpeg", Width: 640, Srcset: true},
+		{MediaID: photo.ID, Name: "w320-h0-contain.jpeg", Key: "b", URL: "/v/320.jpg", Format: "jpeg", Width: 320, Srcset: true},
+		{MediaID: photo.ID, Name: "w640-h0-contain.webp", Key: "c", URL: "/v/640.webp", Format: "webp", Width: 640, Srcset: true},
+		{MediaID: photo.ID, Name: "w320-h0-contain.webp", Key: "d", URL: "/v/320.webp", Format: "webp", Width: 320, Srcset: true},
+		// Pedida em /media/:id, fora do srcset
+		{MediaID: photo.ID, Name: "w320-h320-cover.webp", Key: "e", URL: "/v/320-cover.webp", Format: "webp", Width: 320, Height: 320},
+		{MediaID: drawing.ID, Name: "w320-h0-contain.webp", Key: "f", URL: "/v/desenho-320.webp", Format: "webp", Width: 320, Srcset: true},
+	}
+	if err := db.Create(&variants).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	images, err := responsiveImages(db, []string{photo.URL, drawing.URL, "https://externo.example/capa.jpg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 {
+		t.Fatalf("%d imagens, esperado 2 (imagens de fora da biblioteca não entram)", len(images))
+	}
+
+	got := images[photo.URL]
+	want := &responsiveImage{
+		MediaID: photo.ID, Src: photo.URL, Width: 1600, Height: 900, Alt: "Óculos",
+		Sources: []imageSource{
+			// WebP antes de JPEG; a original entra como a maior largura do seu formato
+			{Type: "image/webp", Srcset: "/v/320.webp 320w, /v/640.webp 640w"},
+			{Type: "image/jpeg", Srcset: "/v/320.jpg 320w, /v/640.jpg 640w, /uploads/2026/10/foto.jpg 1600w"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("foto = %+v, esperado %+v", got, want)
+	}
+
+	// PNG não é um formato gerado: a original fica só no src
+	wantSources := []imageSource{{Type: "image/webp", Srcset: "/v/desenho-320.webp 320w"}}
+	if got := images[drawing.URL]; !reflect.DeepEqual(got.Sources, wantSources) || got.Src != drawing.URL {
+		t.Errorf("desenho = %+v, esperado fontes %+v", got, wantSources)
+	}
+
+	empty, err := responsiveImages(db, nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("responsiveImages sem endereços = %v, %v", empty, err)
+	}
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ryv-api/locale"
+	"ryv-api/models"
+	"ryv-api/tracking"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// scoringConfig reúne a configuração da pontuação de recomendação carregada do banco
+type scoringConfig struct {
+	Settings        models.ScoringSettings
+	CategoryWeights map[string]float64
+	Motivations     map[string]map[string][]string // frases por idioma e categoria
+	CuriosityWords  []string
+	EmotionalWords  []string
+}
+
+// loadScoringConfig carrega pesos, listas de palavras e frases motivacionais
+func (h *RecommendationHandler) loadScoringConfig() (scoringConfig, error) {
+	config := scoringConfig{
+		CategoryWeights: make(map[string]float64),
+		Motivations:     make(map[string]map[string][]string),
+	}
+
+	if err := h.db.Order("id").First(&config.Settings).Error; err != nil && err != gorm.ErrRecordNotFound {
+		return config, err
+	}
+	config.CuriosityWords = splitWordList(config.Settings.CuriosityWords)
+	config.EmotionalWords = splitWordList(config.Settings.EmotionalWords)
+
+	var categories []models.CategoryScoring
+	if err := h.db.Find(&categories).Error; err != nil {
+		return config, err
+	}
+	for _, category := range categories {
+		config.CategoryWeights[category.Category] = category.Weight
+	}
+
+	var phrases []models.MotivationPhrase
+	if err := h.db.Order("id").Find(&phrases).Error; err != nil {
+		return config, err
+	}
+	for _, phrase := range phrases {
+		lang := phrase.Locale
+		if lang == "" {
+			lang = locale.Default
+		}
+		if config.Motivations[lang] == nil {
+			config.Motivations[lang] = make(map[string][]string)
+		}
+		config.Motivations[lang][phrase.Category] = append(config.Motivations[lang][phrase.Category], phrase.Phrase)
+	}
+
+	return config, nil
+}
+
+// splitWordList converte uma lista separada por vírgulas em palavras minúsculas
+func splitWordList(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// categoryScoringResponse representa a configuração de uma categoria no painel
+type categoryScoringResponse struct {
+	Category    string   `json:"category"`
+	Weight      float64  `json:"weight"`
+	Motivations []string `json:"motivations"` // frases em pt-BR
+	// Frases nos demais idiomas
+	TranslatedMotivations map[string][]string `json:"translated_motivations"`
+}
+
+// GetScoringConfig retorna a configuração atual da pontuação de recomendação
+func (h *RecommendationHandler) GetScoringConfig(c *gin.Context) {
+	config, err := h.loadScoringConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings":   config.Settings,
+		"categories": config.categoriesResponse(),
+	})
+}
+
+func (config scoringConfig) categoriesResponse() []categoryScoringResponse {
+	names := make(map[string]bool)
+	for category := range config.CategoryWeights {
+		names[category] = true
+	}
+	for _, motivations := range config.Motivations {
+		for category := range motivations {
+			names[category] = true
+		}
+	}
+
+	categories := make([]categoryScoringResponse, 0, len(names))
+	for name := range names {
+		categories = append(categories, config.categoryResponse(name))
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Category < categories[j].Category
+	})
+	return categories
+}
+
+func (config scoringConfig) categoryResponse(category string) categoryScoringResponse {
+	motivations := config.Motivations[locale.Default][category]
+	if motivations == nil {
+		motivations = []string{}
+	}
+	translated := make(map[string][]string)
+	for lang, phrases := range config.Motivations {
+		if lang != locale.Default && len(phrases[category]) > 0 {
+			translated[lang] = phrases[category]
+		}
+	}
+	return categoryScoringResponse{
+		Category:              category,
+		Weight:                config.CategoryWeights[category],
+		Motivations:           motivations,
+		TranslatedMotivations: translated,
+	}
+}
+
+// UpdateScoringSettingsRequest estrutura para atualização parcial dos parâmetros gerais.
+// Bônus e pesos vão de 0 a 10 (a visualização, contada às centenas, até 1), para que um valor
+// negativo ou exagerado não domine ou inverta o ranking.
+type UpdateScoringSettingsRequest struct {
+	CuriosityWords    *string  `json:"curiosity_words"`
+	CuriosityBonus    *float64 `json:"curiosity_bonus" binding:"omitempty,min=0,max=10"`
+	EmotionalWords    *string  `json:"emotional_words"`
+	EmotionalBonus    *float64 `json:"emotional_bonus" binding:"omitempty,min=0,max=10"`
+	WeekBonus         *float64 `json:"week_bonus" binding:"omitempty,min=0,max=10"`
+	MonthBonus        *float64 `json:"month_bonus" binding:"omitempty,min=0,max=10"`
+	ViewWeight        *float64 `json:"view_weight" binding:"omitempty,min=0,max=1"`
+	EngagementWeight  *float64 `json:"engagement_weight" binding:"omitempty,min=0,max=10"`
+	RandomWeight      *float64 `json:"random_weight" binding:"omitempty,min=0,max=10"`
+	DefaultMotivation *string  `json:"default_motivation"`
+	NoRepeatDays      *int     `json:"no_repeat_days" binding:"omitempty,min=0,max=365"`
+}
+
+// UpdateScoringSettings atualiza os parâmetros gerais da pontuação
+func (h *RecommendationHandler) UpdateScoringSettings(c *gin.Context) {
+	var req UpdateScoringSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	var settings models.ScoringSettings
+	if err := h.db.Order("id").First(&settings).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
+		return
+	}
+
+	if req.CuriosityWords != nil {
+		settings.CuriosityWords = strings.Join(splitWordList(*req.CuriosityWords), ", ")
+	}
+	if req.CuriosityBonus != nil {
+		settings.CuriosityBonus = *req.CuriosityBonus
+	}
+	if req.EmotionalWords != nil {
+		settings.EmotionalWords = strings.Join(splitWordList(*req.EmotionalWords), ", ")
+	}
+	if req.EmotionalBonus != nil {
+		settings.EmotionalBonus = *req.EmotionalBonus
+	}
+	if req.WeekBonus != nil {
+		settings.WeekBonus = *req.WeekBonus
+	}
+	if req.MonthBonus != nil {
+		settings.MonthBonus = *req.MonthBonus
+	}
+	if req.ViewWeight != nil {
+		settings.ViewWeight = *req.ViewWeight
+	}
+	if req.EngagementWeight != nil {
+		settings.EngagementWeight = *req.EngagementWeight
+	}
+	if req.RandomWeight != nil {
+		settings.RandomWeight = *req.RandomWeight
+	}
+	if req.DefaultMotivation != nil {
+		settings.DefaultMotivation = strings.TrimSpace(*req.DefaultMotivation)
+	}
+	if req.NoRepeatDays != nil {
+		settings.NoRepeatDays = *req.NoRepeatDays
+	}
+
+	if err := h.db.Save(&settings).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar configuração da recomendação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateCategoryScoringRequest estrutura para configurar o peso e as frases de uma categoria
+type UpdateCategoryScoringRequest struct {
+	Weight      *float64  `json:"weight" binding:"omitempty,min=0,max=10"`
+	Motivations *[]string `json:"motivations"`
+	Locale      string    `json:"locale"` // idioma das frases (padrão pt-BR)
+}
+
+// UpdateCategoryScoring cria ou atualiza o peso e as frases motivacionais de uma categoria
+func (h *RecommendationHandler) UpdateCategoryScoring(c *gin.Context) {
+	category := strings.TrimSpace(c.Param("category"))
+	if category == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Categoria inválida"})
+		return
+	}
+
+	var req UpdateCategoryScoringRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+	lang := locale.Default
+	if req.Locale != "" {
+		normalized, ok := locale.Normalize(req.Locale)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idioma não suportado; use " + strings.Join(locale.Supported, ", ")})
+			return
+		}
+		lang = normalized
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Weight != nil {
+			var scoring models.CategoryScoring
+			if err := tx.Where("category = ?", category).First(&scoring).Error; err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+			scoring.Category = category
+			scoring.Weight = *req.Weight
+			if err := tx.Save(&scoring).Error; err != nil {
+				return err
+			}
+		}
+
+		if req.Motivations != nil {
+			// As frases da categoria no idioma são substituídas pela lista enviada
+			if err := tx.Where("category = ? AND locale = ?", category, lang).Delete(&models.MotivationPhrase{}).Error; err != nil {
+				return err
+			}
+			for _, phrase := range *req.Motivations {
+				if phrase = strings.TrimSpace(phrase); phrase == "" {
+					continue
+				}
+				if err := tx.Create(&models.MotivationPhrase{Category: category, Locale: lang, Phrase: phrase}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar configuração da categoria"})
+		return
+	}
+
+	config, err := h.loadScoringConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
+		return
+	}
+
+	c.JSON(http.StatusOK, config.categoryResponse(category))
+}
+
+// PreviewRanking retorna o ranking de hoje com a contribuição de cada fator na pontuação.
+// Como na recomendação diária, os artigos escolhidos nos últimos NoRepeatDays dias não são
+// elegíveis, e selected indica o artigo servido hoje (o já gravado ou fixado para a data,
+// ou o primeiro elegível do ranking).
+func (h *RecommendationHandler) PreviewRanking(c *gin.Context) {
+	var articles []models.Article
+	if err := h.db.Where("is_published = ? AND locale = ?", true, locale.Default).Order("id").Find(&articles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+		return
+	}
+
+	engagement, err := h.recentEngagement()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular engajamento"})
+		return
+	}
+
+	config, err := h.loadScoringConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
+		return
+	}
+
+	today := time.Now().Format(tracking.DateLayout)
+	candidates, err := h.withoutRecentPicks(articles, today, config.Settings.NoRepeatDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico de recomendações"})
+		return
+	}
+	eligible := make(map[uint]bool, len(candidates))
+	for _, article := range candidates {
+		eligible[article.ID] = true
+	}
+
+	type rankedArticle struct {
+		ID        uint           `json:"id"`
+		Title     string         `json:"title"`
+		Slug      string         `json:"slug"`
+		Category  string         `json:"category"`
+		Eligible  bool           `json:"eligible"` // false se já foi o artigo do dia nos últimos NoRepeatDays dias
+		Breakdown scoreBreakdown `json:"score"`
+	}
+
+	ranking := make([]rankedArticle, 0, len(articles))
+	for _, article := range articles {
+		ranking = append(ranking, rankedArticle{
+			ID:        article.ID,
+			Title:     article.Title,
+			Slug:      article.Slug,
+			Category:  article.Category,
+			Eligible:  eligible[article.ID],
+			Breakdown: h.calculateArticleScore(article, engagement[article.ID], config, today),
+		})
+	}
+	// Mesmo desempate da escolha do artigo do dia: a menor ID vence
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i].Breakdown.Total > ranking[j].Breakdown.Total
+	})
+
+	type selection struct {
+		ArticleID uint `json:"article_id"`
+		Pinned    bool `json:"pinned"`
+		Stored    bool `json:"stored"` // já gravado no histórico do dia
+	}
+	var selected *selection
+
+	var pick models.DailyPick
+	err = h.db.Where("date = ?", today).First(&pick).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar recomendação do dia"})
+		return
+	}
+	if err == nil {
+		// O artigo gravado só é trocado se tiver sido despublicado
+		for _, article := range articles {
+			if article.ID == pick.ArticleID {
+				selected = &selection{ArticleID: pick.ArticleID, Pinned: pick.Pinned, Stored: true}
+				break
+			}
+		}
+	}
+	if selected == nil {
+		for _, ranked := range ranking {
+			if ranked.Eligible {
+				selected = &selection{ArticleID: ranked.ID}
+				break
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ranking":  ranking,
+		"selected": selected,
+	})
+}
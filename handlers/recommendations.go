@@ -3,14 +3,29 @@ package handlers
 import (
 	"math/rand"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"ryv-api/locale"
+	"ryv-api/middleware"
 	"ryv-api/models"
+	"ryv-api/tracking"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+const (
+	engagementWindowDays = 30 // janela usada para as métricas de engajamento
+	minEngagementViews   = 20 // visualizações mínimas para considerar o engajamento
+
+	categoryAffinityWeight      = 2.0 // peso da preferência do leitor por categoria
+	defaultRecommendationsLimit = 5
+	maxRecommendationsLimit     = 20
+)
+
 type RecommendationHandler struct {
 	db *gorm.DB
 }
@@ -19,187 +34,297 @@ func NewRecommendationHandler(db *gorm.DB) *RecommendationHandler {
 	return &RecommendationHandler{db: db}
 }
 
-// DailyRecommendation retorna uma recomendação inteligente baseada em psicologia e marketing
+// DailyRecommendation retorna uma recomendação inteligente baseada em psicologia e marketing.
+// O artigo do dia é escolhido uma única vez e guardado, então todos os leitores veem o mesmo.
 func (h *RecommendationHandler) DailyRecommendation(c *gin.Context) {
-	var articles []models.Article
-	
-	// Buscar artigos publicados
-	if err := h.db.Where("is_published = ?", true).Find(&articles).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+	// Pesos, palavras e frases são configurados pelos editores no painel
+	config, err := h.loadScoringConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
 		return
 	}
 
-	if len(articles) == 0 {
+	// Leitores participando de um teste A/B podem receber outra estratégia de escolha ou outra frase
+	experiment, variant := h.dailyExperimentVariant(c)
+
+	today := time.Now().Format(tracking.DateLayout)
+	recommendation, err := h.recommendationForVariant(today, config, variant)
+	if err == errNoPublishedArticles {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Nenhum artigo encontrado"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao escolher a recomendação do dia"})
+		return
+	}
 
-	// Algoritmo inteligente de recomendação baseado em:
-	// 1. Psicologia: Escolher artigos que criam conexão emocional
-	// 2. Marketing: Priorizar conteúdo que gera engajamento
-	// 3. Timing: Considerar o momento do dia e contexto
+	// O artigo do dia é o mesmo para todos; leitores de outro idioma recebem a tradução, se houver
+	lang := requestLocale(c)
+	if translation, ok := articleInLocale(h.db, recommendation, lang); ok {
+		recommendation = translation
+	}
+	categoryName := recommendation.Category
+	if names, err := categoryNames(h.db, lang); err == nil && names[categoryName] != "" {
+		categoryName = names[categoryName]
+	}
+
+	// Tempo de leitura estimado
+	readingTime := h.calculateReadingTime(recommendation)
 
-	recommendation := h.selectIntelligentRecommendation(articles)
-	
-	// Calcular tempo de leitura estimado
-	readingTime := h.calculateReadingTime(recommendation.Content)
-	
 	response := gin.H{
-		"id":          recommendation.ID,
-		"title":       recommendation.Title,
-		"excerpt":     recommendation.Excerpt,
-		"category":    recommendation.Category,
-		"slug":        recommendation.Slug,
-		"imageURL":    recommendation.ImageURL,
-		"readingTime": readingTime,
-		"motivation":  h.generateMotivation(recommendation.Category),
+		"id":             recommendation.ID,
+		"title":          recommendation.Title,
+		"excerpt":        recommendation.Excerpt,
+		"category":       recommendation.Category,
+		"categoryName":   categoryName,
+		"slug":           recommendation.Slug,
+		"locale":         recommendation.Locale,
+		"imageURL":       recommendation.ImageURL,
+		"readingTime":    readingTime,
+		"readingMinutes": recommendation.ReadingMinutes,
+		"wordCount":      recommendation.WordCount,
+		"motivation":     h.generateMotivation(recommendation.Category, lang, config, dailyRand(today, recommendation.Category)),
+	}
+
+	if experiment != nil {
+		if variant.HideMotivation {
+			response["motivation"] = ""
+		} else if variant.Motivation != "" {
+			response["motivation"] = variant.Motivation
+		}
+		// O frontend usa a chave para registrar o clique em /api/experiments/:key/click
+		response["experiment"] = gin.H{
+			"key":     experiment.Key,
+			"variant": variant.Key,
+		}
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *RecommendationHandler) selectIntelligentRecommendation(articles []models.Article) models.Article {
-	// Usar seed baseado na data para garantir consistência diária
-	today := time.Now().Format("2006-01-02")
-	seed := int64(0)
-	for _, char := range today {
-		seed += int64(char)
+// recentEngagement carrega as métricas de engajamento dos últimos dias
+func (h *RecommendationHandler) recentEngagement() (map[uint]tracking.EngagementMetrics, error) {
+	to := time.Now().AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -engagementWindowDays)
+	return tracking.LoadEngagementMetrics(h.db, nil, from, to)
+}
+
+// PersonalizedRecommendations retorna artigos para o leitor anônimo, excluindo os já lidos e
+// favorecendo as categorias que ele mais lê. Leitores sem histórico recebem os artigos
+// com maior pontuação geral.
+func (h *RecommendationHandler) PersonalizedRecommendations(c *gin.Context) {
+	readerID := c.Query("reader")
+	if readerID == "" {
+		readerID = middleware.GetReaderID(c)
+	} else if !middleware.ValidReaderID(readerID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Identificador de leitor inválido"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRecommendationsLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultRecommendationsLimit
+	}
+	if limit > maxRecommendationsLimit {
+		limit = maxRecommendationsLimit
+	}
+
+	var articles []models.Article
+	if err := h.db.Where("is_published = ? AND locale = ?", true, locale.Default).Find(&articles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos"})
+		return
+	}
+
+	var history []models.ReaderHistory
+	if readerID != "" {
+		if err := h.db.Where("reader_id = ?", readerID).Find(&history).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar histórico do leitor"})
+			return
+		}
+	}
+
+	engagement, err := h.recentEngagement()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao calcular engajamento"})
+		return
+	}
+
+	config, err := h.loadScoringConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao carregar configuração da recomendação"})
+		return
 	}
-	rand.Seed(seed)
 
-	// Pontuação baseada em psicologia e marketing
+	today := time.Now().Format(tracking.DateLayout)
+
+	// Preferência por categoria: fração das leituras do leitor em cada categoria
+	alreadyRead := make(map[uint]bool, len(history))
+	categoryReads := make(map[string]int)
+	totalReads := 0
+	for _, entry := range history {
+		alreadyRead[entry.ArticleID] = true
+		categoryReads[entry.Category] += entry.Reads
+		totalReads += entry.Reads
+	}
+	personalized := totalReads > 0
+
 	type scoredArticle struct {
 		article models.Article
 		score   float64
+		reason  string
 	}
 
-	var scoredArticles []scoredArticle
-
+	scored := make([]scoredArticle, 0, len(articles))
 	for _, article := range articles {
-		score := h.calculateArticleScore(article)
-		scoredArticles = append(scoredArticles, scoredArticle{
-			article: article,
-			score:   score,
+		if alreadyRead[article.ID] {
+			continue
+		}
+
+		score := h.calculateArticleScore(article, engagement[article.ID], config, today).Total
+		reason := "popular"
+		if personalized && categoryReads[article.Category] > 0 {
+			affinity := float64(categoryReads[article.Category]) / float64(totalReads)
+			score += affinity * categoryAffinityWeight
+			reason = "category"
+		}
+
+		scored = append(scored, scoredArticle{article: article, score: score, reason: reason})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+
+	recommendations := make([]gin.H, 0, len(scored))
+	for _, s := range scored {
+		recommendations = append(recommendations, gin.H{
+			"id":             s.article.ID,
+			"title":          s.article.Title,
+			"excerpt":        s.article.Excerpt,
+			"category":       s.article.Category,
+			"slug":           s.article.Slug,
+			"imageURL":       s.article.ImageURL,
+			"readingTime":    h.calculateReadingTime(s.article),
+			"readingMinutes": s.article.ReadingMinutes,
+			"wordCount":      s.article.WordCount,
+			"reason":         s.reason,
 		})
 	}
 
-	// Ordenar por pontuação
-	for i := 0; i < len(scoredArticles)-1; i++ {
-		for j := i + 1; j < len(scoredArticles); j++ {
-			if scoredArticles[i].score < scoredArticles[j].score {
-				scoredArticles[i], scoredArticles[j] = scoredArticles[j], scoredArticles[i]
-			}
+	c.JSON(http.StatusOK, gin.H{
+		"reader_id":       readerID,
+		"personalized":    personalized,
+		"recommendations": recommendations,
+	})
+}
+
+// selectIntelligentRecommendation escolhe o artigo de maior pontuação na data informada.
+// Empates são resolvidos pelo ID para que a escolha não dependa da ordem da consulta.
+func (h *RecommendationHandler) selectIntelligentRecommendation(articles []models.Article, engagement map[uint]tracking.EngagementMetrics, config scoringConfig, date string) models.Article {
+	// Pontuação baseada em psicologia, marketing e engajamento
+	best := articles[0]
+	bestScore := h.calculateArticleScore(best, engagement[best.ID], config, date).Total
+
+	for _, article := range articles[1:] {
+		score := h.calculateArticleScore(article, engagement[article.ID], config, date).Total
+		if score > bestScore || (score == bestScore && article.ID < best.ID) {
+			best, bestScore = article, score
 		}
 	}
 
-	// Retornar o artigo com maior pontuação
-	return scoredArticles[0].article
+	return best
+}
+
+// scoreBreakdown detalha a contribuição de cada fator na pontuação de um artigo
+type scoreBreakdown struct {
+	Category   float64 `json:"category"`
+	Curiosity  float64 `json:"curiosity"`
+	Emotional  float64 `json:"emotional"`
+	Recency    float64 `json:"recency"`
+	Views      float64 `json:"views"`
+	Engagement float64 `json:"engagement"`
+	Random     float64 `json:"random"`
+	Total      float64 `json:"total"`
 }
 
-func (h *RecommendationHandler) calculateArticleScore(article models.Article) float64 {
-	score := 0.0
+// calculateArticleScore pontua um artigo. A variação aleatória depende apenas da data e do
+// artigo, então a pontuação é a mesma em todas as requisições do dia.
+func (h *RecommendationHandler) calculateArticleScore(article models.Article, engagement tracking.EngagementMetrics, config scoringConfig, date string) scoreBreakdown {
+	var score scoreBreakdown
+	settings := config.Settings
 
 	// Fatores psicológicos
-	psychologicalFactors := map[string]float64{
-		"Saúde Mental": 1.5,  // Alto engajamento emocional
-		"Ótica":        1.2,  // Interesse prático
-		"Optometria":   1.3,  // Conhecimento técnico
-		"Dicas de Saúde": 1.4, // Aplicação prática
-	}
-
-	if factor, exists := psychologicalFactors[article.Category]; exists {
-		score += factor
-	}
+	score.Category = config.CategoryWeights[article.Category]
 
 	// Fatores de marketing
 	// Títulos que criam curiosidade
-	curiosityWords := []string{"como", "por que", "quando", "descubra", "revele", "secreto"}
-	for _, word := range curiosityWords {
-		if contains(article.Title, word) {
-			score += 0.3
+	title := strings.ToLower(article.Title)
+	excerpt := strings.ToLower(article.Excerpt)
+	for _, word := range config.CuriosityWords {
+		if contains(title, word) {
+			score.Curiosity += settings.CuriosityBonus
 		}
 	}
 
 	// Palavras emocionais
-	emotionalWords := []string{"transformar", "conectar", "bem-estar", "saúde", "vida", "felicidade"}
-	for _, word := range emotionalWords {
-		if contains(article.Title, word) || contains(article.Excerpt, word) {
-			score += 0.2
+	for _, word := range config.EmotionalWords {
+		if contains(title, word) || contains(excerpt, word) {
+			score.Emotional += settings.EmotionalBonus
 		}
 	}
 
 	// Fatores temporais
-	now := time.Now()
-	articleDate := *article.PublishedAt
-	
-	// Artigos mais recentes têm pontuação adicional
-	daysSincePublished := now.Sub(articleDate).Hours() / 24
-	if daysSincePublished < 7 {
-		score += 0.5 // Artigos da última semana
-	} else if daysSincePublished < 30 {
-		score += 0.3 // Artigos do último mês
+	// Artigos mais recentes têm pontuação adicional (artigos sem data de publicação não recebem bônus)
+	if article.PublishedAt != nil {
+		daysSincePublished := time.Since(*article.PublishedAt).Hours() / 24
+		if daysSincePublished < 7 {
+			score.Recency = settings.WeekBonus // Artigos da última semana
+		} else if daysSincePublished < 30 {
+			score.Recency = settings.MonthBonus // Artigos do último mês
+		}
 	}
 
 	// Fatores de engajamento
 	if article.ViewCount > 0 {
-		score += float64(article.ViewCount) * 0.01 // Mais visualizações = mais popular
+		score.Views = float64(article.ViewCount) * settings.ViewWeight // Mais visualizações = mais popular
+	}
+
+	// Leitura até o fim, tempo na página e cliques em CTA (só com amostra mínima)
+	if engagement.Views >= minEngagementViews {
+		score.Engagement = engagement.Score * settings.EngagementWeight
 	}
 
 	// Variação aleatória para evitar sempre o mesmo artigo
-	score += rand.Float64() * 0.5
+	score.Random = stableFloat(date, strconv.FormatUint(uint64(article.ID), 10)) * settings.RandomWeight
+
+	score.Total = score.Category + score.Curiosity + score.Emotional + score.Recency +
+		score.Views + score.Engagement + score.Random
 
 	return score
 }
 
-func (h *RecommendationHandler) calculateReadingTime(content string) string {
-	// Média de 200 palavras por minuto
-	wordCount := len(content) / 5 // Estimativa aproximada
-	minutes := wordCount / 200
-	
+// calculateReadingTime formata o tempo de leitura calculado quando o artigo foi salvo
+func (h *RecommendationHandler) calculateReadingTime(article models.Article) string {
+	minutes := article.ReadingMinutes
 	if minutes < 1 {
-		return "1 min"
-	} else if minutes < 5 {
-		return "2-3 min"
-	} else if minutes < 10 {
-		return "5-7 min"
-	} else {
-		return "10+ min"
+		minutes = 1
 	}
+	return strconv.Itoa(minutes) + " min"
 }
 
-func (h *RecommendationHandler) generateMotivation(category string) string {
-	motivations := map[string][]string{
-		"Saúde Mental": {
-			"💡 Desbloqueie insights poderosos sobre sua mente",
-			"🧠 Conecte-se com seu bem-estar emocional",
-			"🌟 Transforme sua perspectiva sobre saúde mental",
-			"❤️ Cuide da sua mente como cuida do seu corpo",
-		},
-		"Ótica": {
-			"👁️ Descubra como cuidar da sua visão",
-			"🔍 Veja o mundo com novos olhos",
-			"✨ Tecnologia que transforma sua experiência visual",
-			"🌍 Enxergue a vida com mais clareza",
-		},
-		"Optometria": {
-			"🔬 Ciência avançada para sua saúde ocular",
-			"📊 Dados que revelam a verdade sobre sua visão",
-			"🎯 Soluções precisas para problemas visuais",
-			"⚡ Conhecimento que ilumina seu caminho",
-		},
-		"Dicas de Saúde": {
-			"💪 Pequenas mudanças, grandes resultados",
-			"🌱 Cultive hábitos que transformam sua vida",
-			"⚡ Energia e vitalidade ao seu alcance",
-			"🚀 Acelere seu potencial de bem-estar",
-		},
-	}
-
-	if categoryMotivations, exists := motivations[category]; exists {
-		return categoryMotivations[rand.Intn(len(categoryMotivations))]
-	}
-	
-	return "🌟 Descubra insights valiosos para sua vida"
+// generateMotivation sorteia uma frase da categoria no idioma do leitor. Sem frases nesse
+// idioma, usa as frases em pt-BR e, por fim, a frase padrão.
+func (h *RecommendationHandler) generateMotivation(category, lang string, config scoringConfig, rng *rand.Rand) string {
+	for _, candidate := range []string{lang, locale.Default} {
+		if categoryMotivations := config.Motivations[candidate][category]; len(categoryMotivations) > 0 {
+			return categoryMotivations[rng.Intn(len(categoryMotivations))]
+		}
+	}
+
+	return config.Settings.DefaultMotivation
 }
 
 func contains(text, word string) bool {
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ryv-api/database"
+	"ryv-api/models"
+	"ryv-api/related"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRelatedLimit = 4
+	maxRelatedLimit     = 20
+)
+
+// relatedIndex guarda os artigos relacionados pré-calculados
+var relatedIndex *related.Index
+
+// SetRelatedIndex define o índice usado no "leia também". É chamado uma vez, antes de o
+// servidor começar a atender requisições.
+func SetRelatedIndex(index *related.Index) {
+	relatedIndex = index
+}
+
+// refreshRelated agenda a atualização do índice após alterações nos artigos
+func refreshRelated() {
+	if relatedIndex != nil {
+		relatedIndex.Refresh()
+	}
+}
+
+// GetRelatedArticles retorna os artigos publicados mais parecidos com o artigo informado ("leia também")
+func GetRelatedArticles(c *gin.Context) {
+	idOrSlug := c.Param("id_or_slug")
+
+	var article models.Article
+	query := database.DB.Select("id, translation_of_id").Where("is_published = ?", true)
+	if id, err := strconv.Atoi(idOrSlug); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("slug = ?", idOrSlug)
+	}
+	if err := query.First(&article).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRelatedLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultRelatedLimit
+	}
+	if limit > maxRelatedLimit {
+		limit = maxRelatedLimit
+	}
+
+	if relatedIndex == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Índice de artigos relacionados indisponível"})
+		return
+	}
+	matches, err := relatedIndex.Related(translationGroup(article), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos relacionados"})
+		return
+	}
+
+	ids := make([]uint, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.ArticleID)
+	}
+	var articles []models.Article
+	if len(ids) > 0 {
+		if err := database.DB.Where("id IN ? AND is_published = ?", ids, true).Find(&articles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar artigos relacionados"})
+			return
+		}
+	}
+	articlesByID := make(map[uint]models.Article, len(articles))
+	for _, a := range articles {
+		articlesByID[a.ID] = a
+	}
+
+	// Manter a ordem por similaridade; artigos despublicados desde a última atualização são ignorados
+	result := make([]gin.H, 0, len(matches))
+	for _, match := range matches {
+		a, exists := articlesByID[match.ArticleID]
+		if !exists {
+			continue
+		}
+		result = append(result, gin.H{
+			"id":              a.ID,
+			"title":           a.Title,
+			"slug":            a.Slug,
+			"excerpt":         a.Excerpt,
+			"image_url":       a.ImageURL,
+			"category":        a.Category,
+			"published_at":    a.PublishedAt,
+			"word_count":      a.WordCount,
+			"reading_minutes": a.ReadingMinutes,
+			"similarity":      match.Score,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"article_id": article.ID,
+		"related":    result,
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"ryv-api/models"
+	"ryv-api/sanitize"
+)
+
+// sanitizePolicy é a política aplicada ao HTML dos artigos antes de gravá-los
+var sanitizePolicy = sanitize.NewPolicy(nil)
+
+// SetSanitizePolicy define a política de sanitização do conteúdo dos artigos
+func SetSanitizePolicy(p *sanitize.Policy) {
+	sanitizePolicy = p
+}
+
+// sanitizeArticle aplica a política ao conteúdo do artigo (e ao original, em traduções).
+// Retorna nil quando nada foi retirado.
+func sanitizeArticle(article *models.Article) *sanitize.Report {
+	var report sanitize.Report
+	article.Content, report = sanitizePolicy.Sanitize(article.Content)
+	if article.OriginalContent != "" {
+		var original sanitize.Report
+		article.OriginalContent, original = sanitizePolicy.Sanitize(article.OriginalContent)
+		report.Merge(original)
+	}
+	if report.Empty() {
+		return nil
+	}
+	return &report
+}
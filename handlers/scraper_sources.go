@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ryv-api/models"
+	"ryv-api/scraper"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScraperSourceHandler struct {
+	db      *gorm.DB
+	service *scraper.ScraperService
+}
+
+func NewScraperSourceHandler(db *gorm.DB, service *scraper.ScraperService) *ScraperSourceHandler {
+	return &ScraperSourceHandler{db: db, service: service}
+}
+
+// ScraperSourceRequest estrutura para criar, atualizar ou testar uma fonte.
+// Na atualização, apenas os campos enviados são alterados.
+type ScraperSourceRequest struct {
+	Name          *string `json:"name"`
+	URL           *string `json:"url"`
+	Type          *string `json:"type"`
+	ItemSelector  *string `json:"item_selector"`
+	TitleSelector *string `json:"title_selector"`
+	BodySelector  *string `json:"body_selector"`
+	LinkSelector  *string `json:"link_selector"`
+	DateSelector  *string `json:"date_selector"`
+	ImageSelector *string `json:"image_selector"`
+	TagsSelector  *string `json:"tags_selector"`
+	Category      *string `json:"category"`
+	Enabled       *bool   `json:"enabled"`
+	Schedule      *string `json:"schedule"`
+}
+
+// apply copia para a fonte os campos enviados na requisição
+func (req ScraperSourceRequest) apply(source *models.ScraperSource) {
+	fields := []struct {
+		value  *string
+		target *string
+	}{
+		{req.Name, &source.Name},
+		{req.URL, &source.URL},
+		{req.Type, &source.Type},
+		{req.ItemSelector, &source.ItemSelector},
+		{req.TitleSelector, &source.TitleSelector},
+		{req.BodySelector, &source.BodySelector},
+		{req.LinkSelector, &source.LinkSelector},
+		{req.DateSelector, &source.DateSelector},
+		{req.ImageSelector, &source.ImageSelector},
+		{req.TagsSelector, &source.TagsSelector},
+		{req.Category, &source.Category},
+		{req.Schedule, &source.Schedule},
+	}
+	for _, field := range fields {
+		if field.value != nil {
+			*field.target = strings.TrimSpace(*field.value)
+		}
+	}
+	if req.Enabled != nil {
+		source.Enabled = *req.Enabled
+	}
+}
+
+// ListScraperSources lista as fontes do scraper com o resultado da última coleta
+func (h *ScraperSourceHandler) ListScraperSources(c *gin.Context) {
+	var sources []models.ScraperSource
+	if err := h.db.Order("name").Find(&sources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar fontes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sources": sources,
+	})
+}
+
+// GetScraperSource retorna uma fonte específica
+func (h *ScraperSourceHandler) GetScraperSource(c *gin.Context) {
+	source, ok := h.findSource(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// CreateScraperSource cadastra uma fonte; sem type ela é html e sem enabled começa habilitada
+func (h *ScraperSourceHandler) CreateScraperSource(c *gin.Context) {
+	var req ScraperSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	// Sem agenda, vale o padrão da coluna (6h)
+	source := models.ScraperSource{Enabled: true, Type: scraper.SourceTypeHTML}
+	req.apply(&source)
+	if source.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nome é obrigatório"})
+		return
+	}
+	if err := scraper.ValidateSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.ScraperSource{}).Where("url = ?", source.URL).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Já existe uma fonte com esta URL"})
+		return
+	}
+
+	if err := h.db.Create(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao criar fonte"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// UpdateScraperSource altera os campos enviados de uma fonte
+func (h *ScraperSourceHandler) UpdateScraperSource(c *gin.Context) {
+	source, ok := h.findSource(c)
+	if !ok {
+		return
+	}
+
+	var req ScraperSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	schedule, sourceType := source.Schedule, source.Type
+	req.apply(&source)
+	if source.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nome é obrigatório"})
+		return
+	}
+	if source.Schedule == "" {
+		source.Schedule = schedule
+	}
+	if source.Type == "" {
+		source.Type = sourceType
+	}
+	if err := scraper.ValidateSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.ScraperSource{}).Where("url = ? AND id <> ?", source.URL, source.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Já existe uma fonte com esta URL"})
+		return
+	}
+
+	if err := h.db.Save(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar fonte"})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteScraperSource remove uma fonte; as sugestões já coletadas continuam na fila
+func (h *ScraperSourceHandler) DeleteScraperSource(c *gin.Context) {
+	source, ok := h.findSource(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(&source).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao remover fonte"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fonte removida com sucesso"})
+}
+
+// TestScraperSource baixa a página e retorna os artigos que os seletores enviados
+// extrairiam, sem gravar a fonte nem as sugestões
+func (h *ScraperSourceHandler) TestScraperSource(c *gin.Context) {
+	var req ScraperSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	source := models.ScraperSource{Type: scraper.SourceTypeHTML}
+	req.apply(&source)
+	if source.Type == "" {
+		source.Type = scraper.SourceTypeHTML
+	}
+	h.runTest(c, source)
+}
+
+// TestSavedScraperSource testa os seletores de uma fonte já cadastrada. Campos enviados
+// no corpo substituem os gravados apenas neste teste.
+func (h *ScraperSourceHandler) TestSavedScraperSource(c *gin.Context) {
+	source, ok := h.findSource(c)
+	if !ok {
+		return
+	}
+
+	if c.Request.ContentLength > 0 {
+		var req ScraperSourceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+			return
+		}
+		req.apply(&source)
+	}
+	h.runTest(c, source)
+}
+
+func (h *ScraperSourceHandler) runTest(c *gin.Context, source models.ScraperSource) {
+	if err := scraper.ValidateSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.TestSource(c.Request.Context(), source)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao acessar a página: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExtractArticleRequest estrutura para extrair o conteúdo de uma página de artigo
+type ExtractArticleRequest struct {
+	URL             string `json:"url" binding:"required"`
+	ContentSelector string `json:"content_selector"`
+}
+
+// ExtractArticle baixa a página e retorna o conteúdo principal como HTML limpo, com título,
+// autor, imagem e data de publicação
+func (h *ScraperSourceHandler) ExtractArticle(c *gin.Context) {
+	var req ExtractArticleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	req.URL = strings.TrimSpace(req.URL)
+	if err := scraper.ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := scraper.ValidateSelector("content_selector", req.ContentSelector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	article, err := h.service.ExtractArticle(c.Request.Context(), req.URL, req.ContentSelector)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao acessar a página: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *ScraperSourceHandler) findSource(c *gin.Context) (models.ScraperSource, bool) {
+	var source models.ScraperSource
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID inválido"})
+		return source, false
+	}
+
+	if err := h.db.First(&source, uint(id)).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Fonte não encontrada"})
+			return source, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar fonte"})
+		return source, false
+	}
+	return source, true
+}
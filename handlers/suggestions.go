@@ -0,0 +1,492 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/sanitize"
+	"ryv-api/scraper"
+	"ryv-api/textutil"
+	"ryv-api/translate"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSuggestionsLimit = 20
+	maxSuggestionsLimit     = 100
+
+	defaultRelatedNewsLimit = 5
+	maxRelatedNewsLimit     = 20
+	relatedNewsTimeout      = 2 * time.Minute
+
+	translationTimeout = 2 * time.Minute
+)
+
+type SuggestionHandler struct {
+	db      *gorm.DB
+	service *scraper.ScraperService
+	job     *scraper.SuggestionJob
+}
+
+func NewSuggestionHandler(db *gorm.DB, service *scraper.ScraperService, job *scraper.SuggestionJob) *SuggestionHandler {
+	return &SuggestionHandler{db: db, service: service, job: job}
+}
+
+// ListSuggestions retorna a fila de sugestões do scraper.
+// Filtros: status (padrão pending; "all" para todas), category, source e q (busca no título).
+func (h *SuggestionHandler) ListSuggestions(c *gin.Context) {
+	query := h.db.Model(&models.ScrapedArticle{})
+
+	status := c.DefaultQuery("status", scraper.StatusPending)
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if category := c.Query("category"); category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if source := c.Query("source"); source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		query = query.Where("title LIKE ?", "%"+search+"%")
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSuggestionsLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultSuggestionsLimit
+	}
+	if limit > maxSuggestionsLimit {
+		limit = maxSuggestionsLimit
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar sugestões"})
+		return
+	}
+
+	var suggestions []models.ScrapedArticle
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&suggestions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar sugestões"})
+		return
+	}
+
+	// Quantidade de sugestões em cada situação, para as abas do painel
+	type statusCount struct {
+		Status string
+		Total  int64
+	}
+	var counts []statusCount
+	if err := h.db.Model(&models.ScrapedArticle{}).Select("status, COUNT(*) AS total").Group("status").Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao buscar sugestões"})
+		return
+	}
+	countsByStatus := gin.H{scraper.StatusPending: 0, scraper.StatusApproved: 0, scraper.StatusRejected: 0, scraper.StatusDuplicate: 0}
+	for _, count := range counts {
+		countsByStatus[count.Status] = count.Total
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+		"counts":      countsByStatus,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (int(total) + limit - 1) / limit,
+		},
+	})
+}
+
+// GetSuggestion retorna uma sugestão específica
+func (h *SuggestionHandler) GetSuggestion(c *gin.Context) {
+	var suggestion models.ScrapedArticle
+	if err := h.db.First(&suggestion, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sugestão não encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// ApproveSuggestionRequest permite ajustar o rascunho criado na aprovação
+type ApproveSuggestionRequest struct {
+	Title     string `json:"title"`
+	Category  string `json:"category"`
+	Tags      string `json:"tags"`
+	Translate *bool  `json:"translate"` // padrão: traduz sugestões que não estão em português, se houver tradutor
+}
+
+// ApproveSuggestion cria um artigo em rascunho a partir da sugestão, com a URL de origem
+// como atribuição e a categoria definida automaticamente pelo conteúdo. Sugestões em
+// outro idioma viram um rascunho em pt-BR, com o título e o conteúdo originais guardados
+// no artigo para consulta.
+func (h *SuggestionHandler) ApproveSuggestion(c *gin.Context) {
+	var req ApproveSuggestionRequest
+	// O corpo é opcional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+			return
+		}
+	}
+
+	var suggestion models.ScrapedArticle
+	if err := h.db.First(&suggestion, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sugestão não encontrada"})
+		return
+	}
+	if suggestion.Status != scraper.StatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": errSuggestionReviewed.Error()})
+		return
+	}
+
+	// A tradução é feita antes da transação, que não deve ficar aberta durante a chamada
+	// ao provedor. Se falhar, a sugestão continua pendente.
+	content := plainTextToHTML(suggestion.Content)
+	var translation *scraper.Translation
+	if (req.Translate == nil || *req.Translate) && scraper.NeedsTranslation(suggestion) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), translationTimeout)
+		translated, err := h.service.TranslateSuggestion(ctx, suggestion, content)
+		cancel()
+		switch {
+		case err == nil:
+			translation = &translated
+		case errors.Is(err, translate.ErrNotConfigured):
+			// Sem tradutor, o rascunho fica no idioma original, a menos que a tradução tenha sido pedida
+			if req.Translate != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+				return
+			}
+		case errors.Is(err, translate.ErrQuota):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao traduzir sugestão: " + err.Error()})
+			return
+		}
+	}
+
+	var article models.Article
+	var sanitized *sanitize.Report
+	status := http.StatusCreated
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&suggestion, c.Param("id")).Error; err != nil {
+			status = http.StatusNotFound
+			return err
+		}
+		// A condição na situação evita que duas aprovações simultâneas criem dois rascunhos
+		result := tx.Model(&suggestion).Where("status = ?", scraper.StatusPending).
+			Updates(reviewUpdates(c, scraper.StatusApproved, nil))
+		if result.Error != nil {
+			status = http.StatusInternalServerError
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			status = http.StatusConflict
+			return errSuggestionReviewed
+		}
+
+		draft := scraper.Translation{Title: suggestion.Title, Excerpt: suggestion.Excerpt, Content: content}
+		if translation != nil {
+			draft = *translation
+		}
+
+		title := strings.TrimSpace(req.Title)
+		if title == "" {
+			title = draft.Title
+		}
+		category := strings.TrimSpace(req.Category)
+		if category == "" {
+			category = h.service.CategorizeContent(title, textutil.StripHTML(draft.Content))
+		}
+		tags := strings.TrimSpace(req.Tags)
+		if tags == "" {
+			tags = suggestion.Tags
+		}
+
+		slug, err := uniqueArticleSlug(tx, title, suggestion.ID)
+		if err != nil {
+			status = http.StatusInternalServerError
+			return err
+		}
+
+		article = models.Article{
+			Title:       title,
+			Slug:        slug,
+			Content:     draft.Content,
+			Excerpt:     draft.Excerpt,
+			ImageURL:    suggestion.ImageURL,
+			Author:      suggestion.Author,
+			Category:    category,
+			Tags:        tags,
+			SourceURL:   suggestion.SourceURL,
+			IsPublished: false,
+		}
+		if translation != nil {
+			article.OriginalLanguage = translation.SourceLanguage
+			article.OriginalTitle = suggestion.Title
+			article.OriginalContent = content
+		}
+		if userID := c.GetUint("user_id"); userID != 0 {
+			article.AuthorID = &userID
+		}
+		if sanitized, err = prepareContent(&article, nil, ""); err != nil {
+			status = http.StatusInternalServerError
+			return err
+		}
+		if err := tx.Create(&article).Error; err != nil {
+			status = http.StatusInternalServerError
+			return err
+		}
+
+		return tx.Model(&suggestion).Update("article_id", article.ID).Error
+	})
+	if err != nil {
+		switch status {
+		case http.StatusNotFound:
+			c.JSON(status, gin.H{"error": "Sugestão não encontrada"})
+		case http.StatusConflict:
+			c.JSON(status, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao aprovar sugestão"})
+		}
+		return
+	}
+
+	response := gin.H{
+		"message":    "Sugestão aprovada, rascunho criado",
+		"article":    newAdminArticle(article, nil),
+		"suggestion": suggestion,
+	}
+	if sanitized != nil {
+		response["sanitized"] = sanitized
+	}
+	if translation != nil {
+		response["translated_by"] = translation.Provider
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// RejectSuggestionRequest estrutura para rejeitar uma sugestão
+type RejectSuggestionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RejectSuggestion tira a sugestão da fila; ela não volta a ser sugerida pelo scraper
+func (h *SuggestionHandler) RejectSuggestion(c *gin.Context) {
+	var req RejectSuggestionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+			return
+		}
+	}
+
+	var suggestion models.ScrapedArticle
+	if err := h.db.First(&suggestion, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sugestão não encontrada"})
+		return
+	}
+
+	result := h.db.Model(&suggestion).Where("status = ?", scraper.StatusPending).
+		Updates(reviewUpdates(c, scraper.StatusRejected, map[string]interface{}{
+			"rejection_reason": strings.TrimSpace(req.Reason),
+		}))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao rejeitar sugestão"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errSuggestionReviewed.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// RestoreDuplicate devolve à fila uma sugestão marcada como repetida por engano
+func (h *SuggestionHandler) RestoreDuplicate(c *gin.Context) {
+	var suggestion models.ScrapedArticle
+	if err := h.db.First(&suggestion, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sugestão não encontrada"})
+		return
+	}
+
+	result := h.db.Model(&suggestion).Where("status = ?", scraper.StatusDuplicate).
+		Updates(map[string]interface{}{
+			"status":                  scraper.StatusPending,
+			"suggested":               true,
+			"duplicate_of_id":         nil,
+			"duplicate_of_article_id": nil,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao atualizar sugestão"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Sugestão não está marcada como repetida"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// RunScraper dispara uma execução do scraper em segundo plano
+func (h *SuggestionHandler) RunScraper(c *gin.Context) {
+	if h.job == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Scraper não configurado"})
+		return
+	}
+
+	if err := h.job.Trigger(); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Scraper iniciado"})
+}
+
+// RelatedNewsRequest permite ajustar a busca de notícias relacionadas
+type RelatedNewsRequest struct {
+	Query string `json:"query"` // padrão: palavras relevantes do título do artigo
+	Limit int    `json:"limit"`
+}
+
+// FindRelatedNews busca notícias relacionadas ao artigo no provedor configurado e grava as
+// novas como sugestões pendentes, na categoria do artigo
+func (h *SuggestionHandler) FindRelatedNews(c *gin.Context) {
+	var req RelatedNewsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+			return
+		}
+	}
+
+	var article models.Article
+	if err := h.db.First(&article, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Artigo não encontrado"})
+		return
+	}
+
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		query = scraper.RelatedNewsQuery(article)
+	}
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Não foi possível montar a busca a partir do título; informe query"})
+		return
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRelatedNewsLimit
+	}
+	if limit > maxRelatedNewsLimit {
+		limit = maxRelatedNewsLimit
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), relatedNewsTimeout)
+	defer cancel()
+
+	found, articles, err := h.service.RelatedNews(ctx, article, scraper.NewsQuery{Query: query, Language: "pt", Limit: limit})
+	if err != nil && len(articles) == 0 {
+		switch {
+		case errors.Is(err, scraper.ErrNewsNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, scraper.ErrNewsQuota):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Erro ao buscar notícias: " + err.Error()})
+		}
+		return
+	}
+
+	// Uma busca interrompida ainda grava as notícias extraídas até ali
+	saved, saveErr := scraper.SaveSuggestions(h.db, articles)
+	if saveErr != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gravar sugestões"})
+		return
+	}
+
+	response := gin.H{
+		"query":             query,
+		"found":             found,
+		"created":           saved.Created,
+		"marked_duplicates": saved.Duplicates,
+		"skipped":           found - saved.Created - saved.Duplicates,
+	}
+	if err != nil {
+		response["warning"] = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+var errSuggestionReviewed = errors.New("sugestão já foi revisada")
+
+// reviewUpdates monta os campos comuns de uma revisão (situação, data e revisor)
+func reviewUpdates(c *gin.Context, status string, extra map[string]interface{}) map[string]interface{} {
+	updates := map[string]interface{}{
+		"status":      status,
+		"reviewed_at": time.Now(),
+		"suggested":   false,
+	}
+	if userID := c.GetUint("user_id"); userID != 0 {
+		updates["reviewed_by"] = userID
+	}
+	for key, value := range extra {
+		updates[key] = value
+	}
+	return updates
+}
+
+// uniqueArticleSlug gera um slug a partir do título que ainda não esteja em uso
+// (inclusive por artigos removidos, já que o índice é único)
+func uniqueArticleSlug(tx *gorm.DB, title string, fallbackID uint) (string, error) {
+	base := textutil.Slugify(title)
+	if base == "" {
+		base = "sugestao-" + strconv.FormatUint(uint64(fallbackID), 10)
+	}
+
+	slug := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Article{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+		slug = base + "-" + strconv.Itoa(i)
+	}
+}
+
+// plainTextToHTML converte o texto extraído pelo scraper em parágrafos HTML.
+// Conteúdo que já é HTML é mantido como está.
+func plainTextToHTML(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "<") {
+		return text
+	}
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			b.WriteString("<p>" + html.EscapeString(paragraph) + "</p>\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ryv-api/database"
+	"ryv-api/middleware"
+	"ryv-api/models"
+	"ryv-api/tracking"
+
+	"github.com/gin-gonic/gin"
+)
+
+// viewRecorder registra as visualizações e eventos dos artigos; quando não configurado,
+// tudo é gravado de forma síncrona
+var viewRecorder tracking.Recorder
+
+// SetViewRecorder define como as visualizações e eventos de artigos são registrados
+func SetViewRecorder(recorder tracking.Recorder) {
+	viewRecorder = recorder
+}
+
+// recordArticleView registra uma visualização do artigo, ignorando bots.
+// Quando o leitor anônimo é identificado, a leitura também entra no seu histórico.
+func recordArticleView(c *gin.Context, article models.Article) {
+	userAgent := c.GetHeader("User-Agent")
+	if tracking.IsBot(userAgent) {
+		return
+	}
+
+	view := tracking.NewView(article.ID, c.ClientIP(), userAgent)
+	view.ReaderID = middleware.GetReaderID(c)
+	view.Category = article.Category
+	currentViewRecorder().Record(view)
+}
+
+// currentViewRecorder retorna o recorder configurado ou um síncrono como padrão
+func currentViewRecorder() tracking.Recorder {
+	if viewRecorder == nil {
+		return tracking.NewDirectRecorder(database.DB)
+	}
+	return viewRecorder
+}
+
+// ArticleEventRequest estrutura para eventos de leitura enviados pelo frontend
+type ArticleEventRequest struct {
+	Type   string `json:"type" binding:"required"` // scroll, time_on_page ou cta_click
+	Value  int    `json:"value"`                   // marco de rolagem (%) ou segundos na página
+	Target string `json:"target"`                  // CTA clicado, ex.: "whatsapp"
+}
+
+// RecordArticleEvent registra um evento de leitura (rolagem, tempo na página ou clique em CTA)
+func RecordArticleEvent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de artigo inválido"})
+		return
+	}
+
+	var req ArticleEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+
+	event, err := tracking.NewEngagement(uint(id), req.Type, req.Value, req.Target)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Eventos de robôs são aceitos, mas não contabilizados. Cada marco conta uma vez por
+	// visitante e dia; eventos de artigos inexistentes ou não publicados são descartados
+	// na gravação, sem consultar o banco aqui.
+	userAgent := c.GetHeader("User-Agent")
+	if !tracking.IsBot(userAgent) {
+		event.VisitorHash = tracking.VisitorHash(event.Date, c.ClientIP(), userAgent)
+		currentViewRecorder().RecordEngagement(event)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Evento registrado"})
+}
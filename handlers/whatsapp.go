@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"ryv-api/database"
+	"ryv-api/experiments"
+	"ryv-api/middleware"
 	"ryv-api/models"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +23,7 @@ func CreateWhatsAppContact(c *gin.Context) {
 	// Capturar informações do cliente
 	contact.IPAddress = c.ClientIP()
 	contact.UserAgent = c.GetHeader("User-Agent")
+	contact.ReaderID = middleware.GetReaderID(c)
 	
 	// Se não foi especificada uma fonte, usar a página atual
 	if contact.Source == "" {
@@ -30,6 +34,11 @@ func CreateWhatsAppContact(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao registrar contato"})
 		return
 	}
+
+	// O contato conta como conversão nos testes A/B dos quais o leitor participa
+	if err := experiments.RecordConversion(database.DB, contact.ReaderID); err != nil {
+		log.Printf("Erro ao registrar conversão do contato %d: %v", contact.ID, err)
+	}
 	
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Contato registrado com sucesso",
@@ -0,0 +1,151 @@
+package imaging
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Modos de enquadramento no redimensionamento
+const (
+	FitContain = "contain" // cabe em largura x altura, mantendo a proporção
+	FitCover   = "cover"   // preenche largura x altura, cortando o excesso das bordas
+)
+
+// Formatos gerados
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+// jpegQuality equilibra tamanho e qualidade para fotos em telas
+const jpegQuality = 82
+
+// DefaultWidths são as larguras das versões geradas no envio, para o srcset, e as únicas
+// medidas aceitas em /media/:id
+var DefaultWidths = []int{320, 640, 960, 1280, 1920}
+
+// ErrUnsupportedFormat indica um formato de saída desconhecido
+var ErrUnsupportedFormat = errors.New("formato de imagem não suportado")
+
+// NormalizeFit valida o modo de enquadramento ("" é contain)
+func NormalizeFit(fit string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(fit)) {
+	case "", FitContain:
+		return FitContain, true
+	case FitCover:
+		return FitCover, true
+	}
+	return "", false
+}
+
+// NormalizeFormat valida o formato de saída ("jpg" é jpeg)
+func NormalizeFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatJPEG, "jpg":
+		return FormatJPEG, true
+	case FormatWebP:
+		return FormatWebP, true
+	}
+	return "", false
+}
+
+// ContentType é o tipo MIME do formato
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Decode lê uma imagem JPEG, PNG, GIF (primeiro quadro) ou WebP
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Resize redimensiona a imagem para caber em width x height (0 em uma das medidas mantém a
+// proporção). Imagens nunca são ampliadas; sem medidas, a imagem volta como está.
+func Resize(src image.Image, width, height int, fit string) image.Image {
+	bounds := src.Bounds()
+	dst, crop := plan(bounds.Dx(), bounds.Dy(), width, height, fit)
+	if dst.Eq(bounds.Size()) && crop.Eq(image.Rect(0, 0, bounds.Dx(), bounds.Dy())) {
+		return src
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, dst.X, dst.Y))
+	draw.CatmullRom.Scale(out, out.Bounds(), src, crop.Add(bounds.Min), draw.Src, nil)
+	return out
+}
+
+// plan calcula o tamanho final e a parte da imagem original usada
+func plan(sourceWidth, sourceHeight, width, height int, fit string) (image.Point, image.Rectangle) {
+	full := image.Rect(0, 0, sourceWidth, sourceHeight)
+	if width <= 0 && height <= 0 {
+		return full.Size(), full
+	}
+
+	if fit == FitCover && width > 0 && height > 0 {
+		// Sem ampliar: o quadro pedido é reduzido até caber na imagem original
+		scale := math.Min(1, math.Min(float64(sourceWidth)/float64(width), float64(sourceHeight)/float64(height)))
+		width = max(1, int(math.Round(float64(width)*scale)))
+		height = max(1, int(math.Round(float64(height)*scale)))
+
+		// Parte central da original com a proporção do quadro
+		crop := full
+		if sourceWidth*height > sourceHeight*width {
+			cropWidth := sourceHeight * width / height
+			crop.Min.X = (sourceWidth - cropWidth) / 2
+			crop.Max.X = crop.Min.X + cropWidth
+		} else {
+			cropHeight := sourceWidth * height / width
+			crop.Min.Y = (sourceHeight - cropHeight) / 2
+			crop.Max.Y = crop.Min.Y + cropHeight
+		}
+		return image.Pt(width, height), crop
+	}
+
+	scale := 1.0
+	if width > 0 {
+		scale = math.Min(scale, float64(width)/float64(sourceWidth))
+	}
+	if height > 0 {
+		scale = math.Min(scale, float64(height)/float64(sourceHeight))
+	}
+	return image.Pt(
+		max(1, int(math.Round(float64(sourceWidth)*scale))),
+		max(1, int(math.Round(float64(sourceHeight)*scale))),
+	), full
+}
+
+// Encode grava a imagem no formato pedido. O WebP é sem perdas (não há codificador com
+// perdas em Go puro): compensa em ilustrações e capturas de tela, mas fotos costumam ficar
+// menores em JPEG. No JPEG, a transparência vira fundo branco.
+func Encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: jpegQuality})
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	}
+	return ErrUnsupportedFormat
+}
+
+// flatten aplica a imagem sobre fundo branco se ela tiver transparência
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Over)
+	return out
+}
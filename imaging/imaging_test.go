@@ -0,0 +1,152 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name                      string
+		sourceWidth, sourceHeight int
+		width, height             int
+		fit                       string
+		wantSize                  image.Point
+		wantCrop                  image.Rectangle
+	}{
+		{"sem medidas", 2000, 1000, 0, 0, FitContain, image.Pt(2000, 1000), image.Rect(0, 0, 2000, 1000)},
+		{"paisagem pela largura", 2000, 1000, 1000, 0, FitContain, image.Pt(1000, 500), image.Rect(0, 0, 2000, 1000)},
+		{"paisagem pela altura", 2000, 1000, 0, 250, FitContain, image.Pt(500, 250), image.Rect(0, 0, 2000, 1000)},
+		{"retrato em quadro quadrado", 1000, 2000, 640, 640, FitContain, image.Pt(320, 640), image.Rect(0, 0, 1000, 2000)},
+		{"maior que a original não amplia", 800, 600, 1920, 0, FitContain, image.Pt(800, 600), image.Rect(0, 0, 800, 600)},
+		{"altura mínima de 1 pixel", 1000, 1, 320, 0, FitContain, image.Pt(320, 1), image.Rect(0, 0, 1000, 1)},
+		// cover precisa das duas medidas; com uma só, funciona como contain
+		{"cover com uma medida", 2000, 1000, 640, 0, FitCover, image.Pt(640, 320), image.Rect(0, 0, 2000, 1000)},
+		{"cover paisagem corta as laterais", 2000, 1000, 640, 640, FitCover, image.Pt(640, 640), image.Rect(500, 0, 1500, 1000)},
+		{"cover retrato corta em cima e embaixo", 1000, 2000, 640, 320, FitCover, image.Pt(640, 320), image.Rect(0, 750, 1000, 1250)},
+		{"cover mesma proporção", 1600, 900, 640, 360, FitCover, image.Pt(640, 360), image.Rect(0, 0, 1600, 900)},
+		// O quadro é reduzido até caber na original, mantendo a proporção pedida
+		{"cover maior que a original", 400, 300, 1280, 1280, FitCover, image.Pt(300, 300), image.Rect(50, 0, 350, 300)},
+	}
+	for _, tt := range tests {
+		size, crop := plan(tt.sourceWidth, tt.sourceHeight, tt.width, tt.height, tt.fit)
+		if size != tt.wantSize || crop != tt.wantCrop {
+			t.Errorf("%s: plan = %v, %v, esperado %v, %v", tt.name, size, crop, tt.wantSize, tt.wantCrop)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for x := 0; x < 400; x++ {
+		for y := 0; y < 200; y++ {
+			// Metade esquerda vermelha, direita azul
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	if got := Resize(src, 0, 0, FitContain); got != image.Image(src) {
+		t.Error("Resize sem medidas deveria retornar a própria imagem")
+	}
+	if got := Resize(src, 800, 0, FitContain); got != image.Image(src) {
+		t.Error("Resize maior que a original deveria retornar a própria imagem")
+	}
+
+	small := Resize(src, 100, 0, FitContain)
+	if size := small.Bounds().Size(); size != image.Pt(100, 50) {
+		t.Errorf("contain = %v, esperado (100,50)", size)
+	}
+
+	// O cover quadrado fica com o centro: metade vermelha e metade azul
+	square := Resize(src, 100, 100, FitCover)
+	if size := square.Bounds().Size(); size != image.Pt(100, 100) {
+		t.Fatalf("cover = %v, esperado (100,100)", size)
+	}
+	if r, _, b, _ := square.At(10, 50).RGBA(); r < 0xf000 || b > 0x1000 {
+		t.Errorf("lado esquerdo do cover = r%d b%d, esperado vermelho", r>>8, b>>8)
+	}
+	if r, _, b, _ := square.At(90, 50).RGBA(); b < 0xf000 || r > 0x1000 {
+		t.Errorf("lado direito do cover = r%d b%d, esperado azul", r>>8, b>>8)
+	}
+
+	// Imagens que não começam em (0,0), como sub-imagens, são cortadas a partir da origem delas
+	sub := src.SubImage(image.Rect(200, 0, 400, 200))
+	if r, _, b, _ := Resize(sub, 50, 50, FitCover).At(25, 25).RGBA(); b < 0xf000 || r > 0x1000 {
+		t.Errorf("sub-imagem = r%d b%d, esperado azul", r>>8, b>>8)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	transparent := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	transparent.Set(0, 0, color.NRGBA{})                           // totalmente transparente
+	transparent.Set(1, 0, color.NRGBA{R: 255, G: 0, B: 0, A: 128}) // vermelho semitransparente
+
+	out := flatten(transparent)
+	if r, g, b, a := out.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff || a != 0xffff {
+		t.Errorf("pixel transparente = %d,%d,%d,%d, esperado branco", r>>8, g>>8, b>>8, a>>8)
+	}
+	if r, g, _, a := out.At(1, 0).RGBA(); r>>8 != 255 || g>>8 < 120 || g>>8 > 135 || a != 0xffff {
+		t.Errorf("pixel semitransparente = r%d g%d a%d, esperado rosa opaco", r>>8, g>>8, a>>8)
+	}
+
+	opaque := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	opaque.Set(0, 0, color.RGBA{A: 255})
+	if flatten(opaque) != image.Image(opaque) {
+		t.Error("imagem opaca deveria voltar como está")
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for _, format := range []string{FormatJPEG, FormatWebP} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, format); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		img, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if size := img.Bounds().Size(); size != image.Pt(8, 4) {
+			t.Errorf("%s: tamanho = %v, esperado (8,4)", format, size)
+		}
+	}
+	if err := Encode(&bytes.Buffer{}, src, "gif"); err != ErrUnsupportedFormat {
+		t.Errorf("Encode gif: erro = %v, esperado %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	fits := []struct {
+		value, want string
+		ok          bool
+	}{
+		{"", FitContain, true},
+		{"COVER", FitCover, true},
+		{"fill", "", false},
+	}
+	for _, tt := range fits {
+		if got, ok := NormalizeFit(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeFit(%q) = %q, %v, esperado %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+	formats := []struct {
+		value, want string
+		ok          bool
+	}{
+		{"jpg", FormatJPEG, true},
+		{" WebP ", FormatWebP, true},
+		{"png", "", false},
+	}
+	for _, tt := range formats {
+		if got, ok := NormalizeFormat(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeFormat(%q) = %q, %v, esperado %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package locale
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Default é o idioma principal do blog, usado quando o pedido não indica outro suportado
+// e quando um artigo não tem versão no idioma pedido
+const Default = "pt-BR"
+
+// Supported são os idiomas em que os artigos podem ser publicados
+var Supported = []string{Default, "en", "es"}
+
+var (
+	supportedTags = []language.Tag{language.BrazilianPortuguese, language.English, language.Spanish}
+	matcher       = language.NewMatcher(supportedTags)
+)
+
+// Normalize converte um código de idioma (ex.: "pt", "pt_br", "en-US", "es-AR") para o
+// idioma suportado correspondente. ok é falso para idiomas não suportados.
+func Normalize(code string) (string, bool) {
+	code = strings.TrimSpace(strings.ReplaceAll(code, "_", "-"))
+	if code == "" {
+		return "", false
+	}
+	tag, err := language.Parse(code)
+	if err != nil {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(tag)
+	if confidence < language.High {
+		return "", false
+	}
+	return Supported[index], true
+}
+
+// Negotiate escolhe o idioma da resposta: o parâmetro lang, se suportado, ou o idioma mais
+// preferido do cabeçalho Accept-Language entre os suportados, ou Default
+func Negotiate(lang, acceptLanguage string) string {
+	if normalized, ok := Normalize(lang); ok {
+		return normalized
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence < language.High {
+		return Default
+	}
+	return Supported[index]
+}
@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"ryv-api/classifier"
 	"ryv-api/database"
 	"ryv-api/handlers"
 	"ryv-api/middleware"
+	"ryv-api/related"
+	"ryv-api/sanitize"
+	"ryv-api/scheduler"
+	"ryv-api/scraper"
+	"ryv-api/storage"
+	"ryv-api/tracking"
+	"ryv-api/translate"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -29,29 +45,135 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000", "http://127.0.0.1:3000"}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.ReaderHeaderName}
+	config.ExposeHeaders = []string{middleware.ReaderHeaderName}
+	config.AllowCredentials = true
 	r.Use(cors.New(config))
 
+	// Visitantes únicos são identificados por um HMAC de IP + User-Agent com uma chave do dia
+	if secret := os.Getenv("VISITOR_HASH_SECRET"); secret != "" {
+		tracking.SetVisitorSecret(secret)
+	} else {
+		log.Println("⚠️ VISITOR_HASH_SECRET não definido: a chave dos visitantes muda a cada reinício")
+	}
+
+	// Visualizações de artigos são acumuladas em memória e gravadas em lote
+	viewRecorder := tracking.NewBufferedRecorder(db, viewFlushInterval())
+	viewRecorder.Start()
+	handlers.SetViewRecorder(viewRecorder)
+
+	// Índice de artigos relacionados ("leia também"), atualizado quando artigos mudam
+	relatedIndex := related.NewIndex(db)
+	handlers.SetRelatedIndex(relatedIndex)
+	go func() {
+		if err := relatedIndex.Rebuild(); err != nil {
+			log.Printf("Erro ao construir índice de artigos relacionados: %v", err)
+		}
+	}()
+
+	// Política de sanitização do HTML dos artigos; EMBED_HOSTS lista os sites de vídeo e
+	// áudio que podem ser incorporados
+	handlers.SetSanitizePolicy(sanitize.NewPolicy(sanitize.ParseHosts(os.Getenv("EMBED_HOSTS"))))
+
+	// Classificador de categorias (naive Bayes) treinado nos artigos já categorizados
+	articleClassifier := classifier.New(db)
+	handlers.SetClassifier(articleClassifier)
+	go func() {
+		if _, err := articleClassifier.Train(); err != nil {
+			log.Printf("Classificador de categorias: %v", err)
+		}
+	}()
+
+	// Scraper de sugestões de posts, moderadas no painel antes de virarem rascunhos
+	scraperService := scraper.NewScraperService(db, scraperConfig())
+	scraperService.SetClassifier(articleClassifier)
+	if apiKey := os.Getenv("NEWSAPI_KEY"); apiKey != "" {
+		// Busca de notícias relacionadas a um artigo, que também alimenta a fila de sugestões
+		scraperService.SetNewsProvider(scraper.NewNewsAPIProvider(apiKey, os.Getenv("NEWSAPI_URL")), envDuration("NEWS_CACHE_TTL", scraper.DefaultNewsCacheTTL))
+	}
+	// Tradução das sugestões aprovadas para pt-BR (os sites monitorados são em inglês).
+	// TRANSLATOR=fake usa o tradutor determinístico, para desenvolvimento sem chave.
+	if os.Getenv("TRANSLATOR") == "fake" {
+		scraperService.SetTranslator(translate.Fake{})
+	} else if apiKey := os.Getenv("DEEPL_API_KEY"); apiKey != "" {
+		scraperService.SetTranslator(translate.NewDeepLTranslator(apiKey, os.Getenv("DEEPL_API_URL")))
+	}
+	suggestionJob := scraper.NewSuggestionJob(db, scraperService)
+
+	// Tarefas periódicas, com agenda editável no painel e bloqueio no banco para que só uma
+	// instância da API execute cada tarefa por vez
+	jobScheduler := scheduler.New(db, envDuration("SCHEDULER_CHECK_INTERVAL", scheduler.DefaultCheckInterval))
+	jobScheduler.Register(scheduler.Definition{
+		Name:        "scraper",
+		Description: "Coleta as fontes do scraper cuja agenda venceu e grava as sugestões",
+		Schedule:    scraperSchedule(),
+		Enabled:     os.Getenv("SCRAPER_INTERVAL") != "off",
+		Task:        scheduler.ScraperTask(suggestionJob),
+	})
+	jobScheduler.Register(scheduler.Definition{
+		Name:        "publish-scheduled",
+		Description: "Publica os rascunhos cuja data agendada chegou",
+		Schedule:    "* * * * *",
+		Enabled:     true,
+		Timeout:     5 * time.Minute,
+		Task:        scheduler.PublishScheduledTask(db, relatedIndex.Refresh),
+	})
+	jobScheduler.Register(scheduler.Definition{
+		Name:        "retention",
+		Description: "Apaga visitantes diários, páginas guardadas do scraper e execuções de tarefas antigas",
+		Schedule:    "0 4 * * *",
+		Enabled:     true,
+		Task:        scheduler.RetentionTask(db, scheduler.DefaultRetention),
+	})
+	if err := jobScheduler.Start(); err != nil {
+		log.Fatalf("Erro ao iniciar o agendador de tarefas: %v", err)
+	}
+
 	// Inicializar handlers
 	recommendationHandler := handlers.NewRecommendationHandler(db)
 	authHandler := handlers.NewAuthHandler(db)
+	analyticsHandler := handlers.NewAnalyticsHandler(db)
+	experimentHandler := handlers.NewExperimentHandler(db)
+	suggestionHandler := handlers.NewSuggestionHandler(db, scraperService, suggestionJob)
+	scraperSourceHandler := handlers.NewScraperSourceHandler(db, scraperService)
+	jobHandler := handlers.NewJobHandler(db, jobScheduler)
+	classifierHandler := handlers.NewClassifierHandler(articleClassifier)
+
+	// Biblioteca de mídia: arquivos no disco, servidos em /uploads, ou em um bucket S3
+	mediaStore := mediaStorage()
+	if local, ok := mediaStore.(*storage.Local); ok {
+		r.Static("/uploads", local.Dir())
+	}
+	mediaHandler := handlers.NewMediaHandler(db, mediaStore, int64(envInt("MEDIA_MAX_SIZE_MB", handlers.DefaultMaxMediaSize>>20))<<20)
 
 	// Rotas da API
 	api := r.Group("/api")
 	{
 		// Rotas públicas de artigos
 		articles := api.Group("/articles")
+		articles.Use(middleware.ReaderMiddleware())
 		{
 			articles.GET("", handlers.GetArticles)
+			articles.GET("/recommendations", recommendationHandler.PersonalizedRecommendations)
 			articles.GET("/categories", handlers.GetCategories)
 			articles.GET("/:id_or_slug", handlers.GetArticleByIDOrSlug)
+			articles.GET("/:id_or_slug/related", handlers.GetRelatedArticles)
+			articles.POST("/:id/events", handlers.RecordArticleEvent)
 		}
 
 		// Rota de recomendação diária
-		api.GET("/articles/daily-recommendation", recommendationHandler.DailyRecommendation)
+		api.GET("/articles/daily-recommendation", middleware.ReaderMiddleware(), recommendationHandler.DailyRecommendation)
+
+		// Rotas de testes A/B
+		publicExperiments := api.Group("/experiments")
+		publicExperiments.Use(middleware.ReaderMiddleware())
+		{
+			publicExperiments.POST("/:key/click", experimentHandler.RecordExperimentClick)
+		}
 
 		// Rotas do WhatsApp
 		whatsapp := api.Group("/whatsapp")
+		whatsapp.Use(middleware.ReaderMiddleware())
 		{
 			whatsapp.POST("/contact", handlers.CreateWhatsAppContact)
 		}
@@ -75,8 +197,59 @@ func main() {
 			adminArticles := protected.Group("/articles")
 			{
 				adminArticles.POST("", handlers.CreateArticle)
+				adminArticles.POST("/classify", classifierHandler.ClassifyArticle)
+				adminArticles.POST("/classify/retrain", classifierHandler.RetrainClassifier)
+				adminArticles.GET("/:id", handlers.GetAdminArticle)
 				adminArticles.PUT("/:id", handlers.UpdateArticle)
 				adminArticles.DELETE("/:id", handlers.DeleteArticle)
+				adminArticles.GET("/:id/views", analyticsHandler.ArticleViews)
+				adminArticles.GET("/:id/engagement", analyticsHandler.ArticleEngagement)
+				adminArticles.POST("/:id/related-news", suggestionHandler.FindRelatedNews)
+			}
+
+			// Biblioteca de mídia (admin)
+			adminMedia := protected.Group("/media")
+			{
+				adminMedia.GET("", mediaHandler.ListMedia)
+				adminMedia.POST("", mediaHandler.UploadMedia)
+				adminMedia.GET("/:id", mediaHandler.GetMedia)
+				adminMedia.PUT("/:id", mediaHandler.UpdateMedia)
+				adminMedia.DELETE("/:id", mediaHandler.DeleteMedia)
+			}
+
+			// Fila de sugestões do scraper (admin)
+			adminSuggestions := protected.Group("/suggestions")
+			{
+				adminSuggestions.GET("", suggestionHandler.ListSuggestions)
+				adminSuggestions.POST("/scrape", suggestionHandler.RunScraper)
+				adminSuggestions.GET("/:id", suggestionHandler.GetSuggestion)
+				adminSuggestions.POST("/:id/approve", suggestionHandler.ApproveSuggestion)
+				adminSuggestions.POST("/:id/reject", suggestionHandler.RejectSuggestion)
+				adminSuggestions.POST("/:id/not-duplicate", suggestionHandler.RestoreDuplicate)
+			}
+
+			// Fontes do scraper (admin)
+			adminScraperSources := protected.Group("/scraper/sources")
+			{
+				adminScraperSources.GET("", scraperSourceHandler.ListScraperSources)
+				adminScraperSources.POST("", scraperSourceHandler.CreateScraperSource)
+				adminScraperSources.POST("/test", scraperSourceHandler.TestScraperSource)
+				adminScraperSources.GET("/:id", scraperSourceHandler.GetScraperSource)
+				adminScraperSources.PUT("/:id", scraperSourceHandler.UpdateScraperSource)
+				adminScraperSources.DELETE("/:id", scraperSourceHandler.DeleteScraperSource)
+				adminScraperSources.POST("/:id/test", scraperSourceHandler.TestSavedScraperSource)
+			}
+			protected.POST("/scraper/extract", scraperSourceHandler.ExtractArticle)
+
+			// Tarefas periódicas (admin)
+			adminJobs := protected.Group("/jobs")
+			{
+				adminJobs.GET("", jobHandler.ListJobs)
+				adminJobs.GET("/:name", jobHandler.GetJob)
+				adminJobs.PUT("/:name", jobHandler.UpdateJob)
+				adminJobs.POST("/:name/run", jobHandler.RunJob)
+				adminJobs.GET("/:name/runs", jobHandler.ListJobRuns)
+				adminJobs.GET("/:name/runs/:run_id", jobHandler.GetJobRun)
 			}
 
 			// Rotas de contatos WhatsApp (admin)
@@ -85,9 +258,45 @@ func main() {
 				adminWhatsApp.GET("/contacts", handlers.GetWhatsAppContacts)
 				adminWhatsApp.GET("/stats", handlers.GetWhatsAppContactStats)
 			}
+
+			// Configuração da recomendação diária (admin)
+			adminRecommendations := protected.Group("/recommendations")
+			{
+				adminRecommendations.GET("/config", recommendationHandler.GetScoringConfig)
+				adminRecommendations.PUT("/config", recommendationHandler.UpdateScoringSettings)
+				adminRecommendations.PUT("/config/categories/:category", recommendationHandler.UpdateCategoryScoring)
+				adminRecommendations.GET("/preview", recommendationHandler.PreviewRanking)
+				adminRecommendations.GET("/daily", recommendationHandler.ListDailyPicks)
+				adminRecommendations.PUT("/daily/:date", recommendationHandler.PinDailyPick)
+				adminRecommendations.DELETE("/daily/:date", recommendationHandler.UnpinDailyPick)
+			}
+
+			// Testes A/B (admin)
+			adminExperiments := protected.Group("/experiments")
+			{
+				adminExperiments.GET("", experimentHandler.ListExperiments)
+				adminExperiments.POST("", experimentHandler.CreateExperiment)
+				adminExperiments.PUT("/:id/status", experimentHandler.UpdateExperimentStatus)
+				adminExperiments.GET("/:id/results", experimentHandler.ExperimentResults)
+			}
+
+			// Rotas de analytics (admin)
+			analytics := protected.Group("/analytics")
+			{
+				analytics.GET("/overview", analyticsHandler.Overview)
+				analytics.GET("/contacts/timeseries", analyticsHandler.ContactsTimeSeries)
+				analytics.GET("/contacts/heatmap", analyticsHandler.ContactsHeatmap)
+				analytics.GET("/contacts/sources", analyticsHandler.TopSources)
+				analytics.GET("/contacts/articles", analyticsHandler.TopArticles)
+				analytics.GET("/articles/conversion", analyticsHandler.ArticleConversion)
+				analytics.GET("/articles/engagement", analyticsHandler.Engagement)
+			}
 		}
 	}
 
+	// Imagens da biblioteca em outros tamanhos (?w=&h=&fit=&format=), com cache longo
+	r.GET("/media/:id", mediaHandler.ServeMedia)
+
 	// Rota de health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -96,6 +305,123 @@ func main() {
 		})
 	})
 
-	log.Println("🚀 Servidor RYV API iniciado na porta 3001")
-	log.Fatal(r.Run(":3001"))
-} 
\ No newline at end of file
+	srv := &http.Server{
+		Addr:    ":3001",
+		Handler: r,
+	}
+
+	go func() {
+		log.Println("🚀 Servidor RYV API iniciado na porta 3001")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// Aguardar sinal de encerramento para desligar de forma graciosa
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("🛑 Encerrando servidor...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Erro ao encerrar servidor: %v", err)
+	}
+
+	jobScheduler.Stop()
+
+	// Gravar as visualizações que ainda estão no buffer
+	if err := viewRecorder.Stop(); err != nil {
+		log.Printf("Erro ao gravar visualizações pendentes: %v", err)
+	}
+	log.Println("✅ Servidor encerrado")
+}
+
+// viewFlushInterval lê o intervalo de gravação das visualizações de VIEW_FLUSH_INTERVAL
+func viewFlushInterval() time.Duration {
+	if value := os.Getenv("VIEW_FLUSH_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("⚠️ VIEW_FLUSH_INTERVAL inválido (%s), usando %s", value, tracking.DefaultFlushInterval)
+	}
+	return tracking.DefaultFlushInterval
+} 
+
+// scraperSchedule é a agenda inicial da verificação das fontes do scraper: a cada
+// SCRAPER_INTERVAL, ou a cada 10 minutos. Depois de criada, a agenda é editada no painel.
+func scraperSchedule() string {
+	if value := os.Getenv("SCRAPER_INTERVAL"); value != "" && value != "off" {
+		if _, err := scheduler.ParseSchedule("@every " + value); err == nil {
+			return "@every " + value
+		}
+		log.Printf("⚠️ SCRAPER_INTERVAL inválido (%s), usando 10m", value)
+	}
+	return "*/10 * * * *"
+}
+
+// mediaStorage escolhe o armazenamento da biblioteca de mídia: um bucket S3 (ou compatível)
+// com STORAGE=s3, ou o diretório MEDIA_DIR
+func mediaStorage() storage.Storage {
+	if os.Getenv("STORAGE") == "s3" {
+		store, err := storage.NewS3(storage.S3Config{
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			log.Fatalf("Erro ao configurar o armazenamento S3: %v", err)
+		}
+		return store
+	}
+
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	baseURL := os.Getenv("MEDIA_BASE_URL")
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	store, err := storage.NewLocal(dir, baseURL)
+	if err != nil {
+		log.Fatalf("Erro ao criar o diretório de mídia: %v", err)
+	}
+	return store
+}
+
+// scraperConfig lê a identificação e os limites de cortesia do scraper das variáveis SCRAPER_*
+func scraperConfig() scraper.Config {
+	return scraper.Config{
+		UserAgent:       os.Getenv("SCRAPER_USER_AGENT"),
+		HostDelay:       envDuration("SCRAPER_HOST_DELAY", scraper.DefaultHostDelay),
+		HostConcurrency: envInt("SCRAPER_HOST_CONCURRENCY", scraper.DefaultHostConcurrency),
+		Workers:         envInt("SCRAPER_WORKERS", scraper.DefaultWorkers),
+		Deadline:        envDuration("SCRAPER_DEADLINE", scraper.DefaultDeadline),
+	}
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+			return duration
+		}
+		log.Printf("⚠️ %s inválido (%s), usando %s", name, value, fallback)
+	}
+	return fallback
+}
+
+func envInt(name string, fallback int) int {
+	if value := os.Getenv(name); value != "" {
+		if number, err := strconv.Atoi(value); err == nil && number > 0 {
+			return number
+		}
+		log.Printf("⚠️ %s inválido (%s), usando %d", name, value, fallback)
+	}
+	return fallback
+}
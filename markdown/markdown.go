@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"ryv-api/textutil"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// Formatos do conteúdo dos artigos
+const (
+	FormatHTML     = "html"
+	FormatMarkdown = "markdown"
+)
+
+// Heading é um título do artigo, com a âncora gerada, para montar o sumário
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// md converte Markdown no estilo do GitHub (tabelas, tachado, links automáticos). Títulos
+// aceitam âncora própria ("## Introdução {#intro}"). HTML e atributos escritos no meio do
+// Markdown são mantidos: quem chama deve passar o resultado pela política de sanitização.
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.Linkify),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID(), parser.WithAttribute()),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// NormalizeFormat converte o formato informado ("", "html", "md", "markdown") para um dos
+// formatos suportados. ok é falso para formatos desconhecidos.
+func NormalizeFormat(format string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatHTML:
+		return FormatHTML, true
+	case "md", FormatMarkdown:
+		return FormatMarkdown, true
+	}
+	return "", false
+}
+
+// Render converte o Markdown em HTML, com âncoras (id) nos títulos, e retorna os títulos
+// na ordem do texto para o sumário
+func Render(source string) (string, []Heading, error) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	headings := []Heading{}
+	err := ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if value, found := heading.AttributeString("id"); found {
+			if b, isBytes := value.([]byte); isBytes {
+				id = string(b)
+			}
+		}
+		headings = append(headings, Heading{Level: heading.Level, ID: id, Text: nodeText(heading, src)})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, doc); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), headings, nil
+}
+
+// nodeText junta o texto dos filhos do nó, sem a formatação
+func nodeText(node ast.Node, src []byte) string {
+	var b strings.Builder
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		switch n := child.(type) {
+		case *ast.Text:
+			b.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(n.Value)
+		default:
+			b.WriteString(nodeText(child, src))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// headingIDs gera as âncoras dos títulos a partir do texto, sem acentos ("Introdução" ->
+// "introducao"), numerando as repetidas ("introducao-2")
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]bool)}
+}
+
+// Generate implementa parser.IDs
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := textutil.Slugify(string(value))
+	// A âncora precisa começar com letra (também é a regra da sanitização)
+	if base == "" || base[0] < 'a' || base[0] > 'z' {
+		base = strings.TrimSuffix("secao-"+base, "-")
+	}
+	id := base
+	for n := 2; ids.used[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	ids.used[id] = true
+	return []byte(id)
+}
+
+// Put implementa parser.IDs, para âncoras escritas pelo autor
+func (ids *headingIDs) Put(value []byte) {
+	ids.used[string(value)] = true
+}
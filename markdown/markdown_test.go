@@ -0,0 +1,179 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderHeadingIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []Heading
+	}{
+		{
+			name:   "acentos removidos",
+			source: "## Introdução à Visão\n\n### Cuidados com Óculos",
+			want: []Heading{
+				{Level: 2, ID: "introducao-a-visao", Text: "Introdução à Visão"},
+				{Level: 3, ID: "cuidados-com-oculos", Text: "Cuidados com Óculos"},
+			},
+		},
+		{
+			name:   "títulos repetidos são numerados",
+			source: "## Dicas\n\n## Dicas\n\n## Dicas",
+			want: []Heading{
+				{Level: 2, ID: "dicas", Text: "Dicas"},
+				{Level: 2, ID: "dicas-2", Text: "Dicas"},
+				{Level: 2, ID: "dicas-3", Text: "Dicas"},
+			},
+		},
+		{
+			name:   "âncora própria",
+			source: "## Introdução {#intro}\n\n## Intro",
+			want: []Heading{
+				{Level: 2, ID: "intro", Text: "Introdução"},
+				// A âncora gerada não repete a escrita pelo autor
+				{Level: 2, ID: "intro-2", Text: "Intro"},
+			},
+		},
+		{
+			name:   "âncora começa com letra",
+			source: "## 2024 em números\n\n## ???",
+			want: []Heading{
+				{Level: 2, ID: "secao-2024-em-numeros", Text: "2024 em números"},
+				{Level: 2, ID: "secao", Text: "???"},
+			},
+		},
+		{
+			name:   "formatação fora do texto do sumário",
+			source: "## Use **óculos** de `sol`\n\nSetext\n------",
+			want: []Heading{
+				{Level: 2, ID: "use-oculos-de-sol", Text: "Use óculos de sol"},
+				{Level: 2, ID: "setext", Text: "Setext"},
+			},
+		},
+		{
+			name:   "sem títulos",
+			source: "Só um parágrafo.",
+			want:   []Heading{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, headings, err := Render(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(headings, tt.want) {
+				t.Errorf("headings = %+v, esperado %+v", headings, tt.want)
+			}
+			for _, heading := range tt.want {
+				if !strings.Contains(content, `id="`+heading.ID+`"`) {
+					t.Errorf("HTML sem a âncora %q:\n%s", heading.ID, content)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTableOfContentsLevels(t *testing.T) {
+	source := `# Título
+
+## Sintomas
+
+### Olho seco
+
+#### Causas
+
+### Olho vermelho
+
+## Tratamento
+
+> ## Dentro da citação
+`
+	_, headings, err := Render(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O sumário é plano, na ordem do texto: a hierarquia vem do nível de cada título
+	want := []struct {
+		level int
+		id    string
+	}{
+		{1, "titulo"},
+		{2, "sintomas"},
+		{3, "olho-seco"},
+		{4, "causas"},
+		{3, "olho-vermelho"},
+		{2, "tratamento"},
+		{2, "dentro-da-citacao"},
+	}
+	if len(headings) != len(want) {
+		t.Fatalf("%d títulos, esperado %d: %+v", len(headings), len(want), headings)
+	}
+	for i, w := range want {
+		if headings[i].Level != w.level || headings[i].ID != w.id {
+			t.Errorf("título %d = h%d #%s, esperado h%d #%s", i, headings[i].Level, headings[i].ID, w.level, w.id)
+		}
+	}
+}
+
+func TestRenderKeepsRawHTML(t *testing.T) {
+	// O HTML passa como está; a política de sanitização é aplicada depois, por quem chama
+	source := "Texto com <span class=\"x\">span</span>.\n\n<script>alert(1)</script>\n\n## Título {.destaque onclick=\"x()\"}\n"
+	content, _, err := Render(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<span class="x">span</span>`, "<script>alert(1)</script>", `<h2 class="destaque" id="titulo">Título</h2>`} {
+		if !strings.Contains(content, want) {
+			t.Errorf("HTML sem %q:\n%s", want, content)
+		}
+	}
+	// Nos atributos de título, o goldmark só mantém os atributos globais conhecidos
+	if strings.Contains(content, "onclick") {
+		t.Errorf("HTML com onclick no título:\n%s", content)
+	}
+}
+
+func TestRenderExtensions(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"| a | b |\n|---|---|\n| 1 | 2 |", "<table>"},
+		{"~~riscado~~", "<del>riscado</del>"},
+		{"Veja https://exemplo.com.br hoje", `<a href="https://exemplo.com.br">https://exemplo.com.br</a>`},
+	}
+	for _, tt := range tests {
+		content, _, err := Render(tt.source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(content, tt.want) {
+			t.Errorf("Render(%q) = %q, esperado conter %q", tt.source, content, tt.want)
+		}
+	}
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+		ok     bool
+	}{
+		{"", FormatHTML, true},
+		{"HTML", FormatHTML, true},
+		{" md ", FormatMarkdown, true},
+		{"markdown", FormatMarkdown, true},
+		{"rst", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := NormalizeFormat(tt.format); got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeFormat(%q) = %q, %v, esperado %q, %v", tt.format, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ReaderCookieName é o cookie que guarda o identificador anônimo do leitor
+	ReaderCookieName = "ryv_reader"
+	// ReaderHeaderName permite que o frontend envie o identificador quando cookies não estão disponíveis
+	ReaderHeaderName = "X-Reader-ID"
+
+	readerCookieMaxAge = 365 * 24 * 60 * 60 // 1 ano
+)
+
+var readerIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// ReaderMiddleware identifica o leitor anônimo pelo header X-Reader-ID ou pelo cookie ryv_reader.
+// Se nenhum for enviado, gera um novo identificador e o devolve no cookie e no header.
+func ReaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		readerID := c.GetHeader(ReaderHeaderName)
+		if !ValidReaderID(readerID) {
+			readerID, _ = c.Cookie(ReaderCookieName)
+		}
+
+		if !ValidReaderID(readerID) {
+			var err error
+			readerID, err = newReaderID()
+			if err != nil {
+				c.Next()
+				return
+			}
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(ReaderCookieName, readerID, readerCookieMaxAge, "/", "", false, true)
+		c.Header(ReaderHeaderName, readerID)
+		c.Set("reader_id", readerID)
+
+		c.Next()
+	}
+}
+
+// ValidReaderID verifica se o identificador do leitor tem um formato aceito
+func ValidReaderID(readerID string) bool {
+	return readerIDPattern.MatchString(readerID)
+}
+
+// GetReaderID retorna o identificador do leitor definido pelo ReaderMiddleware
+func GetReaderID(c *gin.Context) string {
+	return c.GetString("reader_id")
+}
+
+func newReaderID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -3,28 +3,58 @@ package models
 import (
 	"time"
 
+	"ryv-api/locale"
+	"ryv-api/markdown"
+	"ryv-api/textutil"
+
 	"gorm.io/gorm"
 )
 
 // Article representa um artigo do blog
 type Article struct {
-	ID          uint           `json:"id" gorm:"primaryKey"`
-	Title       string         `json:"title" gorm:"not null"`
-	Slug        string         `json:"slug" gorm:"uniqueIndex;not null"`
-	Content     string         `json:"content" gorm:"type:text"`
-	Excerpt     string         `json:"excerpt"`
-	ImageURL    string         `json:"image_url"`
-	Category    string         `json:"category"` // saúde mental, ótica, optometria
-	Tags        string         `json:"tags"`     // tags separadas por vírgula
-	Author      string         `json:"author"`
-	AuthorID    *uint          `json:"author_id"`
-	SourceURL   string         `json:"source_url"`
-	PublishedAt *time.Time     `json:"published_at"`
-	IsPublished bool           `json:"is_published" gorm:"default:false"`
-	ViewCount   int            `json:"view_count" gorm:"default:0"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID               uint               `json:"id" gorm:"primaryKey"`
+	Title            string             `json:"title" gorm:"not null"`
+	Slug             string             `json:"slug" gorm:"uniqueIndex;not null"`
+	Content          string             `json:"content" gorm:"type:text"`                       // HTML servido aos leitores
+	ContentFormat    string             `json:"content_format" gorm:"default:'html'"`           // html ou markdown
+	ContentSource    string             `json:"-" gorm:"type:text"`                             // Markdown de onde o conteúdo foi gerado
+	TableOfContents  []markdown.Heading `json:"toc,omitempty" gorm:"serializer:json;type:text"` // títulos do conteúdo em Markdown, com as âncoras
+	Excerpt          string             `json:"excerpt"`
+	ImageURL         string             `json:"image_url"`
+	Category         string             `json:"category"` // saúde mental, ótica, optometria
+	Tags             string             `json:"tags"`     // tags separadas por vírgula
+	Author           string             `json:"author"`
+	AuthorID         *uint              `json:"author_id"`
+	SourceURL        string             `json:"source_url"`
+	PublishedAt      *time.Time         `json:"published_at"`
+	IsPublished      bool               `json:"is_published" gorm:"default:false"`
+	ViewCount        int                `json:"view_count" gorm:"default:0"`
+	WordCount        int                `json:"word_count" gorm:"default:0"`      // palavras do conteúdo sem HTML
+	ReadingMinutes   int                `json:"reading_minutes" gorm:"default:0"` // tempo estimado de leitura
+	ScheduledAt      *time.Time         `json:"scheduled_at" gorm:"index"`        // publicação agendada de um rascunho
+	Fingerprint      int64              `json:"-" gorm:"default:0"`               // SimHash do conteúdo, para detectar sugestões repetidas
+	OriginalLanguage string             `json:"original_language,omitempty"`      // idioma da sugestão traduzida que originou o artigo
+	OriginalTitle    string             `json:"-"`                                // título e conteúdo antes da tradução, só para o painel
+	OriginalContent  string             `json:"-" gorm:"type:text"`
+	Locale           string             `json:"locale" gorm:"index;default:'pt-BR'"`      // idioma do artigo (pt-BR, en ou es)
+	TranslationOfID  *uint              `json:"translation_of_id,omitempty" gorm:"index"` // artigo canônico (pt-BR) de que este é uma tradução
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt     `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// BeforeSave recalcula a contagem de palavras, o tempo de leitura e a impressão digital
+// do conteúdo sempre que o artigo é salvo, e normaliza o idioma
+func (a *Article) BeforeSave(tx *gorm.DB) error {
+	if normalized, ok := locale.Normalize(a.Locale); ok {
+		a.Locale = normalized
+	} else {
+		a.Locale = locale.Default
+	}
+	a.WordCount = textutil.WordCount(a.Content)
+	a.ReadingMinutes = textutil.ReadingMinutes(a.WordCount)
+	a.Fingerprint = int64(textutil.SimHash(textutil.StripHTML(a.Content)))
+	return nil
 }
 
 // WhatsAppContact representa um contato via WhatsApp
@@ -33,8 +63,9 @@ type WhatsAppContact struct {
 	Name      string         `json:"name" gorm:"not null"`
 	Phone     string         `json:"phone" gorm:"not null"`
 	Message   string         `json:"message"`
-	Source    string         `json:"source"` // página onde o contato foi feito
-	ArticleID *uint          `json:"article_id,omitempty"` // se foi feito a partir de um artigo
+	Source    string         `json:"source"`                                   // página onde o contato foi feito
+	ArticleID *uint          `json:"article_id,omitempty"`                     // se foi feito a partir de um artigo
+	ReaderID  string         `json:"reader_id,omitempty" gorm:"index;size:64"` // leitor anônimo que fez o contato
 	IPAddress string         `json:"ip_address"`
 	UserAgent string         `json:"user_agent"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -54,6 +85,18 @@ type Category struct {
 	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// CategoryTranslation é o nome e a descrição de uma categoria em outro idioma. A categoria
+// continua identificada pelo nome em português, gravado nos artigos.
+type CategoryTranslation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Category    string    `json:"category" gorm:"uniqueIndex:idx_category_translation;not null"`
+	Locale      string    `json:"locale" gorm:"uniqueIndex:idx_category_translation;not null"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 // User representa um usuário administrador
 type User struct {
 	ID           uint           `json:"id" gorm:"primaryKey"`
@@ -68,14 +111,307 @@ type User struct {
 
 // ScrapedArticle representa uma sugestão de post vinda do scraper
 type ScrapedArticle struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"not null"`
-	Excerpt   string         `json:"excerpt"`
-	Content   string         `json:"content" gorm:"type:text"`
-	ImageURL  string         `json:"image_url"`
-	SourceURL string         `json:"source_url"`
-	Suggested bool           `json:"suggested" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
-} 
\ No newline at end of file
+	ID                   uint           `json:"id" gorm:"primaryKey"`
+	Title                string         `json:"title" gorm:"not null"`
+	Excerpt              string         `json:"excerpt"`
+	Content              string         `json:"content" gorm:"type:text"` // HTML limpo do artigo
+	ImageURL             string         `json:"image_url"`
+	Author               string         `json:"author"`
+	SourceURL            string         `json:"source_url" gorm:"index"`
+	CanonicalURL         string         `json:"canonical_url" gorm:"index"` // URL normalizada, usada para encontrar repetidas
+	Fingerprint          int64          `json:"-" gorm:"default:0"`         // SimHash do conteúdo
+	Source               string         `json:"source" gorm:"index"`        // site de onde a sugestão veio
+	Category             string         `json:"category" gorm:"index"`      // categoria do site de origem
+	Language             string         `json:"language"`                   // idioma detectado (en ou pt); vazio se incerto
+	Tags                 string         `json:"tags"`                       // tags separadas por vírgula
+	PublishedAt          *time.Time     `json:"published_at"`
+	Suggested            bool           `json:"suggested" gorm:"default:true"`
+	Status               string         `json:"status" gorm:"index;default:'pending'"` // pending, approved, rejected ou duplicate
+	DuplicateOfID        *uint          `json:"duplicate_of_id,omitempty"`             // sugestão original, se for repetida
+	DuplicateOfArticleID *uint          `json:"duplicate_of_article_id,omitempty"`     // artigo já publicado com o mesmo conteúdo
+	ReviewedAt           *time.Time     `json:"reviewed_at"`
+	ReviewedBy           *uint          `json:"reviewed_by"`
+	RejectionReason      string         `json:"rejection_reason,omitempty"`
+	ArticleID            *uint          `json:"article_id,omitempty"` // rascunho criado na aprovação
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// BeforeSave normaliza a URL canônica (ou a deriva da URL de origem) e calcula a
+// impressão digital do conteúdo
+func (s *ScrapedArticle) BeforeSave(tx *gorm.DB) error {
+	canonical := textutil.CanonicalURL(s.CanonicalURL)
+	if canonical == "" {
+		canonical = textutil.CanonicalURL(s.SourceURL)
+	}
+	s.CanonicalURL = canonical
+	s.Fingerprint = int64(textutil.SimHash(textutil.StripHTML(s.Content)))
+	if s.Language == "" {
+		s.Language = textutil.DetectLanguage(s.Title + "\n" + s.Excerpt + "\n" + textutil.StripHTML(s.Content))
+	}
+	return nil
+}
+
+// ArticleView representa o agregado diário de visualizações de um artigo
+type ArticleView struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ArticleID      uint      `json:"article_id" gorm:"uniqueIndex:idx_article_view_day;not null"`
+	Date           string    `json:"date" gorm:"uniqueIndex:idx_article_view_day;size:10;not null"` // YYYY-MM-DD
+	Views          int       `json:"views" gorm:"default:0"`                                        // visualizações (sem bots)
+	UniqueVisitors int       `json:"unique_visitors" gorm:"default:0"`                              // visitantes únicos no dia
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ArticleVisitor registra um visitante (hash de IP + User-Agent) de um artigo em um dia
+type ArticleVisitor struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ArticleID   uint      `json:"article_id" gorm:"uniqueIndex:idx_article_visitor_day;not null"`
+	Date        string    `json:"date" gorm:"uniqueIndex:idx_article_visitor_day;size:10;not null;index"`
+	VisitorHash string    `json:"visitor_hash" gorm:"uniqueIndex:idx_article_visitor_day;size:32;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArticleEventVisitor registra que um visitante já enviou um marco de leitura (ex.:
+// "scroll:50") de um artigo em um dia; cada marco conta uma vez por visitante e dia
+type ArticleEventVisitor struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ArticleID   uint      `json:"article_id" gorm:"uniqueIndex:idx_article_event_visitor_day;not null"`
+	Date        string    `json:"date" gorm:"uniqueIndex:idx_article_event_visitor_day;size:10;not null;index"`
+	VisitorHash string    `json:"visitor_hash" gorm:"uniqueIndex:idx_article_event_visitor_day;size:32;not null"`
+	Milestone   string    `json:"milestone" gorm:"uniqueIndex:idx_article_event_visitor_day;size:32;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArticleEngagement representa o agregado diário de eventos de leitura de um artigo
+type ArticleEngagement struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	ArticleID         uint      `json:"article_id" gorm:"uniqueIndex:idx_article_engagement_day;not null"`
+	Date              string    `json:"date" gorm:"uniqueIndex:idx_article_engagement_day;size:10;not null"` // YYYY-MM-DD
+	Scroll25          int       `json:"scroll_25" gorm:"default:0"`                                          // leitores que chegaram a 25% do texto
+	Scroll50          int       `json:"scroll_50" gorm:"default:0"`
+	Scroll75          int       `json:"scroll_75" gorm:"default:0"`
+	Scroll100         int       `json:"scroll_100" gorm:"default:0"`
+	TimeOnPageSeconds int       `json:"time_on_page_seconds" gorm:"default:0"` // soma dos tempos informados
+	TimeOnPageSamples int       `json:"time_on_page_samples" gorm:"default:0"` // quantidade de tempos informados
+	CTAClicks         int       `json:"cta_clicks" gorm:"default:0"`           // cliques em qualquer CTA
+	WhatsAppClicks    int       `json:"whatsapp_clicks" gorm:"default:0"`      // cliques no botão do WhatsApp
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ReaderHistory registra os artigos lidos por um leitor anônimo
+type ReaderHistory struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ReaderID    string    `json:"reader_id" gorm:"uniqueIndex:idx_reader_article;size:64;not null"`
+	ArticleID   uint      `json:"article_id" gorm:"uniqueIndex:idx_reader_article;not null"`
+	Category    string    `json:"category"`
+	Reads       int       `json:"reads" gorm:"default:0"`
+	FirstReadAt time.Time `json:"first_read_at"`
+	LastReadAt  time.Time `json:"last_read_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ScoringSettings guarda os parâmetros gerais da pontuação de recomendação (registro único)
+type ScoringSettings struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	CuriosityWords    string    `json:"curiosity_words"` // palavras separadas por vírgula
+	CuriosityBonus    float64   `json:"curiosity_bonus"` // por palavra de curiosidade no título
+	EmotionalWords    string    `json:"emotional_words"` // palavras separadas por vírgula
+	EmotionalBonus    float64   `json:"emotional_bonus"` // por palavra emocional no título ou resumo
+	WeekBonus         float64   `json:"week_bonus"`      // artigos publicados nos últimos 7 dias
+	MonthBonus        float64   `json:"month_bonus"`     // artigos publicados nos últimos 30 dias
+	ViewWeight        float64   `json:"view_weight"`     // por visualização
+	EngagementWeight  float64   `json:"engagement_weight"`
+	RandomWeight      float64   `json:"random_weight"` // variação máxima para evitar sempre o mesmo artigo
+	DefaultMotivation string    `json:"default_motivation"`
+	NoRepeatDays      int       `json:"no_repeat_days" gorm:"default:7"` // dias até um artigo poder voltar a ser o do dia
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// CategoryScoring guarda o peso de uma categoria na pontuação de recomendação
+type CategoryScoring struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Category  string    `json:"category" gorm:"uniqueIndex;not null"`
+	Weight    float64   `json:"weight"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MotivationPhrase representa uma frase motivacional exibida na recomendação diária
+type MotivationPhrase struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Category  string    `json:"category" gorm:"index;not null"`
+	Locale    string    `json:"locale" gorm:"index;default:'pt-BR'"`
+	Phrase    string    `json:"phrase" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DailyPick registra o artigo recomendado em cada dia. Um dia fixado manualmente
+// pelos editores (Pinned) não é recalculado.
+type DailyPick struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      string    `json:"date" gorm:"uniqueIndex;size:10;not null"` // YYYY-MM-DD
+	ArticleID uint      `json:"article_id" gorm:"index;not null"`
+	Pinned    bool      `json:"pinned" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Experiment representa um teste A/B. Enquanto está em execução, cada leitor anônimo
+// é atribuído de forma fixa a uma das variantes.
+type Experiment struct {
+	ID          uint                `json:"id" gorm:"primaryKey"`
+	Key         string              `json:"key" gorm:"uniqueIndex;size:64;not null"`
+	Name        string              `json:"name" gorm:"not null"`
+	Description string              `json:"description"`
+	Target      string              `json:"target" gorm:"index;not null"`        // onde o teste é aplicado, ex.: daily_recommendation
+	Status      string              `json:"status" gorm:"index;default:'draft'"` // draft, running ou stopped
+	StartedAt   *time.Time          `json:"started_at"`
+	EndedAt     *time.Time          `json:"ended_at"`
+	Variants    []ExperimentVariant `json:"variants" gorm:"foreignKey:ExperimentID"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// ExperimentVariant representa uma das versões comparadas em um experimento
+type ExperimentVariant struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ExperimentID   uint      `json:"experiment_id" gorm:"uniqueIndex:idx_experiment_variant;not null"`
+	Key            string    `json:"key" gorm:"uniqueIndex:idx_experiment_variant;size:64;not null"`
+	Name           string    `json:"name"`
+	Weight         int       `json:"weight" gorm:"default:1"`              // proporção do tráfego
+	IsControl      bool      `json:"is_control" gorm:"default:false"`      // base de comparação dos resultados
+	Strategy       string    `json:"strategy" gorm:"default:'scoring'"`    // scoring, latest ou popular
+	HideMotivation bool      `json:"hide_motivation" gorm:"default:false"` // não exibe frase motivacional
+	Motivation     string    `json:"motivation"`                           // frase fixa no lugar das frases da categoria
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ExperimentAssignment guarda a variante de cada leitor e o que ele fez depois de vê-la
+type ExperimentAssignment struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	ExperimentID uint       `json:"experiment_id" gorm:"uniqueIndex:idx_experiment_reader;not null"`
+	ReaderID     string     `json:"reader_id" gorm:"uniqueIndex:idx_experiment_reader;index;size:64;not null"`
+	VariantID    uint       `json:"variant_id" gorm:"index;not null"`
+	Impressions  int        `json:"impressions" gorm:"default:0"`
+	Clicks       int        `json:"clicks" gorm:"default:0"`
+	Conversions  int        `json:"conversions" gorm:"default:0"`
+	ClickedAt    *time.Time `json:"clicked_at"`
+	ConvertedAt  *time.Time `json:"converted_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ScraperSource representa um site consultado pelo scraper: uma página HTML com os seletores
+// CSS usados para extrair cada campo dos itens, um feed ou um sitemap
+type ScraperSource struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"not null"`
+	URL           string     `json:"url" gorm:"uniqueIndex;not null"`
+	Type          string     `json:"type" gorm:"default:'html'"` // html, feed (RSS, Atom ou JSON Feed) ou sitemap
+	ItemSelector  string     `json:"item_selector"`              // cada item (card) da listagem; só para html
+	TitleSelector string     `json:"title_selector"`
+	BodySelector  string     `json:"body_selector"`
+	LinkSelector  string     `json:"link_selector"`
+	DateSelector  string     `json:"date_selector"` // lê o atributo datetime ou o texto
+	ImageSelector string     `json:"image_selector"`
+	TagsSelector  string     `json:"tags_selector"`
+	Category      string     `json:"category"`
+	Enabled       bool       `json:"enabled"`
+	Schedule      string     `json:"schedule" gorm:"default:'6h'"` // intervalo entre coletas (ex.: 30m, 6h, 24h)
+	LastRunAt     *time.Time `json:"last_run_at"`
+	LastFound     int        `json:"last_found"`
+	LastError     string     `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// FetchedPage guarda a última versão de uma página baixada pelo scraper, com o ETag e o
+// Last-Modified usados nas requisições condicionais seguintes
+type FetchedPage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	URL          string    `json:"url" gorm:"uniqueIndex;not null"`
+	FinalURL     string    `json:"final_url"` // URL após redirecionamentos
+	ETag         string    `json:"etag" gorm:"column:etag"`
+	LastModified string    `json:"last_modified"`
+	Body         string    `json:"-" gorm:"type:text"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ScheduledJob é uma tarefa periódica (coleta do scraper, publicação agendada, limpeza).
+// A agenda e a habilitação são editadas no painel; o bloqueio garante que só uma instância
+// da API execute a tarefa por vez.
+type ScheduledJob struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	Name           string     `json:"name" gorm:"uniqueIndex;not null"`
+	Description    string     `json:"description"`
+	Schedule       string     `json:"schedule" gorm:"not null"` // expressão cron ou "@every 10m"
+	Enabled        bool       `json:"enabled"`
+	NextRunAt      *time.Time `json:"next_run_at" gorm:"index"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastStatus     string     `json:"last_status"` // success ou failed
+	LastError      string     `json:"last_error"`
+	LastDurationMS int64      `json:"last_duration_ms"`
+	LockedBy       string     `json:"locked_by"`    // instância que está executando a tarefa
+	LockedUntil    *time.Time `json:"locked_until"` // o bloqueio expira se a instância cair
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// JobRun registra uma execução de uma tarefa periódica
+type JobRun struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	JobName    string     `json:"job_name" gorm:"index;not null"`
+	Trigger    string     `json:"trigger"`             // schedule ou manual
+	Instance   string     `json:"instance"`            // instância da API que executou
+	Status     string     `json:"status" gorm:"index"` // running, success ou failed
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at"`
+	DurationMS int64      `json:"duration_ms"`
+	Items      int        `json:"items"` // itens processados (sugestões criadas, artigos publicados...)
+	Error      string     `json:"error"`
+	Log        string     `json:"log,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Media representa um arquivo (imagem) da biblioteca de mídia
+type Media struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Key         string         `json:"key" gorm:"uniqueIndex;not null"` // caminho do arquivo no armazenamento
+	Storage     string         `json:"storage"`                         // local ou s3
+	URL         string         `json:"url" gorm:"index;not null"`       // endereço público, usado em image_url e no conteúdo
+	FileName    string         `json:"file_name"`                       // nome original do arquivo enviado
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"` // bytes
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	AltText     string         `json:"alt_text"`
+	UploadedBy  *uint          `json:"uploaded_by"`
+	Variants    []MediaVariant `json:"variants,omitempty" gorm:"foreignKey:MediaID"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// MediaVariant é uma versão redimensionada de uma imagem da biblioteca: as geradas no envio
+// (srcset) e as pedidas em /media/:id, guardadas para as próximas requisições
+type MediaVariant struct {
+	ID        uint      `json:"-" gorm:"primaryKey"`
+	MediaID   uint      `json:"-" gorm:"uniqueIndex:idx_media_variants_name;not null"`
+	Name      string    `json:"name" gorm:"uniqueIndex:idx_media_variants_name;not null"` // medidas, enquadramento e formato pedidos (ex.: w640-h0-contain.webp)
+	Key       string    `json:"-" gorm:"not null"`                                        // caminho do arquivo no armazenamento
+	URL       string    `json:"url"`
+	Format    string    `json:"format"` // jpeg ou webp
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Size      int64     `json:"size"`
+	Srcset    bool      `json:"srcset"` // gerada no envio, para o srcset dos artigos
+	CreatedAt time.Time `json:"created_at"`
+}
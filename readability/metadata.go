@@ -0,0 +1,217 @@
+package readability
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// articleTypes são os tipos schema.org de JSON-LD que descrevem um artigo
+var articleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "Report": true,
+	"ScholarlyArticle": true, "MedicalScholarlyArticle": true, "TechArticle": true,
+	"AnalysisNewsArticle": true, "OpinionNewsArticle": true, "ReportageNewsArticle": true,
+	"MedicalWebPage": true, "HealthTopicContent": true,
+}
+
+// dateLayouts são os formatos ISO 8601 usados em metadados
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// jsonLDArticle são os campos de artigo lidos do JSON-LD
+type jsonLDArticle struct {
+	Headline      string
+	Description   string
+	Image         string
+	Author        string
+	Publisher     string
+	DatePublished string
+}
+
+// readMetadata lê título, autor, imagem, data, descrição, site e link canônico dos
+// metadados da página.
+// O JSON-LD tem preferência, seguido do OpenGraph e das meta tags comuns.
+func readMetadata(doc *goquery.Document, pageURL *url.URL) Article {
+	var article Article
+	ld := readJSONLD(doc)
+
+	article.Title = firstNonEmpty(ld.Headline, meta(doc, "og:title"), meta(doc, "twitter:title"))
+	article.Excerpt = firstNonEmpty(meta(doc, "og:description"), meta(doc, "description"), ld.Description, meta(doc, "twitter:description"))
+	article.SiteName = firstNonEmpty(meta(doc, "og:site_name"), ld.Publisher)
+
+	if image := firstNonEmpty(meta(doc, "og:image"), meta(doc, "og:image:url"), ld.Image, meta(doc, "twitter:image")); image != "" {
+		article.ImageURL = resolve(pageURL, image)
+	}
+
+	if href, exists := doc.Find(`link[rel="canonical"]`).First().Attr("href"); exists && strings.TrimSpace(href) != "" {
+		article.CanonicalURL = resolve(pageURL, strings.TrimSpace(href))
+	} else if ogURL := meta(doc, "og:url"); ogURL != "" {
+		article.CanonicalURL = resolve(pageURL, ogURL)
+	}
+
+	article.Author = ld.Author
+	if article.Author == "" {
+		article.Author = meta(doc, "author")
+	}
+	// article:author costuma ser a URL do perfil, que não serve como nome
+	if author := meta(doc, "article:author"); article.Author == "" && !strings.HasPrefix(author, "http") {
+		article.Author = author
+	}
+	if article.Author == "" {
+		article.Author = cleanText(doc.Find(`[rel="author"], [itemprop="author"] [itemprop="name"], .byline, .author-name`).First().Text())
+	}
+
+	dates := []string{ld.DatePublished, meta(doc, "article:published_time"), meta(doc, "datePublished"), meta(doc, "date")}
+	if datetime, exists := doc.Find(`[itemprop="datePublished"]`).First().Attr("datetime"); exists {
+		dates = append(dates, datetime)
+	}
+	if datetime, exists := doc.Find("time[datetime]").First().Attr("datetime"); exists {
+		dates = append(dates, datetime)
+	}
+	for _, date := range dates {
+		if publishedAt, ok := parseDate(date); ok {
+			article.PublishedAt = publishedAt
+			break
+		}
+	}
+
+	return article
+}
+
+// readJSONLD procura um artigo nos blocos JSON-LD da página, inclusive dentro de @graph
+func readJSONLD(doc *goquery.Document) jsonLDArticle {
+	var found jsonLDArticle
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(i int, script *goquery.Selection) bool {
+		var data interface{}
+		if err := json.Unmarshal([]byte(script.Text()), &data); err != nil {
+			return true
+		}
+		if node := findArticleNode(data); node != nil {
+			found = jsonLDArticle{
+				Headline:      firstNonEmpty(jsonString(node["headline"]), jsonString(node["name"])),
+				Description:   jsonString(node["description"]),
+				Image:         jsonString(node["image"]),
+				Author:        jsonNames(node["author"]),
+				Publisher:     jsonNames(node["publisher"]),
+				DatePublished: firstNonEmpty(jsonString(node["datePublished"]), jsonString(node["dateCreated"])),
+			}
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func findArticleNode(data interface{}) map[string]interface{} {
+	switch value := data.(type) {
+	case []interface{}:
+		for _, item := range value {
+			if node := findArticleNode(item); node != nil {
+				return node
+			}
+		}
+	case map[string]interface{}:
+		if isArticleType(value["@type"]) {
+			return value
+		}
+		if graph, ok := value["@graph"]; ok {
+			return findArticleNode(graph)
+		}
+	}
+	return nil
+}
+
+func isArticleType(value interface{}) bool {
+	switch t := value.(type) {
+	case string:
+		return articleTypes[t]
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && articleTypes[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonString lê um valor que pode ser texto, objeto com url/name ou lista (usa o primeiro)
+func jsonString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return firstNonEmpty(jsonString(v["url"]), jsonString(v["name"]), jsonString(v["@id"]))
+	case []interface{}:
+		for _, item := range v {
+			if s := jsonString(item); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// jsonNames lê nomes de pessoas ou organizações; vários autores são separados por vírgula
+func jsonNames(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return jsonString(v["name"])
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			if name := jsonNames(item); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+func meta(doc *goquery.Document, name string) string {
+	selector := `meta[property="` + name + `"], meta[name="` + name + `"], meta[itemprop="` + name + `"]`
+	content, _ := doc.Find(selector).First().Attr("content")
+	return strings.TrimSpace(content)
+}
+
+func parseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func resolve(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	if base == nil {
+		return ref.String()
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+// Package readability extrai o conteúdo principal de páginas de artigos: encontra o corpo
+// do texto pela densidade de texto dos blocos, preserva a estrutura básica (títulos,
+// listas e parágrafos) como HTML limpo e lê os metadados OpenGraph e JSON-LD.
+package readability
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ryv-api/textutil"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	// minParagraphLength é o tamanho mínimo de um parágrafo para contar na pontuação
+	minParagraphLength = 25
+	// maxExcerptLength é o tamanho do resumo gerado quando a página não tem descrição
+	maxExcerptLength = 200
+)
+
+// Article é o resultado da extração de uma página
+type Article struct {
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	Excerpt      string    `json:"excerpt"`
+	ImageURL     string    `json:"image_url"`
+	SiteName     string    `json:"site_name"`
+	CanonicalURL string    `json:"canonical_url"` // link canônico declarado pela página
+	PublishedAt  time.Time `json:"published_at"`  // zero quando a página não informa
+	Content      string    `json:"content"`       // HTML limpo do corpo do artigo
+	Text         string    `json:"text"`          // o mesmo conteúdo em texto, parágrafos separados por linha em branco
+}
+
+var (
+	// Classes e ids de elementos que quase nunca fazem parte do artigo
+	unlikelyPattern = regexp.MustCompile(`(?i)comment|sidebar|footer|header|menu|\bnav|share|social|related|advert|\bads?\b|promo|newsletter|subscribe|cookie|popup|modal|breadcrumb|banner|sponsor|widget|disqus|skip|byline`)
+	// ... a menos que também indiquem conteúdo
+	maybePattern = regexp.MustCompile(`(?i)article|body|content|entry|main|post|text|story|column`)
+
+	positivePattern = regexp.MustCompile(`(?i)article|body|content|entry|main|post|text|story|blog|hentry`)
+	negativePattern = regexp.MustCompile(`(?i)comment|footer|sidebar|share|social|related|advert|promo|meta|\btags?\b|author-?box|widget|hidden|caption`)
+)
+
+// junkSelector são elementos removidos antes da pontuação
+const junkSelector = "script, style, noscript, iframe, form, nav, footer, aside, svg, button, input, select, textarea, object, embed, canvas, template, dialog, header"
+
+// Parse extrai o artigo do documento. Se contentSelector for informado e encontrar um
+// elemento, ele é usado como corpo em vez da pontuação por densidade de texto.
+func Parse(doc *goquery.Document, pageURL *url.URL, contentSelector string) Article {
+	// Os metadados são lidos antes da limpeza, que remove os scripts de JSON-LD
+	article := readMetadata(doc, pageURL)
+	if article.Title == "" {
+		article.Title = cleanText(doc.Find("h1").First().Text())
+	}
+	if article.Title == "" {
+		article.Title = cleanText(doc.Find("title").First().Text())
+	}
+
+	// O h1 é o título, que já vai à parte
+	doc.Find(junkSelector + ", h1").Remove()
+
+	var content []*html.Node
+	if strings.TrimSpace(contentSelector) != "" {
+		if selection := doc.Find(contentSelector).First(); selection.Length() > 0 {
+			content = selection.Nodes
+		}
+	}
+	if content == nil {
+		removeUnlikely(doc)
+		content = mainContent(doc)
+	}
+
+	article.Content = render(content, pageURL)
+	article.Text = Text(article.Content)
+
+	if article.Excerpt == "" {
+		article.Excerpt = Excerpt(article.Text)
+	}
+
+	return article
+}
+
+// Excerpt retorna o começo do texto, sem cortar palavras nem letras acentuadas ao meio
+func Excerpt(text string) string {
+	text = cleanText(text)
+	if utf8.RuneCountInString(text) <= maxExcerptLength {
+		return text
+	}
+	cut := string([]rune(text)[:maxExcerptLength])
+	if i := strings.LastIndex(cut, " "); i > maxExcerptLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "..."
+}
+
+// removeUnlikely remove os elementos cujas classes e ids indicam menus, comentários,
+// compartilhamento e afins
+func removeUnlikely(doc *goquery.Document) {
+	doc.Find("body *").Each(func(i int, selection *goquery.Selection) {
+		switch goquery.NodeName(selection) {
+		case "body", "article", "main", "a":
+			return
+		}
+		class, _ := selection.Attr("class")
+		id, _ := selection.Attr("id")
+		names := class + " " + id
+		if unlikelyPattern.MatchString(names) && !maybePattern.MatchString(names) {
+			selection.Remove()
+		}
+	})
+}
+
+// mainContent encontra o elemento com mais texto corrido (e menos links) e junta a ele os
+// irmãos que também parecem fazer parte do artigo
+func mainContent(doc *goquery.Document) []*html.Node {
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+
+	addScore := func(node *html.Node, score float64) {
+		if node == nil || node.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(node)
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+
+	doc.Find("p, pre, td, blockquote, div").Each(func(i int, selection *goquery.Selection) {
+		// Divs só contam como parágrafo quando não têm blocos dentro
+		if goquery.NodeName(selection) == "div" && selection.Find("p, div, ul, ol, table, pre, blockquote, h1, h2, h3, h4, h5, h6").Length() > 0 {
+			return
+		}
+		text := cleanText(selection.Text())
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + float64(min(length/100, 3))
+		parent := selection.Nodes[0].Parent
+		addScore(parent, score)
+		if parent != nil {
+			addScore(parent.Parent, score/2)
+		}
+	})
+
+	var top *html.Node
+	var topScore float64
+	for _, node := range candidates {
+		score := scores[node] * (1 - linkDensity(goquery.NewDocumentFromNode(node).Selection))
+		scores[node] = score
+		if top == nil || score > topScore {
+			top, topScore = node, score
+		}
+	}
+	if top == nil {
+		return doc.Find("body").Nodes
+	}
+
+	// Irmãos com pontuação próxima ou parágrafos longos com poucos links também entram
+	threshold := max(10, topScore*0.2)
+	var content []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		if sibling == top {
+			content = append(content, sibling)
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			content = append(content, sibling)
+			continue
+		}
+		if sibling.Data == "p" {
+			selection := goquery.NewDocumentFromNode(sibling).Selection
+			text := cleanText(selection.Text())
+			density := linkDensity(selection)
+			length := utf8.RuneCountInString(text)
+			if (length > 80 && density < 0.25) || (length > 0 && density == 0 && strings.Contains(text, ". ")) {
+				content = append(content, sibling)
+			}
+		}
+	}
+	return content
+}
+
+// initialScore dá um ponto de partida conforme a tag e as classes do elemento
+func initialScore(node *html.Node) float64 {
+	var score float64
+	switch node.Data {
+	case "article":
+		score = 10
+	case "div", "main", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	return score + classWeight(node)
+}
+
+func classWeight(node *html.Node) float64 {
+	var weight float64
+	for _, attr := range node.Attr {
+		if attr.Key != "class" && attr.Key != "id" {
+			continue
+		}
+		if negativePattern.MatchString(attr.Val) {
+			weight -= 25
+		}
+		if positivePattern.MatchString(attr.Val) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity é a fração do texto do elemento que está dentro de links
+func linkDensity(selection *goquery.Selection) float64 {
+	length := utf8.RuneCountInString(cleanText(selection.Text()))
+	if length == 0 {
+		return 0
+	}
+	linkLength := 0
+	selection.Find("a").Each(func(i int, link *goquery.Selection) {
+		linkLength += utf8.RuneCountInString(cleanText(link.Text()))
+	})
+	return float64(linkLength) / float64(length)
+}
+
+// Text converte o HTML do conteúdo em texto, com os blocos separados por linha em branco
+func Text(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return textutil.StripHTML(content)
+	}
+
+	var blocks []string
+	doc.Find("p, h2, h3, h4, li, blockquote, pre").Each(func(i int, block *goquery.Selection) {
+		// Blocos dentro de outro bloco já entram no texto do bloco de fora
+		if block.ParentsFiltered("p, li, blockquote, pre").Length() > 0 {
+			return
+		}
+		if text := cleanText(block.Text()); text != "" {
+			blocks = append(blocks, text)
+		}
+	})
+	if len(blocks) == 0 {
+		return textutil.StripHTML(content)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,174 @@
+package readability
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseFixture lê uma página de testdata como se tivesse sido baixada de pageURL
+func parseFixture(t *testing.T, name, pageURL string) Article {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(doc, base, "")
+}
+
+func TestParseMainContent(t *testing.T) {
+	article := parseFixture(t, "artigo.html", "https://revista.example/saude/olho-seco")
+
+	for _, want := range []string{"ar frio e seco do inverno", "procure um especialista"} {
+		if !strings.Contains(article.Content, want) {
+			t.Errorf("conteúdo sem %q:\n%s", want, article.Content)
+		}
+	}
+	for _, unwanted := range []string{"Mais lidas", "Dez mitos", "Ótima matéria", "umidificador, realmente", "direitos reservados", "Contato"} {
+		if strings.Contains(article.Content, unwanted) {
+			t.Errorf("conteúdo com %q, que está fora do artigo:\n%s", unwanted, article.Content)
+		}
+	}
+}
+
+func TestParseKeepsStructure(t *testing.T) {
+	article := parseFixture(t, "artigo.html", "https://revista.example/saude/olho-seco")
+
+	for _, want := range []string{
+		"<h2>Cuidados no dia a dia</h2>",
+		"<ul>\n<li>Faça pausas a cada vinte minutos diante das telas.</li>",
+		"<li>Evite direcionar o ar quente do carro para o rosto.</li>\n</ul>",
+		`<a href="https://revista.example/saude/colirios">colírios lubrificantes</a>`,
+	} {
+		if !strings.Contains(article.Content, want) {
+			t.Errorf("conteúdo sem %q:\n%s", want, article.Content)
+		}
+	}
+	// O h1 é o título e não se repete no corpo
+	if strings.Contains(article.Content, "<h2>Olho seco no inverno") {
+		t.Errorf("título repetido no corpo:\n%s", article.Content)
+	}
+	if !strings.HasPrefix(article.Text, "O ar frio e seco") || !strings.Contains(article.Text, "\n\nCuidados no dia a dia\n\n") {
+		t.Errorf("Text = %q", article.Text)
+	}
+}
+
+func TestParseJSONLDMetadata(t *testing.T) {
+	article := parseFixture(t, "artigo.html", "https://revista.example/saude/olho-seco")
+
+	tests := []struct {
+		field, got, want string
+	}{
+		// O artigo está dentro de @graph, com @type em lista
+		{"Title", article.Title, "Olho seco no inverno: o que fazer"},
+		{"Author", article.Author, "Ana Souza, João Lima"},
+		{"Excerpt", article.Excerpt, "Como aliviar o olho seco nos meses frios."},
+		{"SiteName", article.SiteName, "Revista Visão"},
+		{"ImageURL", article.ImageURL, "https://revista.example/imagens/olho-seco.jpg"},
+		{"CanonicalURL", article.CanonicalURL, "https://revista.example/saude/olho-seco-inverno"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, esperado %q", tt.field, tt.got, tt.want)
+		}
+	}
+	want := time.Date(2024, 6, 21, 11, 30, 0, 0, time.UTC)
+	if !article.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %s, esperado %s", article.PublishedAt, want)
+	}
+}
+
+func TestParseOpenGraphFallback(t *testing.T) {
+	article := parseFixture(t, "opengraph.html", "https://blog.example/lentes-guia?utm_source=x")
+
+	tests := []struct {
+		field, got, want string
+	}{
+		{"Title", article.Title, "Lentes de contato: guia para iniciantes"},
+		// article:author com URL de perfil não serve como nome
+		{"Author", article.Author, "Carla Mendes"},
+		{"ImageURL", article.ImageURL, "https://cdn.example/lentes.jpg"},
+		{"CanonicalURL", article.CanonicalURL, "https://blog.example/lentes-guia"},
+		// Sem descrição, o resumo vem do texto
+		{"Excerpt", article.Excerpt, Excerpt(article.Text)},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, esperado %q", tt.field, tt.got, tt.want)
+		}
+	}
+	want := time.Date(2024, 3, 5, 14, 0, 0, 0, time.UTC)
+	if !article.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %s, esperado %s", article.PublishedAt, want)
+	}
+	if !strings.Contains(article.Content, "ceratite") {
+		t.Errorf("conteúdo sem o segundo parágrafo:\n%s", article.Content)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	brt := time.FixedZone("", -3*60*60)
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-06-21T08:30:00-03:00", time.Date(2024, 6, 21, 8, 30, 0, 0, brt), true},
+		{"2024-06-21T08:30:00.123Z", time.Date(2024, 6, 21, 8, 30, 0, 123000000, time.UTC), true},
+		{"2024-06-21T08:30:00-0300", time.Date(2024, 6, 21, 8, 30, 0, 0, brt), true},
+		{"2024-06-21T08:30:00", time.Date(2024, 6, 21, 8, 30, 0, 0, time.UTC), true},
+		{"2024-06-21T08:30-03:00", time.Date(2024, 6, 21, 8, 30, 0, 0, brt), true},
+		{"2024-06-21 08:30:00", time.Date(2024, 6, 21, 8, 30, 0, 0, time.UTC), true},
+		{" 2024-06-21 ", time.Date(2024, 6, 21, 0, 0, 0, 0, time.UTC), true},
+		{"21/06/2024", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDate(tt.value)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %s, %v, esperado %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	short := "Texto curto sobre visão."
+	if got := Excerpt("  Texto   curto\nsobre visão. "); got != short {
+		t.Errorf("Excerpt = %q, esperado %q", got, short)
+	}
+
+	// 250 letras acentuadas em palavras de 9 caracteres: o corte não pode partir uma letra
+	long := strings.TrimSpace(strings.Repeat("ação éüçã ", 25))
+	got := Excerpt(long)
+	if !utf8.ValidString(got) {
+		t.Fatalf("Excerpt gerou UTF-8 inválido: %q", got)
+	}
+	if !strings.HasSuffix(got, "éüçã...") {
+		t.Errorf("Excerpt = %q, esperado o corte no fim de uma palavra", got)
+	}
+	if n := utf8.RuneCountInString(strings.TrimSuffix(got, "...")); n > maxExcerptLength {
+		t.Errorf("Excerpt com %d caracteres, esperado no máximo %d", n, maxExcerptLength)
+	}
+
+	// Sem espaço na segunda metade, corta no limite de caracteres
+	word := strings.Repeat("ã", 300)
+	if got := Excerpt(word); got != strings.Repeat("ã", maxExcerptLength)+"..." {
+		t.Errorf("Excerpt de uma palavra longa = %q", got)
+	}
+}
@@ -0,0 +1,324 @@
+package readability
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockTags são os elementos tratados como blocos; o texto solto entre eles vira parágrafo
+var blockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"blockquote": true, "pre": true, "figure": true, "figcaption": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+	"header": true, "footer": true, "aside": true, "address": true, "details": true, "summary": true,
+	"hr": true,
+}
+
+// droppedTags são removidos com todo o conteúdo
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "object": true, "embed": true,
+	"svg": true, "canvas": true, "form": true, "button": true, "input": true, "select": true,
+	"textarea": true, "template": true, "img": true, "picture": true, "video": true, "audio": true,
+	"nav": true, "hr": true, "head": true, "title": true, "meta": true, "link": true,
+}
+
+// headingLevels mapeia os títulos da página para os níveis usados nos artigos do blog
+// (o h1 é o título do próprio artigo)
+var headingLevels = map[string]string{
+	"h1": "h2", "h2": "h2", "h3": "h3", "h4": "h4", "h5": "h4", "h6": "h4",
+}
+
+// Sanitize limpa um trecho HTML (como o conteúdo de um feed), mantendo só a estrutura
+// básica: parágrafos, títulos, listas, citações, código, ênfase e links
+func Sanitize(fragment string, base *url.URL) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return ""
+	}
+	return render(nodes, base)
+}
+
+// render gera o HTML limpo dos nós. Elementos desconhecidos são desembrulhados e o texto
+// solto entre blocos vira parágrafo.
+func render(nodes []*html.Node, base *url.URL) string {
+	r := &renderer{base: base}
+	for _, node := range nodes {
+		r.block(node)
+	}
+	r.flush()
+	return strings.TrimSpace(r.out.String())
+}
+
+type renderer struct {
+	base    *url.URL
+	out     strings.Builder
+	pending strings.Builder // conteúdo inline ainda sem parágrafo
+}
+
+// block trata um nó em contexto de bloco
+func (r *renderer) block(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		r.pending.WriteString(r.inlineText(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			r.block(child)
+		}
+		return
+	}
+
+	tag := node.Data
+	if droppedTags[tag] {
+		return
+	}
+	if !blockTags[tag] {
+		r.pending.WriteString(r.inline(node))
+		return
+	}
+
+	r.flush()
+	switch tag {
+	case "p", "dt", "dd", "figcaption", "summary", "address":
+		r.paragraph("p", r.children(node))
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.paragraph(headingLevels[tag], r.children(node))
+	case "ul", "ol":
+		r.list(node)
+	case "li":
+		// Item fora de lista
+		r.paragraph("p", r.children(node))
+	case "blockquote":
+		inner := &renderer{base: r.base}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			inner.block(child)
+		}
+		inner.flush()
+		if content := strings.TrimSpace(inner.out.String()); content != "" {
+			r.out.WriteString("<blockquote>\n" + content + "\n</blockquote>\n")
+		}
+	case "pre":
+		if text := strings.Trim(nodeText(node), "\n"); strings.TrimSpace(text) != "" {
+			r.out.WriteString("<pre><code>" + html.EscapeString(text) + "</code></pre>\n")
+		}
+	default:
+		// Contêineres (div, section, células de tabela...) são desembrulhados
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			r.block(child)
+		}
+	}
+	r.flush()
+}
+
+// list gera uma lista com os itens li; listas formadas só por links (menus, "leia
+// também") são descartadas
+func (r *renderer) list(node *html.Node) {
+	var items []string
+	linkOnly := true
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+		item := strings.TrimSpace(r.children(child))
+		if item == "" {
+			continue
+		}
+		if !isLinkOnly(child) {
+			linkOnly = false
+		}
+		items = append(items, "<li>"+item+"</li>")
+	}
+	if len(items) == 0 || (linkOnly && len(items) > 1) {
+		return
+	}
+	r.out.WriteString("<" + node.Data + ">\n" + strings.Join(items, "\n") + "\n</" + node.Data + ">\n")
+}
+
+// paragraph grava um bloco com o conteúdo inline, se houver texto
+func (r *renderer) paragraph(tag, content string) {
+	content = strings.TrimSpace(content)
+	content = strings.TrimSuffix(strings.TrimPrefix(content, "<br>"), "<br>")
+	if strings.TrimSpace(html.UnescapeString(stripTags(content))) == "" {
+		return
+	}
+	r.out.WriteString("<" + tag + ">" + strings.TrimSpace(content) + "</" + tag + ">\n")
+}
+
+// flush transforma o conteúdo inline acumulado em parágrafo
+func (r *renderer) flush() {
+	content := r.pending.String()
+	r.pending.Reset()
+	r.paragraph("p", content)
+}
+
+// children gera o conteúdo inline dos filhos; blocos internos viram texto separado por espaço
+func (r *renderer) children(node *html.Node) string {
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(r.inline(child))
+	}
+	return b.String()
+}
+
+// inline trata um nó em contexto inline
+func (r *renderer) inline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return r.inlineText(node.Data)
+	case html.ElementNode:
+	default:
+		return r.children(node)
+	}
+
+	tag := node.Data
+	if droppedTags[tag] {
+		return ""
+	}
+	content := r.children(node)
+	switch tag {
+	case "br":
+		return "<br>"
+	case "strong", "b":
+		return wrap("strong", content)
+	case "em", "i":
+		return wrap("em", content)
+	case "code":
+		return wrap("code", content)
+	case "a":
+		href := r.link(attr(node, "href"))
+		if href == "" || strings.TrimSpace(content) == "" {
+			return content
+		}
+		return `<a href="` + html.EscapeString(href) + `">` + content + "</a>"
+	}
+	if blockTags[tag] {
+		return " " + content + " "
+	}
+	return content
+}
+
+// inlineText escapa o texto e junta os espaços em branco
+func (r *renderer) inlineText(text string) string {
+	if text == "" {
+		return ""
+	}
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return " "
+	}
+	escaped := html.EscapeString(strings.Join(fields, " "))
+	if startsWithSpace(text) {
+		escaped = " " + escaped
+	}
+	if endsWithSpace(text) {
+		escaped += " "
+	}
+	return escaped
+}
+
+// link resolve o endereço e aceita apenas http, https e mailto
+func (r *renderer) link(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if r.base != nil {
+		ref = r.base.ResolveReference(ref)
+	}
+	switch ref.Scheme {
+	case "http", "https", "mailto":
+		return ref.String()
+	}
+	return ""
+}
+
+func wrap(tag, content string) string {
+	if strings.TrimSpace(content) == "" {
+		return content
+	}
+	// Mantém os espaços fora da tag: "<em> texto </em>" vira " <em>texto</em> "
+	trimmed := strings.TrimSpace(content)
+	prefix := content[:strings.Index(content, trimmed)]
+	suffix := content[len(prefix)+len(trimmed):]
+	return prefix + "<" + tag + ">" + trimmed + "</" + tag + ">" + suffix
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// isLinkOnly verifica se todo o texto do item está dentro de links
+func isLinkOnly(node *html.Node) bool {
+	total := len(strings.TrimSpace(nodeText(node)))
+	if total == 0 {
+		return false
+	}
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linked += len(strings.TrimSpace(nodeText(n)))
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return linked >= total
+}
+
+func nodeText(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(nodeText(child))
+	}
+	return b.String()
+}
+
+// stripTags remove as tags do HTML gerado pelo renderer (que não tem atributos com ">")
+func stripTags(content string) string {
+	var b strings.Builder
+	inTag := false
+	for _, c := range content {
+		switch {
+		case c == '<':
+			inTag = true
+		case c == '>':
+			inTag = false
+		case !inTag:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func startsWithSpace(text string) bool {
+	return strings.TrimLeft(text, " \t\n\r\f") != text
+}
+
+func endsWithSpace(text string) bool {
+	return strings.TrimRight(text, " \t\n\r\f") != text
+}
@@ -0,0 +1,56 @@
+package readability
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	base, _ := url.Parse("https://blog.example/artigos/lentes")
+	tests := []struct {
+		name     string
+		fragment string
+		want     string
+	}{
+		{
+			name:     "link relativo vira absoluto",
+			fragment: `<p>Veja o <a href="../guia?x=1">guia</a>.</p>`,
+			want:     `<p>Veja o <a href="https://blog.example/guia?x=1">guia</a>.</p>`,
+		},
+		{
+			name:     "javascript e âncoras perdem o link",
+			fragment: `<p><a href="javascript:alert(1)">clique</a> e <a href="#topo">topo</a></p>`,
+			want:     `<p>clique e topo</p>`,
+		},
+		{
+			name:     "títulos ajustados ao nível do blog",
+			fragment: `<h1>Um</h1><h5>Cinco</h5>`,
+			want:     "<h2>Um</h2>\n<h4>Cinco</h4>",
+		},
+		{
+			name:     "texto solto vira parágrafo e atributos somem",
+			fragment: `Texto <b class="x">forte</b><div style="color:red"><i>ênfase</i></div>`,
+			want:     "<p>Texto <strong>forte</strong></p>\n<p><em>ênfase</em></p>",
+		},
+		{
+			name:     "lista só de links é descartada",
+			fragment: `<ul><li><a href="/a">A</a></li><li><a href="/b">B</a></li></ul><p>fim</p>`,
+			want:     "<p>fim</p>",
+		},
+		{
+			name:     "scripts e imagens removidos",
+			fragment: `<p>antes<script>alert(1)</script><img src="x.jpg"> depois</p>`,
+			want:     "<p>antes depois</p>",
+		},
+		{
+			name:     "pre mantém o texto escapado",
+			fragment: "<pre>a &lt; b\n  c</pre>",
+			want:     "<pre><code>a &lt; b\n  c</code></pre>",
+		},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.fragment, base); got != tt.want {
+			t.Errorf("%s: Sanitize = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package related
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ryv-api/locale"
+	"ryv-api/models"
+	"ryv-api/textutil"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxRelatedPerArticle é quantos artigos relacionados são pré-calculados para cada artigo
+	maxRelatedPerArticle = 20
+	// minSimilarity descarta artigos que compartilham apenas termos muito genéricos
+	minSimilarity = 0.05
+	// refreshDelay agrupa várias alterações seguidas em uma única reconstrução
+	refreshDelay = 2 * time.Second
+)
+
+// Peso de cada campo na representação do artigo
+const (
+	titleWeight   = 3
+	tagsWeight    = 3
+	excerptWeight = 2
+	contentWeight = 1
+)
+
+// Match representa um artigo relacionado e sua similaridade (0 a 1)
+type Match struct {
+	ArticleID uint
+	Score     float64
+}
+
+// Index mantém, para cada artigo publicado, a lista pré-calculada de artigos
+// mais parecidos por similaridade de cosseno entre vetores TF-IDF
+type Index struct {
+	db *gorm.DB
+
+	mu      sync.RWMutex
+	related map[uint][]Match
+	built   bool
+
+	// rebuildMu serializa as reconstruções, para que uma leitura mais antiga dos artigos
+	// nunca substitua uma mais recente
+	rebuildMu sync.Mutex
+
+	refreshMu sync.Mutex
+	timer     *time.Timer
+}
+
+func NewIndex(db *gorm.DB) *Index {
+	return &Index{
+		db:      db,
+		related: make(map[uint][]Match),
+	}
+}
+
+// Related retorna os artigos mais parecidos com o artigo informado.
+// O índice é construído na primeira consulta, caso ainda não exista.
+func (i *Index) Related(articleID uint, limit int) ([]Match, error) {
+	i.mu.RLock()
+	built := i.built
+	i.mu.RUnlock()
+
+	if !built {
+		if err := i.buildOnce(); err != nil {
+			return nil, err
+		}
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	matches := i.related[articleID]
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return append([]Match(nil), matches...), nil
+}
+
+// Refresh agenda a reconstrução do índice em segundo plano. Chamadas próximas
+// (ex.: várias edições seguidas) resultam em uma única reconstrução.
+func (i *Index) Refresh() {
+	i.refreshMu.Lock()
+	defer i.refreshMu.Unlock()
+
+	if i.timer != nil {
+		i.timer.Stop()
+	}
+	i.timer = time.AfterFunc(refreshDelay, func() {
+		if err := i.Rebuild(); err != nil {
+			log.Printf("Erro ao reconstruir índice de artigos relacionados: %v", err)
+		}
+	})
+}
+
+// buildOnce constrói o índice se nenhuma reconstrução terminou enquanto esperava a vez
+func (i *Index) buildOnce() error {
+	i.rebuildMu.Lock()
+	defer i.rebuildMu.Unlock()
+
+	i.mu.RLock()
+	built := i.built
+	i.mu.RUnlock()
+	if built {
+		return nil
+	}
+	return i.rebuild()
+}
+
+// Rebuild recalcula a similaridade entre todos os artigos publicados em pt-BR (os termos
+// são normalizados para o português; traduções usam os relacionados do artigo canônico)
+func (i *Index) Rebuild() error {
+	i.rebuildMu.Lock()
+	defer i.rebuildMu.Unlock()
+
+	return i.rebuild()
+}
+
+func (i *Index) rebuild() error {
+	var articles []models.Article
+	if err := i.db.Select("id, title, excerpt, content, tags").
+		Where("is_published = ? AND locale = ?", true, locale.Default).
+		Find(&articles).Error; err != nil {
+		return err
+	}
+
+	related := Compute(articles, maxRelatedPerArticle)
+
+	i.mu.Lock()
+	i.related = related
+	i.built = true
+	i.mu.Unlock()
+
+	return nil
+}
+
+// Compute calcula, para cada artigo, os artigos mais parecidos (no máximo limit)
+func Compute(articles []models.Article, limit int) map[uint][]Match {
+	termFrequencies := make([]map[string]float64, len(articles))
+	documentFrequency := make(map[string]int)
+
+	for idx, article := range articles {
+		tf := make(map[string]float64)
+		addTerms(tf, article.Title, titleWeight)
+		addTerms(tf, strings.ReplaceAll(article.Tags, ",", " "), tagsWeight)
+		addTerms(tf, article.Excerpt, excerptWeight)
+		addTerms(tf, textutil.StripHTML(article.Content), contentWeight)
+		termFrequencies[idx] = tf
+		for term := range tf {
+			documentFrequency[term]++
+		}
+	}
+
+	// Vetores TF-IDF normalizados (norma 1), com TF sublinear
+	total := float64(len(articles))
+	vectors := make([]map[string]float64, len(articles))
+	for idx, tf := range termFrequencies {
+		vector := make(map[string]float64, len(tf))
+		norm := 0.0
+		for term, freq := range tf {
+			idf := math.Log((1+total)/(1+float64(documentFrequency[term]))) + 1
+			weight := (1 + math.Log(freq)) * idf
+			vector[term] = weight
+			norm += weight * weight
+		}
+		norm = math.Sqrt(norm)
+		if norm > 0 {
+			for term := range vector {
+				vector[term] /= norm
+			}
+		}
+		vectors[idx] = vector
+	}
+
+	related := make(map[uint][]Match, len(articles))
+	for a := range articles {
+		var matches []Match
+		for b := range articles {
+			if a == b {
+				continue
+			}
+			score := cosine(vectors[a], vectors[b])
+			if score < minSimilarity {
+				continue
+			}
+			matches = append(matches, Match{ArticleID: articles[b].ID, Score: score})
+		}
+		sort.Slice(matches, func(x, y int) bool {
+			if matches[x].Score == matches[y].Score {
+				return matches[x].ArticleID > matches[y].ArticleID
+			}
+			return matches[x].Score > matches[y].Score
+		})
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+		related[articles[a].ID] = matches
+	}
+
+	return related
+}
+
+func addTerms(tf map[string]float64, text string, weight float64) {
+	for _, token := range textutil.Tokenize(text) {
+		tf[token] += weight
+	}
+}
+
+// cosine calcula o produto escalar de dois vetores já normalizados
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	dot := 0.0
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	return dot
+}
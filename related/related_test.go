@@ -0,0 +1,57 @@
+package related
+
+import (
+	"testing"
+
+	"ryv-api/models"
+)
+
+func TestCompute(t *testing.T) {
+	articles := []models.Article{
+		{ID: 1, Title: "Cuidados com os óculos de grau", Tags: "oculos,lentes", Content: "<p>Limpe as lentes dos óculos com flanela.</p>"},
+		{ID: 2, Title: "Como limpar lentes de óculos", Tags: "oculos,limpeza", Content: "<p>A flanela certa evita riscos nas lentes.</p>"},
+		{ID: 3, Title: "Ansiedade e sono", Tags: "saude mental", Content: "<p>Dormir bem reduz a ansiedade.</p>"},
+		{ID: 4, Title: "Sono e ansiedade no trabalho", Tags: "saude mental,sono", Content: "<p>Rotina de sono para quem sofre com ansiedade.</p>"},
+	}
+
+	related := Compute(articles, 10)
+
+	for _, tt := range []struct {
+		article, want uint
+	}{{1, 2}, {2, 1}, {3, 4}, {4, 3}} {
+		matches := related[tt.article]
+		if len(matches) == 0 || matches[0].ArticleID != tt.want {
+			t.Errorf("artigo %d: relacionados = %v, esperado %d primeiro", tt.article, matches, tt.want)
+			continue
+		}
+		if score := matches[0].Score; score <= 0 || score > 1.0000001 {
+			t.Errorf("artigo %d: similaridade %f fora de (0, 1]", tt.article, score)
+		}
+		for _, match := range matches {
+			if match.ArticleID == tt.article {
+				t.Errorf("artigo %d aparece entre os próprios relacionados", tt.article)
+			}
+		}
+	}
+
+	// Artigos sem termos em comum ficam abaixo da similaridade mínima
+	for _, match := range related[1] {
+		if match.ArticleID == 3 || match.ArticleID == 4 {
+			t.Errorf("artigo 1 relacionado ao artigo %d (similaridade %f)", match.ArticleID, match.Score)
+		}
+	}
+}
+
+func TestComputeLimit(t *testing.T) {
+	articles := []models.Article{
+		{ID: 1, Title: "Óculos de sol"},
+		{ID: 2, Title: "Óculos de grau"},
+		{ID: 3, Title: "Óculos infantis"},
+		{ID: 4, Title: "Óculos esportivos"},
+	}
+	for id, matches := range Compute(articles, 2) {
+		if len(matches) > 2 {
+			t.Errorf("artigo %d: %d relacionados, limite 2", id, len(matches))
+		}
+	}
+}
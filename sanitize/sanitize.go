@@ -0,0 +1,325 @@
+package sanitize
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultEmbedHosts são os sites cujos players podem ser incorporados (iframe) nos artigos
+var DefaultEmbedHosts = []string{
+	"www.youtube.com", "youtube.com", "www.youtube-nocookie.com",
+	"player.vimeo.com", "open.spotify.com",
+}
+
+// allowedAttributes são os elementos permitidos e os atributos aceitos em cada um.
+// Elementos fora da lista são desembrulhados (o conteúdo fica) e os de droppedElements são
+// removidos com o conteúdo.
+var allowedAttributes = map[string][]string{
+	"p": nil, "br": nil, "hr": nil, "div": nil, "span": nil,
+	"h1": {"id"}, "h2": {"id"}, "h3": {"id"}, "h4": {"id"}, "h5": {"id"}, "h6": {"id"},
+	"ul": nil, "ol": {"start", "type"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+	"blockquote": {"cite"}, "pre": {"class"}, "code": {"class"},
+	"strong": nil, "b": nil, "em": nil, "i": nil, "u": nil, "s": nil, "mark": nil,
+	"small": nil, "sub": nil, "sup": nil, "abbr": {"title"}, "cite": nil, "q": nil,
+	"a":      {"href", "title", "target", "rel"},
+	"img":    {"src", "alt", "title", "width", "height", "loading"},
+	"figure": nil, "figcaption": nil,
+	"table": nil, "caption": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+	"th": {"colspan", "rowspan", "scope"}, "td": {"colspan", "rowspan"},
+	"iframe": {"src", "width", "height", "title", "allow", "allowfullscreen", "frameborder"},
+}
+
+// droppedElements são removidos com todo o conteúdo
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "object": true,
+	"embed": true, "applet": true, "frame": true, "frameset": true, "svg": true, "math": true,
+	"form": true, "input": true, "button": true, "select": true, "textarea": true,
+	"head": true, "title": true, "meta": true, "link": true, "base": true,
+}
+
+var (
+	// idPattern aceita os ids gerados para os títulos (âncoras do sumário)
+	idPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,127}$`)
+	// languageClassPattern aceita só a classe de linguagem dos blocos de código
+	languageClassPattern = regexp.MustCompile(`^language-[A-Za-z0-9_+-]{1,32}$`)
+	// sizePattern aceita larguras e alturas numéricas ou em porcentagem
+	sizePattern = regexp.MustCompile(`^[0-9]{1,4}%?$`)
+)
+
+// Report resume o que foi retirado do HTML
+type Report struct {
+	Elements   map[string]int `json:"elements,omitempty"`   // removidos com o conteúdo
+	Unwrapped  map[string]int `json:"unwrapped,omitempty"`  // tags removidas, com o conteúdo mantido
+	Attributes map[string]int `json:"attributes,omitempty"` // atributos removidos, por nome
+	Added      map[string]int `json:"added,omitempty"`      // atributos acrescentados ou trocados pela política (rel)
+	URLs       []string       `json:"urls,omitempty"`       // endereços bloqueados (esquema ou site não permitido)
+}
+
+// Empty indica que nada foi retirado nem acrescentado
+func (r Report) Empty() bool {
+	return len(r.Elements) == 0 && len(r.Unwrapped) == 0 && len(r.Attributes) == 0 && len(r.Added) == 0 && len(r.URLs) == 0
+}
+
+// Summary descreve o relatório em uma linha, para logs
+func (r Report) Summary() string {
+	var parts []string
+	for _, group := range []struct {
+		label  string
+		counts map[string]int
+	}{{"elementos", r.Elements}, {"tags", r.Unwrapped}, {"atributos", r.Attributes}, {"acrescentados", r.Added}} {
+		if len(group.counts) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(group.counts))
+		for name := range group.counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts = append(parts, group.label+": "+strings.Join(names, ", "))
+	}
+	if len(r.URLs) > 0 {
+		parts = append(parts, "endereços: "+strings.Join(r.URLs, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Merge soma ao relatório o que foi retirado em outro
+func (r *Report) Merge(other Report) {
+	for _, group := range []struct {
+		counts *map[string]int
+		other  map[string]int
+	}{{&r.Elements, other.Elements}, {&r.Unwrapped, other.Unwrapped}, {&r.Attributes, other.Attributes}, {&r.Added, other.Added}} {
+		for name, n := range group.other {
+			r.count(group.counts, name)
+			(*group.counts)[name] += n - 1
+		}
+	}
+	r.URLs = append(r.URLs, other.URLs...)
+}
+
+func (r *Report) count(counts *map[string]int, name string) {
+	if *counts == nil {
+		*counts = make(map[string]int)
+	}
+	(*counts)[name]++
+}
+
+// Policy é a política de sanitização do conteúdo dos artigos: uma lista de elementos e
+// atributos permitidos, links só com esquemas seguros e iframes só dos sites aprovados
+type Policy struct {
+	embedHosts map[string]bool
+}
+
+// NewPolicy cria a política com os sites aprovados para iframes (DefaultEmbedHosts se vazio)
+func NewPolicy(embedHosts []string) *Policy {
+	if len(embedHosts) == 0 {
+		embedHosts = DefaultEmbedHosts
+	}
+	hosts := make(map[string]bool, len(embedHosts))
+	for _, host := range embedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts[host] = true
+		}
+	}
+	return &Policy{embedHosts: hosts}
+}
+
+// ParseHosts converte uma lista de sites separada por vírgulas
+func ParseHosts(list string) []string {
+	var hosts []string
+	for _, host := range strings.Split(list, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// Sanitize aplica a política ao HTML. O resultado é sempre gerado a partir da árvore já
+// limpa, mesmo quando nada foi retirado, para que seja exatamente o HTML verificado e não
+// uma marcação que o navegador possa interpretar de outro jeito.
+func (p *Policy) Sanitize(content string) (string, Report) {
+	var report Report
+	if strings.TrimSpace(content) == "" {
+		return content, report
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), root)
+	if err != nil {
+		// Conteúdo que nem o parser aceita vira texto
+		report.count(&report.Elements, "html")
+		return html.EscapeString(content), report
+	}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+
+	p.clean(root, &report)
+
+	var b strings.Builder
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&b, child); err != nil {
+			return "", report
+		}
+	}
+	return b.String(), report
+}
+
+// clean aplica a política aos filhos do nó
+func (p *Policy) clean(parent *html.Node, report *Report) {
+	for child := parent.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		switch child.Type {
+		case html.CommentNode, html.DoctypeNode:
+			parent.RemoveChild(child)
+			report.count(&report.Elements, "comment")
+		case html.ElementNode:
+			tag := child.Data
+			_, allowed := allowedAttributes[tag]
+			switch {
+			case child.Namespace != "" || droppedElements[tag]:
+				parent.RemoveChild(child)
+				report.count(&report.Elements, tag)
+			case tag == "iframe" && !p.allowedEmbed(child, report):
+				parent.RemoveChild(child)
+				report.count(&report.Elements, tag)
+			case !allowed:
+				// Desembrulha: os filhos tomam o lugar do elemento e também são verificados
+				first := child.FirstChild
+				for grandchild := child.FirstChild; grandchild != nil; grandchild = child.FirstChild {
+					child.RemoveChild(grandchild)
+					parent.InsertBefore(grandchild, child)
+				}
+				parent.RemoveChild(child)
+				report.count(&report.Unwrapped, tag)
+				if first != nil {
+					next = first
+				}
+			default:
+				p.cleanAttributes(child, report)
+				p.clean(child, report)
+			}
+		}
+
+		child = next
+	}
+}
+
+// cleanAttributes mantém só os atributos permitidos no elemento, com valores seguros
+func (p *Policy) cleanAttributes(node *html.Node, report *Report) {
+	allowed := allowedAttributes[node.Data]
+	attrs := node.Attr[:0]
+	blank := false
+	rel, hasRel := "", false
+	for _, attr := range node.Attr {
+		key := strings.ToLower(attr.Key)
+		if attr.Namespace != "" || !contains(allowed, key) {
+			report.count(&report.Attributes, key)
+			continue
+		}
+
+		value := strings.TrimSpace(attr.Val)
+		ok := true
+		switch key {
+		case "href", "cite":
+			ok = safeURL(value, true)
+		case "src":
+			ok = node.Data == "iframe" || safeURL(value, false)
+		case "id":
+			ok = idPattern.MatchString(value)
+		case "class":
+			ok = languageClassPattern.MatchString(value)
+		case "width", "height", "colspan", "rowspan", "start", "frameborder":
+			ok = sizePattern.MatchString(value)
+		case "target":
+			ok = value == "_blank"
+			blank = ok
+		case "rel":
+			// Recalculado abaixo para links que abrem em outra aba
+			rel, hasRel = value, true
+			ok = false
+		}
+		if !ok {
+			if key == "href" || key == "src" || key == "cite" {
+				report.URLs = append(report.URLs, value)
+			} else if key != "rel" {
+				report.count(&report.Attributes, key)
+			}
+			continue
+		}
+		attr.Key, attr.Val = key, value
+		attrs = append(attrs, attr)
+	}
+	if node.Data == "a" {
+		switch {
+		case blank:
+			attrs = append(attrs, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+			if rel != "noopener noreferrer" {
+				report.count(&report.Added, "rel")
+			}
+		case hasRel:
+			report.count(&report.Attributes, "rel")
+		}
+	}
+	node.Attr = attrs
+}
+
+// allowedEmbed verifica se o iframe aponta, por https e sem usuário, para um site aprovado
+func (p *Policy) allowedEmbed(node *html.Node, report *Report) bool {
+	var src string
+	for _, attr := range node.Attr {
+		if strings.ToLower(attr.Key) == "src" {
+			src = strings.TrimSpace(attr.Val)
+		}
+	}
+	embed, err := url.Parse(src)
+	if err == nil && embed.Scheme == "" && strings.HasPrefix(src, "//") {
+		embed.Scheme = "https"
+	}
+	// Usuário e senha no endereço só servem para disfarçar o site de destino
+	if err != nil || embed.Scheme != "https" || embed.User != nil || !p.embedHosts[strings.ToLower(embed.Hostname())] {
+		report.URLs = append(report.URLs, src)
+		return false
+	}
+	return true
+}
+
+// safeURL aceita endereços relativos e os esquemas http e https (e mailto e tel em links)
+func safeURL(value string, link bool) bool {
+	if value == "" {
+		return false
+	}
+	// Caracteres de controle e espaços no esquema são usados para disfarçar "javascript:"
+	if strings.IndexFunc(value, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return false
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "":
+		return true
+	case "http", "https":
+		return true
+	case "mailto", "tel":
+		return link
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
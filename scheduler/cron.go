@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule calcula o próximo horário de execução de uma tarefa
+type Schedule interface {
+	// Next retorna o primeiro horário de execução depois de t
+	Next(t time.Time) time.Time
+}
+
+// macros são os atalhos aceitos no lugar das cinco posições da expressão
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronField descreve uma das cinco posições da expressão
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField  = cronField{name: "minuto", min: 0, max: 59}
+	hourField    = cronField{name: "hora", min: 0, max: 23}
+	dayField     = cronField{name: "dia do mês", min: 1, max: 31}
+	monthField   = cronField{name: "mês", min: 1, max: 12, names: monthNames}
+	weekdayField = cronField{name: "dia da semana", min: 0, max: 7, names: weekdayNames}
+)
+
+// ParseSchedule interpreta uma expressão cron de cinco posições (minuto, hora, dia do mês,
+// mês e dia da semana), com listas, intervalos, passos e nomes em inglês (jan, mon...),
+// os atalhos @hourly, @daily, @weekly, @monthly e @yearly, ou "@every <duração>".
+// Os horários seguem o fuso do servidor.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("intervalo inválido em %q (mínimo 1m)", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if expanded, ok := macros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expressão cron %q deve ter 5 posições (minuto hora dia mês dia-da-semana)", spec)
+	}
+
+	var schedule cronSchedule
+	var err error
+	if schedule.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if schedule.day, err = dayField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if schedule.weekday, err = weekdayField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// 7 também é domingo
+	if schedule.weekday&(1<<7) != 0 {
+		schedule.weekday |= 1
+	}
+	schedule.anyDay = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	schedule.anyWeekday = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("expressão cron %q nunca coincide com uma data", spec)
+	}
+	return schedule, nil
+}
+
+// parse converte a posição em um conjunto de bits com os valores aceitos
+func (f cronField) parse(value string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("passo inválido %q no %s", part, f.name)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			low, high, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = f.value(low); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(high); err != nil {
+				return 0, err
+			}
+		default:
+			n, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			start, end = n, n
+			// "5/15" vai do 5 até o fim
+			if hasStep {
+				end = f.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("intervalo invertido %q no %s", part, f.name)
+		}
+		for n := start; n <= end; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+func (f cronField) value(text string) (int, error) {
+	if n, ok := f.names[strings.ToLower(text)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("valor inválido %q no %s (%d a %d)", text, f.name, f.min, f.max)
+	}
+	return n, nil
+}
+
+// cronSchedule guarda, para cada posição, os valores aceitos como bits
+type cronSchedule struct {
+	minute, hour, day, month, weekday uint64
+	// Como no cron tradicional, quando dia do mês e dia da semana são restritos, basta
+	// um dos dois coincidir
+	anyDay, anyWeekday bool
+}
+
+// maxSearchYears limita a busca por expressões que nunca coincidem (ex.: 30 de fevereiro)
+const maxSearchYears = 5
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<t.Hour()) == 0 {
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+			continue
+		}
+		if s.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	dayOK := s.day&(1<<t.Day()) != 0
+	weekdayOK := s.weekday&(1<<int(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return dayOK && weekdayOK
+	}
+	return dayOK || weekdayOK
+}
+
+// everySchedule executa a tarefa a cada intervalo fixo
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval).Truncate(time.Second)
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// date monta um horário em UTC; 1º de janeiro de 2025 é uma quarta-feira
+func date(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestScheduleNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		// Passos
+		{"*/15 * * * *", date(1, 1, 10, 14).Add(30 * time.Second), date(1, 1, 10, 15)},
+		{"*/15 * * * *", date(1, 1, 10, 15), date(1, 1, 10, 30)},
+		// "5/15" começa no 5 e vai até o fim: 5, 20, 35, 50
+		{"5/15 * * * *", date(1, 1, 10, 0), date(1, 1, 10, 5)},
+		{"5/15 * * * *", date(1, 1, 10, 35), date(1, 1, 10, 50)},
+		{"5/15 * * * *", date(1, 1, 10, 50), date(1, 1, 11, 5)},
+		// Listas e intervalos
+		{"0 8,18 * * *", date(1, 1, 9, 0), date(1, 1, 18, 0)},
+		{"30 9-11 * * *", date(1, 1, 11, 30), date(1, 2, 9, 30)},
+		// Só o dia do mês ou só o dia da semana
+		{"0 9 1 * *", date(1, 1, 10, 0), date(2, 1, 9, 0)},
+		{"0 9 * * mon", date(1, 1, 10, 0), date(1, 6, 9, 0)},
+		// Dia do mês e dia da semana restritos: basta um dos dois
+		{"0 9 1 * mon", date(1, 1, 10, 0), date(1, 6, 9, 0)},
+		{"0 9 1 * mon", date(1, 31, 10, 0), date(2, 1, 9, 0)},
+		{"0 9 15 * fri", date(1, 11, 0, 0), date(1, 15, 9, 0)},
+		// Com * ou passo em uma das posições, as duas precisam coincidir
+		{"0 9 */2 * mon", date(1, 1, 10, 0), date(1, 13, 9, 0)},
+		// 0 e 7 são domingo
+		{"0 9 * * 0", date(1, 1, 10, 0), date(1, 5, 9, 0)},
+		{"0 9 * * 7", date(1, 1, 10, 0), date(1, 5, 9, 0)},
+		{"0 9 * * 5-7", date(1, 4, 10, 0), date(1, 5, 9, 0)},
+		// Nomes de meses e dias
+		{"0 12 * MAR-apr mon-fri", date(1, 1, 0, 0), date(3, 3, 12, 0)},
+		// Dia que não existe em todos os meses
+		{"0 0 31 * *", date(2, 1, 0, 0), date(3, 31, 0, 0)},
+		{"0 0 29 2 *", date(1, 1, 0, 0), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Atalhos
+		{"@hourly", date(1, 1, 10, 30), date(1, 1, 11, 0)},
+		{"@daily", date(1, 1, 10, 0), date(1, 2, 0, 0)},
+		{"@weekly", date(1, 1, 10, 0), date(1, 5, 0, 0)},
+		{"@monthly", date(1, 1, 10, 0), date(2, 1, 0, 0)},
+		{"@yearly", date(1, 1, 10, 0), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Intervalo fixo
+		{"@every 90m", date(1, 1, 10, 0).Add(1500 * time.Millisecond), date(1, 1, 11, 30).Add(time.Second)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, esperado %s", tt.spec, tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * sunday",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"@every 30s",
+		"@every amanhã",
+		"@sometimes",
+		// Nunca coincide
+		"0 0 30 2 *",
+	}
+	for _, spec := range specs {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) sem erro, esperado erro", spec)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ryv-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Situações de uma execução
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// Origens de uma execução
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+const (
+	// DefaultCheckInterval é o intervalo padrão entre verificações das tarefas vencidas
+	DefaultCheckInterval = 30 * time.Second
+	// DefaultTimeout é o prazo padrão de uma execução
+	DefaultTimeout = 30 * time.Minute
+	// lockMargin mantém o bloqueio um pouco além do prazo, para a execução terminar de gravar
+	lockMargin = time.Minute
+	// maxLogSize limita o log guardado de cada execução
+	maxLogSize = 64 * 1024
+)
+
+var (
+	// ErrUnknownJob indica que a tarefa não está registrada
+	ErrUnknownJob = errors.New("tarefa não encontrada")
+	// ErrJobLocked indica que a tarefa já está em execução nesta ou em outra instância
+	ErrJobLocked = errors.New("a tarefa já está em execução")
+)
+
+// Task é o trabalho de uma tarefa. Deve parar quando ctx for cancelado.
+type Task func(ctx context.Context, run *Run) error
+
+// Definition descreve uma tarefa registrada no código. Schedule e Enabled são só os
+// valores iniciais: depois de criada, a tarefa é configurada pelo painel.
+type Definition struct {
+	Name        string
+	Description string
+	Schedule    string
+	Enabled     bool
+	Timeout     time.Duration // padrão DefaultTimeout
+	Task        Task
+}
+
+// Run acompanha uma execução em andamento: itens processados e mensagens de log
+type Run struct {
+	mu    sync.Mutex
+	items int
+	log   strings.Builder
+}
+
+// AddItems soma itens processados pela execução
+func (r *Run) AddItems(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items += n
+}
+
+// Logf registra uma mensagem no log da execução
+func (r *Run) Logf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.log.Len() >= maxLogSize {
+		return
+	}
+	line := time.Now().Format("15:04:05") + " " + fmt.Sprintf(format, args...) + "\n"
+	if r.log.Len()+len(line) > maxLogSize {
+		line = line[:maxLogSize-r.log.Len()]
+	}
+	r.log.WriteString(line)
+}
+
+func (r *Run) result() (int, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.items, r.log.String()
+}
+
+// Scheduler executa as tarefas registradas conforme a agenda gravada no banco. Várias
+// instâncias da API podem rodar juntas: cada execução bloqueia a tarefa no banco.
+type Scheduler struct {
+	db       *gorm.DB
+	instance string
+	interval time.Duration
+
+	definitions map[string]Definition
+	order       []string
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	runs    sync.WaitGroup
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started bool
+}
+
+func New(db *gorm.DB, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:          db,
+		instance:    instanceID(),
+		interval:    interval,
+		definitions: make(map[string]Definition),
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// instanceID identifica esta instância da API nos bloqueios e no histórico
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "api"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// Register adiciona uma tarefa. Deve ser chamado antes de Start.
+func (s *Scheduler) Register(definition Definition) {
+	if definition.Timeout <= 0 {
+		definition.Timeout = DefaultTimeout
+	}
+	if _, exists := s.definitions[definition.Name]; !exists {
+		s.order = append(s.order, definition.Name)
+	}
+	s.definitions[definition.Name] = definition
+}
+
+// Has verifica se a tarefa está registrada
+func (s *Scheduler) Has(name string) bool {
+	_, ok := s.definitions[name]
+	return ok
+}
+
+// Names retorna as tarefas registradas, na ordem de registro
+func (s *Scheduler) Names() []string {
+	return append([]string(nil), s.order...)
+}
+
+// Sync cria no banco as tarefas registradas que ainda não existem e calcula a próxima
+// execução das que não têm uma
+func (s *Scheduler) Sync() error {
+	now := time.Now()
+	for _, name := range s.order {
+		definition := s.definitions[name]
+
+		var job models.ScheduledJob
+		err := s.db.Where("name = ?", name).First(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			job = models.ScheduledJob{
+				Name:        name,
+				Description: definition.Description,
+				Schedule:    definition.Schedule,
+				Enabled:     definition.Enabled,
+			}
+			if schedule, err := ParseSchedule(job.Schedule); err == nil {
+				next := schedule.Next(now)
+				job.NextRunAt = &next
+			} else {
+				return fmt.Errorf("agenda inválida da tarefa %s: %w", name, err)
+			}
+			if err := s.db.Create(&job).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if job.Description != definition.Description {
+			updates["description"] = definition.Description
+		}
+		if job.NextRunAt == nil {
+			if schedule, err := ParseSchedule(job.Schedule); err == nil {
+				updates["next_run_at"] = schedule.Next(now)
+			}
+		}
+		if len(updates) > 0 {
+			if err := s.db.Model(&job).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Start grava as tarefas registradas e passa a verificar, a cada intervalo, quais venceram
+func (s *Scheduler) Start() error {
+	if err := s.Sync(); err != nil {
+		return err
+	}
+	s.started = true
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.runDue()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop interrompe as verificações, cancela as execuções em andamento e espera que terminem
+func (s *Scheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	if s.started {
+		<-s.done
+	}
+	s.cancel()
+	s.runs.Wait()
+}
+
+// runDue inicia as tarefas habilitadas cuja próxima execução já passou
+func (s *Scheduler) runDue() {
+	var jobs []models.ScheduledJob
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, time.Now()).Find(&jobs).Error; err != nil {
+		log.Printf("Erro ao buscar tarefas agendadas: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		definition, ok := s.definitions[job.Name]
+		if !ok {
+			continue
+		}
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			log.Printf("Agenda inválida da tarefa %s: %v", job.Name, err)
+			continue
+		}
+		if _, err := s.start(definition, schedule, TriggerSchedule); err != nil && !errors.Is(err, ErrJobLocked) {
+			log.Printf("Erro ao iniciar a tarefa %s: %v", job.Name, err)
+		}
+	}
+}
+
+// Trigger inicia agora, em segundo plano, uma execução da tarefa, mesmo desabilitada.
+// A próxima execução agendada não muda.
+func (s *Scheduler) Trigger(name string) (models.JobRun, error) {
+	definition, ok := s.definitions[name]
+	if !ok {
+		return models.JobRun{}, ErrUnknownJob
+	}
+	return s.start(definition, nil, TriggerManual)
+}
+
+// start bloqueia a tarefa no banco, registra a execução e a roda em segundo plano.
+// Execuções agendadas (schedule não nulo) só começam se a tarefa ainda estiver vencida,
+// o que evita que duas instâncias rodem a mesma ocorrência uma após a outra.
+func (s *Scheduler) start(definition Definition, schedule Schedule, trigger string) (models.JobRun, error) {
+	if s.ctx.Err() != nil {
+		return models.JobRun{}, errors.New("agendador encerrado")
+	}
+
+	now := time.Now()
+	lockedUntil := now.Add(definition.Timeout + lockMargin)
+	query := s.db.Model(&models.ScheduledJob{}).
+		Where("name = ? AND (locked_until IS NULL OR locked_until < ?)", definition.Name, now)
+	if schedule != nil {
+		query = query.Where("enabled = ? AND next_run_at <= ?", true, now)
+	}
+	result := query.Updates(map[string]interface{}{
+		"locked_by":    s.instance,
+		"locked_until": lockedUntil,
+	})
+	if result.Error != nil {
+		return models.JobRun{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.JobRun{}, ErrJobLocked
+	}
+
+	// Execuções que ficaram "running" pertencem a uma instância que caiu com o bloqueio
+	if err := s.db.Model(&models.JobRun{}).
+		Where("job_name = ? AND status = ?", definition.Name, StatusRunning).
+		Updates(map[string]interface{}{"status": StatusFailed, "error": "execução interrompida"}).Error; err != nil {
+		log.Printf("Erro ao encerrar execuções interrompidas da tarefa %s: %v", definition.Name, err)
+	}
+
+	run := models.JobRun{
+		JobName:   definition.Name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		Status:    StatusRunning,
+		StartedAt: now,
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		s.unlock(definition.Name, nil)
+		return models.JobRun{}, err
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		s.execute(definition, schedule, run)
+	}()
+	return run, nil
+}
+
+// execute roda a tarefa dentro do prazo, grava o resultado e libera o bloqueio
+func (s *Scheduler) execute(definition Definition, schedule Schedule, run models.JobRun) {
+	ctx, cancel := context.WithTimeout(s.ctx, definition.Timeout)
+	defer cancel()
+
+	progress := &Run{}
+	err := safeRun(ctx, definition.Task, progress)
+	items, logText := progress.result()
+
+	finished := time.Now()
+	status := StatusSuccess
+	errText := ""
+	if err != nil {
+		status = StatusFailed
+		errText = err.Error()
+		log.Printf("Tarefa %s falhou: %v", definition.Name, err)
+	}
+
+	if err := s.db.Model(&run).Updates(map[string]interface{}{
+		"status":      status,
+		"finished_at": finished,
+		"duration_ms": finished.Sub(run.StartedAt).Milliseconds(),
+		"items":       items,
+		"error":       errText,
+		"log":         logText,
+	}).Error; err != nil {
+		log.Printf("Erro ao gravar execução da tarefa %s: %v", definition.Name, err)
+	}
+
+	updates := map[string]interface{}{
+		"last_run_at":      run.StartedAt,
+		"last_status":      status,
+		"last_error":       errText,
+		"last_duration_ms": finished.Sub(run.StartedAt).Milliseconds(),
+	}
+	if schedule != nil {
+		// A agenda pode ter sido alterada no painel durante a execução
+		var job models.ScheduledJob
+		if err := s.db.Select("schedule").Where("name = ?", definition.Name).First(&job).Error; err == nil {
+			if current, err := ParseSchedule(job.Schedule); err == nil {
+				schedule = current
+			}
+		}
+		updates["next_run_at"] = schedule.Next(finished)
+	}
+	s.unlock(definition.Name, updates)
+}
+
+// unlock libera o bloqueio da tarefa, se ainda for desta instância, gravando os campos extras
+func (s *Scheduler) unlock(name string, extra map[string]interface{}) {
+	updates := map[string]interface{}{
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	for key, value := range extra {
+		updates[key] = value
+	}
+	if err := s.db.Model(&models.ScheduledJob{}).
+		Where("name = ? AND locked_by = ?", name, s.instance).
+		Updates(updates).Error; err != nil {
+		log.Printf("Erro ao liberar a tarefa %s: %v", name, err)
+	}
+}
+
+// safeRun executa a tarefa convertendo um panic em erro, para não derrubar a API
+func safeRun(ctx context.Context, task Task, run *Run) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return task(ctx, run)
+}
@@ -0,0 +1,324 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ryv-api/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "scheduler.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.ScheduledJob{}, &models.JobRun{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestScheduler registra a tarefa e grava a definição no banco, sem iniciar o ciclo de
+// verificações
+func newTestScheduler(t *testing.T, db *gorm.DB, task Task) *Scheduler {
+	t.Helper()
+
+	s := New(db, time.Hour)
+	s.Register(Definition{Name: "teste", Schedule: "@every 10m", Enabled: true, Task: task})
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s
+}
+
+func loadJob(t *testing.T, db *gorm.DB) models.ScheduledJob {
+	t.Helper()
+	var job models.ScheduledJob
+	if err := db.Where("name = ?", "teste").First(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestStartLockedJob(t *testing.T) {
+	db := openTestDB(t)
+	release := make(chan struct{})
+	s := newTestScheduler(t, db, func(ctx context.Context, run *Run) error {
+		<-release
+		return nil
+	})
+
+	if _, err := s.Trigger("teste"); err != nil {
+		t.Fatal(err)
+	}
+	if job := loadJob(t, db); job.LockedBy != s.instance || job.LockedUntil == nil {
+		t.Errorf("bloqueio = %q até %v, esperado desta instância", job.LockedBy, job.LockedUntil)
+	}
+
+	// Outra instância (ou outro clique no painel) não inicia a tarefa bloqueada
+	other := New(db, time.Hour)
+	other.Register(s.definitions["teste"])
+	if _, err := other.Trigger("teste"); !errors.Is(err, ErrJobLocked) {
+		t.Errorf("Trigger de outra instância: erro = %v, esperado %v", err, ErrJobLocked)
+	}
+	if _, err := s.Trigger("teste"); !errors.Is(err, ErrJobLocked) {
+		t.Errorf("segundo Trigger: erro = %v, esperado %v", err, ErrJobLocked)
+	}
+
+	close(release)
+	s.runs.Wait()
+
+	job := loadJob(t, db)
+	if job.LockedBy != "" || job.LockedUntil != nil {
+		t.Errorf("bloqueio = %q até %v após a execução, esperado liberado", job.LockedBy, job.LockedUntil)
+	}
+	if _, err := other.Trigger("teste"); err != nil {
+		t.Errorf("Trigger após a liberação: %v", err)
+	}
+	other.Stop()
+}
+
+func TestStartTakesOverExpiredLock(t *testing.T) {
+	db := openTestDB(t)
+	s := newTestScheduler(t, db, func(ctx context.Context, run *Run) error { return nil })
+
+	// Uma instância que caiu deixou o bloqueio e a execução "running" para trás
+	expired := time.Now().Add(-time.Minute)
+	if err := db.Model(&models.ScheduledJob{}).Where("name = ?", "teste").
+		Updates(map[string]interface{}{"locked_by": "caiu", "locked_until": expired}).Error; err != nil {
+		t.Fatal(err)
+	}
+	stale := models.JobRun{JobName: "teste", Trigger: TriggerSchedule, Instance: "caiu", Status: StatusRunning, StartedAt: time.Now().Add(-time.Hour)}
+	if err := db.Create(&stale).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := s.Trigger("teste")
+	if err != nil {
+		t.Fatalf("Trigger com o bloqueio vencido: %v", err)
+	}
+	s.runs.Wait()
+
+	if err := db.First(&stale, stale.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stale.Status != StatusFailed || stale.Error != "execução interrompida" {
+		t.Errorf("execução abandonada = %s (%q), esperado %s", stale.Status, stale.Error, StatusFailed)
+	}
+	if err := db.First(&run, run.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != StatusSuccess || run.Instance != s.instance || run.Trigger != TriggerManual {
+		t.Errorf("nova execução = %s por %s (%s), esperado %s por %s (%s)", run.Status, run.Instance, run.Trigger, StatusSuccess, s.instance, TriggerManual)
+	}
+
+	// Um bloqueio ainda válido de outra instância é respeitado
+	if err := db.Model(&models.ScheduledJob{}).Where("name = ?", "teste").
+		Updates(map[string]interface{}{"locked_by": "outra", "locked_until": time.Now().Add(time.Minute)}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Trigger("teste"); !errors.Is(err, ErrJobLocked) {
+		t.Errorf("Trigger com o bloqueio válido: erro = %v, esperado %v", err, ErrJobLocked)
+	}
+}
+
+func TestExecuteAdvancesNextRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    Task
+		status  string
+		errText string
+	}{
+		{"sucesso", func(ctx context.Context, run *Run) error {
+			run.AddItems(3)
+			run.Logf("3 itens")
+			return nil
+		}, StatusSuccess, ""},
+		{"erro", func(ctx context.Context, run *Run) error {
+			return errors.New("falhou")
+		}, StatusFailed, "falhou"},
+		{"panic", func(ctx context.Context, run *Run) error {
+			panic("quebrou")
+		}, StatusFailed, "panic: quebrou"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			s := newTestScheduler(t, db, tt.task)
+
+			schedule, err := ParseSchedule("@every 10m")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Ainda não venceu: a execução agendada não começa
+			if _, err := s.start(s.definitions["teste"], schedule, TriggerSchedule); !errors.Is(err, ErrJobLocked) {
+				t.Fatalf("start antes do horário: erro = %v, esperado %v", err, ErrJobLocked)
+			}
+
+			due := time.Now().Add(-time.Minute)
+			if err := db.Model(&models.ScheduledJob{}).Where("name = ?", "teste").Update("next_run_at", due).Error; err != nil {
+				t.Fatal(err)
+			}
+			s.runDue()
+			s.runs.Wait()
+
+			job := loadJob(t, db)
+			if job.NextRunAt == nil || !job.NextRunAt.After(time.Now().Add(9*time.Minute)) {
+				t.Errorf("next_run_at = %v, esperado daqui a 10 minutos", job.NextRunAt)
+			}
+			if job.LastStatus != tt.status || job.LastError != tt.errText || job.LastRunAt == nil {
+				t.Errorf("último status = %s (%q) em %v, esperado %s (%q)", job.LastStatus, job.LastError, job.LastRunAt, tt.status, tt.errText)
+			}
+
+			var runs []models.JobRun
+			if err := db.Find(&runs).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(runs) != 1 {
+				t.Fatalf("%d execuções, esperado 1", len(runs))
+			}
+			if runs[0].Status != tt.status || runs[0].Trigger != TriggerSchedule || runs[0].FinishedAt == nil {
+				t.Errorf("execução = %s (%s), terminada em %v", runs[0].Status, runs[0].Trigger, runs[0].FinishedAt)
+			}
+			if tt.status == StatusSuccess && (runs[0].Items != 3 || !strings.Contains(runs[0].Log, "3 itens")) {
+				t.Errorf("execução com %d itens e log %q, esperado 3 itens", runs[0].Items, runs[0].Log)
+			}
+
+			// A próxima ocorrência ainda não venceu
+			s.runDue()
+			s.runs.Wait()
+			var count int64
+			db.Model(&models.JobRun{}).Count(&count)
+			if count != 1 {
+				t.Errorf("%d execuções após nova verificação, esperado 1", count)
+			}
+		})
+	}
+}
+
+func TestPublishScheduledTask(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.Article{}); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	future := time.Now().Add(time.Hour)
+	articles := []models.Article{
+		{Title: "Vencido", Slug: "vencido", ScheduledAt: &past},
+		{Title: "Futuro", Slug: "futuro", ScheduledAt: &future},
+		{Title: "Sem agenda", Slug: "sem-agenda"},
+	}
+	for i := range articles {
+		if err := db.Create(&articles[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := 0
+	run := &Run{}
+	if err := PublishScheduledTask(db, func() { calls++ })(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := run.result(); items != 1 || calls != 1 {
+		t.Errorf("%d publicados e %d avisos, esperado 1 e 1", items, calls)
+	}
+
+	want := map[string]bool{"vencido": true, "futuro": false, "sem-agenda": false}
+	for slug, published := range want {
+		var article models.Article
+		if err := db.Where("slug = ?", slug).First(&article).Error; err != nil {
+			t.Fatal(err)
+		}
+		if article.IsPublished != published {
+			t.Errorf("%s: publicado = %v, esperado %v", slug, article.IsPublished, published)
+		}
+		if published && (article.ScheduledAt != nil || article.PublishedAt == nil || !article.PublishedAt.Equal(past)) {
+			t.Errorf("%s: agendado para %v, publicado em %v, esperado publicado em %s", slug, article.ScheduledAt, article.PublishedAt, past)
+		}
+	}
+
+	// Sem nada a publicar, o aviso não é chamado
+	if err := PublishScheduledTask(db, func() { calls++ })(context.Background(), &Run{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("%d avisos, esperado 1", calls)
+	}
+}
+
+func TestRetentionTask(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&models.ArticleVisitor{}, &models.ArticleEventVisitor{}, &models.FetchedPage{}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -40)
+	oldDay, today := old.Format("2006-01-02"), now.Format("2006-01-02")
+	records := []interface{}{
+		&models.ArticleVisitor{ArticleID: 1, Date: oldDay, VisitorHash: "a"},
+		&models.ArticleVisitor{ArticleID: 1, Date: today, VisitorHash: "a"},
+		&models.ArticleEventVisitor{ArticleID: 1, Date: oldDay, VisitorHash: "a", Milestone: "scroll:50"},
+		&models.ArticleEventVisitor{ArticleID: 1, Date: today, VisitorHash: "a", Milestone: "scroll:50"},
+		&models.FetchedPage{URL: "https://a.example/velha", FetchedAt: old},
+		&models.FetchedPage{URL: "https://a.example/nova", FetchedAt: now},
+		&models.JobRun{JobName: "teste", Status: StatusSuccess, StartedAt: now.AddDate(0, 0, -100)},
+		&models.JobRun{JobName: "teste", Status: StatusSuccess, StartedAt: now.AddDate(0, 0, -10)},
+		// Execuções em andamento nunca são apagadas
+		&models.JobRun{JobName: "teste", Status: StatusRunning, StartedAt: now.AddDate(0, 0, -100)},
+	}
+	for _, record := range records {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := &Run{}
+	if err := RetentionTask(db, DefaultRetention)(context.Background(), run); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := run.result(); items != 4 {
+		t.Errorf("%d registros removidos, esperado 4", items)
+	}
+
+	counts := []struct {
+		name  string
+		model interface{}
+		want  int64
+	}{
+		{"visitantes", &models.ArticleVisitor{}, 1},
+		{"marcos", &models.ArticleEventVisitor{}, 1},
+		{"páginas", &models.FetchedPage{}, 1},
+		{"execuções", &models.JobRun{}, 2},
+	}
+	for _, c := range counts {
+		var count int64
+		if err := db.Model(c.model).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != c.want {
+			t.Errorf("%s: %d registros, esperado %d", c.name, count, c.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/scraper"
+
+	"gorm.io/gorm"
+)
+
+// ScraperTask coleta as fontes do scraper cuja agenda venceu
+func ScraperTask(job *scraper.SuggestionJob) Task {
+	return func(ctx context.Context, run *Run) error {
+		result, err := job.Run(ctx)
+		if errors.Is(err, scraper.ErrJobRunning) {
+			// Uma coleta disparada pelo painel já está em andamento
+			run.Logf("Coleta em andamento, execução pulada")
+			return nil
+		}
+		run.AddItems(result.Created)
+		run.Logf("%d fontes coletadas (%d com erro, %d adiadas), %d artigos encontrados, %d novas sugestões, %d repetidas, %d já conhecidas",
+			result.Sources, result.Failed, result.Skipped, result.Found, result.Created, result.Marked, result.Duplicates)
+		return err
+	}
+}
+
+// PublishScheduledTask publica os rascunhos cuja data de publicação agendada chegou.
+// onPublish, se informado, é chamado quando algum artigo é publicado.
+func PublishScheduledTask(db *gorm.DB, onPublish func()) Task {
+	return func(ctx context.Context, run *Run) error {
+		var articles []models.Article
+		if err := db.WithContext(ctx).Where("is_published = ? AND scheduled_at <= ?", false, time.Now()).
+			Order("scheduled_at").Find(&articles).Error; err != nil {
+			return err
+		}
+
+		published := 0
+		for _, article := range articles {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := db.Model(&article).Updates(map[string]interface{}{
+				"is_published": true,
+				"published_at": *article.ScheduledAt,
+				"scheduled_at": nil,
+			}).Error; err != nil {
+				return err
+			}
+			published++
+			run.AddItems(1)
+			run.Logf("Publicado: %s (#%d)", article.Title, article.ID)
+		}
+		if published > 0 && onPublish != nil {
+			onPublish()
+		}
+		return nil
+	}
+}
+
+// Retention define por quantos dias cada tipo de registro auxiliar é guardado
+type Retention struct {
+	VisitorDays     int // visitantes únicos por dia (os totais diários continuam)
+	FetchedPageDays int // páginas guardadas pelo scraper para requisições condicionais
+	JobRunDays      int // histórico de execuções das tarefas
+}
+
+// DefaultRetention são os prazos usados pela limpeza periódica
+var DefaultRetention = Retention{VisitorDays: 30, FetchedPageDays: 30, JobRunDays: 90}
+
+// RetentionTask apaga os registros auxiliares mais antigos que os prazos de retenção
+func RetentionTask(db *gorm.DB, retention Retention) Task {
+	return func(ctx context.Context, run *Run) error {
+		now := time.Now()
+		tx := db.WithContext(ctx)
+
+		steps := []struct {
+			name  string
+			query func() *gorm.DB
+		}{
+			{"visitantes", func() *gorm.DB {
+				cutoff := now.AddDate(0, 0, -retention.VisitorDays).Format("2006-01-02")
+				return tx.Where("date < ?", cutoff).Delete(&models.ArticleVisitor{})
+			}},
+			{"marcos de leitura de visitantes", func() *gorm.DB {
+				cutoff := now.AddDate(0, 0, -retention.VisitorDays).Format("2006-01-02")
+				return tx.Where("date < ?", cutoff).Delete(&models.ArticleEventVisitor{})
+			}},
+			{"páginas guardadas do scraper", func() *gorm.DB {
+				return tx.Where("fetched_at < ?", now.AddDate(0, 0, -retention.FetchedPageDays)).Delete(&models.FetchedPage{})
+			}},
+			{"execuções de tarefas", func() *gorm.DB {
+				return tx.Where("started_at < ? AND status <> ?", now.AddDate(0, 0, -retention.JobRunDays), StatusRunning).Delete(&models.JobRun{})
+			}},
+		}
+		for _, step := range steps {
+			result := step.query()
+			if result.Error != nil {
+				return result.Error
+			}
+			run.AddItems(int(result.RowsAffected))
+			run.Logf("%d registros de %s removidos", result.RowsAffected, step.name)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"net/url"
+	"unicode/utf8"
+
+	"ryv-api/models"
+	"ryv-api/readability"
+	"ryv-api/textutil"
+)
+
+// ExtractArticle baixa a página do artigo e extrai o conteúdo principal como HTML limpo,
+// com título, autor, imagem e data lidos do OpenGraph e do JSON-LD. contentSelector é
+// opcional e indica onde fica o texto na página.
+func (s *ScraperService) ExtractArticle(ctx context.Context, articleURL, contentSelector string) (readability.Article, error) {
+	doc, pageURL, err := s.fetchDocument(ctx, articleURL)
+	if err != nil {
+		return readability.Article{}, err
+	}
+	return readability.Parse(doc, pageURL, contentSelector), nil
+}
+
+// followItem monta o artigo a partir do item da listagem, completando-o com a página do
+// link. Se a página não puder ser baixada, fica o que a própria listagem traz.
+func (s *ScraperService) followItem(ctx context.Context, item feedItem, source models.ScraperSource, listURL *url.URL) ScrapedArticle {
+	article := ScrapedArticle{
+		Title:       textutil.StripHTML(item.Title),
+		URL:         item.URL,
+		ImageURL:    item.ImageURL,
+		PublishedAt: item.PublishedAt,
+		Tags:        item.Tags,
+		Category:    source.Category,
+		Source:      source.URL,
+	}
+	content := readability.Sanitize(item.Content, listURL)
+	summary := textutil.StripHTML(item.Summary)
+
+	if item.URL != "" {
+		page, err := s.ExtractArticle(ctx, item.URL, pageContentSelector(source))
+		if err != nil {
+			if !errors.Is(err, ErrDisallowedByRobots) && ctx.Err() == nil {
+				log.Printf("Erro ao baixar artigo %s: %v", item.URL, err)
+			}
+		} else {
+			// O texto da página costuma ser o artigo inteiro; o da listagem, muitas vezes só um resumo
+			if utf8.RuneCountInString(page.Text) > utf8.RuneCountInString(readability.Text(content)) {
+				content = page.Content
+			}
+			if article.Title == "" {
+				article.Title = page.Title
+			}
+			if article.ImageURL == "" {
+				article.ImageURL = page.ImageURL
+			}
+			if article.PublishedAt.IsZero() {
+				article.PublishedAt = page.PublishedAt
+			}
+			if summary == "" {
+				summary = page.Excerpt
+			}
+			article.Author = page.Author
+			article.CanonicalURL = page.CanonicalURL
+		}
+	}
+
+	if content == "" && summary != "" {
+		content = "<p>" + html.EscapeString(summary) + "</p>"
+	}
+	article.Content = content
+	if summary == "" {
+		summary = readability.Text(content)
+	}
+	article.Excerpt = readability.Excerpt(summary)
+	if article.Category == "" {
+		article.Category = s.CategorizeContent(article.Title, readability.Text(content))
+	}
+
+	return article
+}
+
+// pageContentSelector é o seletor do corpo na página do artigo. Em fontes html o seletor
+// de corpo se refere ao card da listagem, então a página usa a extração automática.
+func pageContentSelector(source models.ScraperSource) string {
+	if source.Type == SourceTypeFeed || source.Type == SourceTypeSitemap {
+		return source.BodySelector
+	}
+	return ""
+}
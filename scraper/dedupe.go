@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"ryv-api/models"
+	"ryv-api/textutil"
+
+	"gorm.io/gorm"
+)
+
+// MaxDuplicateDistance é a diferença máxima, em bits, entre as impressões digitais de dois
+// textos para que sejam considerados o mesmo artigo. Textos sem relação diferem em cerca
+// de 32 bits; pequenas edições, em poucos.
+const MaxDuplicateDistance = 6
+
+// fingerprintRef é a impressão digital de uma sugestão ou de um artigo já gravado
+type fingerprintRef struct {
+	ID          uint
+	Fingerprint int64
+}
+
+// dedupeIndex reúne as URLs canônicas e as impressões digitais do que já foi sugerido ou
+// publicado, para reconhecer o mesmo artigo vindo de outro link ou de outro site
+type dedupeIndex struct {
+	suggestionURLs map[string]bool
+	articleURLs    map[string]uint
+	suggestions    []fingerprintRef
+	articles       []fingerprintRef
+}
+
+// duplicateMatch indica o original de uma sugestão repetida
+type duplicateMatch struct {
+	suggestionID *uint
+	articleID    *uint
+}
+
+// loadDedupeIndex carrega as sugestões (inclusive rejeitadas e removidas) e os artigos.
+// Sugestões já marcadas como repetidas não servem de original.
+func loadDedupeIndex(db *gorm.DB) (*dedupeIndex, error) {
+	index := &dedupeIndex{
+		suggestionURLs: make(map[string]bool),
+		articleURLs:    make(map[string]uint),
+	}
+
+	var suggestions []models.ScrapedArticle
+	if err := db.Unscoped().Model(&models.ScrapedArticle{}).
+		Select("id", "canonical_url", "fingerprint", "status").Find(&suggestions).Error; err != nil {
+		return nil, err
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.CanonicalURL != "" {
+			index.suggestionURLs[suggestion.CanonicalURL] = true
+		}
+		if suggestion.Status != StatusDuplicate && suggestion.Fingerprint != 0 {
+			index.suggestions = append(index.suggestions, fingerprintRef{ID: suggestion.ID, Fingerprint: suggestion.Fingerprint})
+		}
+	}
+
+	var articles []models.Article
+	if err := db.Model(&models.Article{}).Select("id", "source_url", "fingerprint").Find(&articles).Error; err != nil {
+		return nil, err
+	}
+	for _, article := range articles {
+		if canonical := textutil.CanonicalURL(article.SourceURL); canonical != "" {
+			index.articleURLs[canonical] = article.ID
+		}
+		if article.Fingerprint != 0 {
+			index.articles = append(index.articles, fingerprintRef{ID: article.ID, Fingerprint: article.Fingerprint})
+		}
+	}
+
+	return index, nil
+}
+
+// suggested verifica se alguma das URLs canônicas já foi sugerida
+func (i *dedupeIndex) suggested(urls ...string) bool {
+	for _, u := range urls {
+		if u != "" && i.suggestionURLs[u] {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateOf procura um artigo publicado com a mesma URL ou um artigo ou sugestão com
+// conteúdo quase igual. Artigos têm preferência sobre sugestões.
+func (i *dedupeIndex) duplicateOf(fingerprint int64, urls ...string) (duplicateMatch, bool) {
+	for _, u := range urls {
+		if id, ok := i.articleURLs[u]; u != "" && ok {
+			return duplicateMatch{articleID: &id}, true
+		}
+	}
+	if fingerprint == 0 {
+		return duplicateMatch{}, false
+	}
+	if id, ok := nearest(i.articles, fingerprint); ok {
+		return duplicateMatch{articleID: &id}, true
+	}
+	if id, ok := nearest(i.suggestions, fingerprint); ok {
+		return duplicateMatch{suggestionID: &id}, true
+	}
+	return duplicateMatch{}, false
+}
+
+// add registra uma sugestão recém-gravada
+func (i *dedupeIndex) add(suggestion models.ScrapedArticle) {
+	if suggestion.CanonicalURL != "" {
+		i.suggestionURLs[suggestion.CanonicalURL] = true
+	}
+	if suggestion.Status != StatusDuplicate && suggestion.Fingerprint != 0 {
+		i.suggestions = append(i.suggestions, fingerprintRef{ID: suggestion.ID, Fingerprint: suggestion.Fingerprint})
+	}
+}
+
+// nearest retorna o item com a impressão digital mais próxima, se estiver dentro do limite
+func nearest(refs []fingerprintRef, fingerprint int64) (uint, bool) {
+	bestID, bestDistance := uint(0), MaxDuplicateDistance+1
+	for _, ref := range refs {
+		distance := textutil.HammingDistance(uint64(ref.Fingerprint), uint64(fingerprint))
+		if distance < bestDistance {
+			bestID, bestDistance = ref.ID, distance
+		}
+	}
+	return bestID, bestDistance <= MaxDuplicateDistance
+}
@@ -0,0 +1,452 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/textutil"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// MaxFeedItems limita quantos itens novos de um feed ou sitemap são baixados por coleta
+	MaxFeedItems = 20
+	// maxChildSitemaps limita quantos sitemaps de um índice são lidos (os mais recentes)
+	maxChildSitemaps = 3
+)
+
+var errUnknownFeed = errors.New("formato de feed não reconhecido (esperado RSS, Atom ou JSON Feed)")
+
+// feedItem é um item da listagem (card HTML, item de feed ou URL de sitemap), antes de a
+// página do artigo ser baixada
+type feedItem struct {
+	Title       string
+	URL         string
+	Summary     string
+	Content     string
+	ImageURL    string
+	PublishedAt time.Time
+	Tags        []string
+}
+
+// readFeed lê o feed ou sitemap da fonte e retorna os itens e a URL final
+func (s *ScraperService) readFeed(ctx context.Context, source models.ScraperSource) ([]feedItem, *url.URL, error) {
+	body, feedURL, err := s.fetchPage(ctx, source.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []feedItem
+	if source.Type == SourceTypeSitemap {
+		items, err = s.sitemapItems(ctx, body, feedURL)
+	} else {
+		items, err = parseFeed(body, feedURL)
+	}
+	return items, feedURL, err
+}
+
+// withoutKnownItems remove os itens cujo link já foi sugerido (comparando também a URL
+// canônica, sem parâmetros de rastreamento), para não baixá-los de novo
+func (s *ScraperService) withoutKnownItems(items []feedItem) ([]feedItem, error) {
+	if s.db == nil || len(items) == 0 {
+		return items, nil
+	}
+
+	urls := make([]string, 0, len(items))
+	canonicals := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.URL != "" {
+			urls = append(urls, item.URL)
+		}
+		if canonical := textutil.CanonicalURL(item.URL); canonical != "" {
+			canonicals = append(canonicals, canonical)
+		}
+	}
+	var known []models.ScrapedArticle
+	if len(urls) > 0 {
+		if err := s.db.Unscoped().Model(&models.ScrapedArticle{}).Select("source_url", "canonical_url").
+			Where("source_url IN ? OR canonical_url IN ?", urls, canonicals).Find(&known).Error; err != nil {
+			return nil, err
+		}
+	}
+	knownURLs := make(map[string]bool, 2*len(known))
+	for _, suggestion := range known {
+		knownURLs[suggestion.SourceURL] = true
+		if suggestion.CanonicalURL != "" {
+			knownURLs[suggestion.CanonicalURL] = true
+		}
+	}
+
+	fresh := items[:0]
+	for _, item := range items {
+		if !knownURLs[item.URL] && !knownURLs[textutil.CanonicalURL(item.URL)] {
+			fresh = append(fresh, item)
+		}
+	}
+	return fresh, nil
+}
+
+// parseFeed reconhece e lê feeds RSS 2.0 (e RSS 1.0), Atom e JSON Feed
+func parseFeed(body []byte, feedURL *url.URL) ([]feedItem, error) {
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseJSONFeed(trimmed, feedURL)
+	}
+
+	switch xmlRoot(trimmed) {
+	case "rss", "RDF":
+		return parseRSS(trimmed, feedURL)
+	case "feed":
+		return parseAtom(trimmed, feedURL)
+	}
+	return nil, errUnknownFeed
+}
+
+// xmlRoot retorna o nome do elemento raiz do documento XML
+func xmlRoot(body []byte) string {
+	decoder := newXMLDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+func newXMLDecoder(body []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	// Feeds em ISO-8859-1, Windows-1252 e afins são convertidos para UTF-8
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder
+}
+
+type rssDocument struct {
+	Items    []rssItem `xml:"channel>item"`
+	RDFItems []rssItem `xml:"item"` // RSS 1.0 (RDF) traz os itens fora do channel
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Categories  []string `xml:"category"`
+	Enclosures  []struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	Media []struct {
+		URL    string `xml:"url,attr"`
+		Medium string `xml:"medium,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnail struct {
+		URL string `xml:"url,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+func parseRSS(body []byte, feedURL *url.URL) ([]feedItem, error) {
+	var doc rssDocument
+	if err := newXMLDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("erro ao ler feed RSS: %w", err)
+	}
+
+	items := make([]feedItem, 0, len(doc.Items)+len(doc.RDFItems))
+	for _, entry := range append(doc.Items, doc.RDFItems...) {
+		item := feedItem{
+			Title:   entry.Title,
+			Summary: entry.Description,
+			Content: entry.Content,
+		}
+		link := strings.TrimSpace(entry.Link)
+		if link == "" && strings.HasPrefix(strings.TrimSpace(entry.GUID), "http") {
+			link = entry.GUID
+		}
+		if link != "" {
+			item.URL = resolveURL(feedURL, link)
+		}
+		date := entry.PubDate
+		if date == "" {
+			date = entry.Date
+		}
+		item.PublishedAt, _ = parseDate(date)
+		item.Tags = cleanTags(entry.Categories)
+
+		for _, enclosure := range entry.Enclosures {
+			if strings.HasPrefix(enclosure.Type, "image/") && item.ImageURL == "" {
+				item.ImageURL = resolveURL(feedURL, enclosure.URL)
+			}
+		}
+		for _, media := range entry.Media {
+			if item.ImageURL == "" && media.URL != "" && (media.Medium == "image" || strings.HasPrefix(media.Type, "image/")) {
+				item.ImageURL = resolveURL(feedURL, media.URL)
+			}
+		}
+		if item.ImageURL == "" && entry.Thumbnail.URL != "" {
+			item.ImageURL = resolveURL(feedURL, entry.Thumbnail.URL)
+		}
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+type atomDocument struct {
+	Entries []struct {
+		Title     atomText `xml:"title"`
+		Summary   atomText `xml:"summary"`
+		Content   atomText `xml:"content"`
+		Published string   `xml:"published"`
+		Updated   string   `xml:"updated"`
+		Links     []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Categories []struct {
+			Term  string `xml:"term,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+// atomText é um campo de texto do Atom, que pode ser texto, HTML escapado ou XHTML
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+func parseAtom(body []byte, feedURL *url.URL) ([]feedItem, error) {
+	var doc atomDocument
+	if err := newXMLDecoder(body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("erro ao ler feed Atom: %w", err)
+	}
+
+	items := make([]feedItem, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		item := feedItem{
+			Title:   textutil.StripHTML(entry.Title.String()),
+			Summary: entry.Summary.String(),
+			Content: entry.Content.String(),
+		}
+		for _, link := range entry.Links {
+			switch {
+			case (link.Rel == "" || link.Rel == "alternate") && item.URL == "":
+				item.URL = resolveURL(feedURL, link.Href)
+			case link.Rel == "enclosure" && strings.HasPrefix(link.Type, "image/") && item.ImageURL == "":
+				item.ImageURL = resolveURL(feedURL, link.Href)
+			}
+		}
+		date := entry.Published
+		if date == "" {
+			date = entry.Updated
+		}
+		item.PublishedAt, _ = parseDate(date)
+
+		tags := make([]string, 0, len(entry.Categories))
+		for _, category := range entry.Categories {
+			if category.Label != "" {
+				tags = append(tags, category.Label)
+			} else {
+				tags = append(tags, category.Term)
+			}
+		}
+		item.Tags = cleanTags(tags)
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+type jsonFeedDocument struct {
+	Version string `json:"version"`
+	Items   []struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url"`
+		Title         string   `json:"title"`
+		ContentHTML   string   `json:"content_html"`
+		ContentText   string   `json:"content_text"`
+		Summary       string   `json:"summary"`
+		Image         string   `json:"image"`
+		BannerImage   string   `json:"banner_image"`
+		DatePublished string   `json:"date_published"`
+		DateModified  string   `json:"date_modified"`
+		Tags          []string `json:"tags"`
+	} `json:"items"`
+}
+
+func parseJSONFeed(body []byte, feedURL *url.URL) ([]feedItem, error) {
+	var doc jsonFeedDocument
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("erro ao ler JSON Feed: %w", err)
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, errUnknownFeed
+	}
+
+	items := make([]feedItem, 0, len(doc.Items))
+	for _, entry := range doc.Items {
+		item := feedItem{
+			Title:   entry.Title,
+			Summary: entry.Summary,
+			Content: entry.ContentHTML,
+			Tags:    cleanTags(entry.Tags),
+		}
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+		link := entry.URL
+		if link == "" && strings.HasPrefix(entry.ID, "http") {
+			link = entry.ID
+		}
+		if link != "" {
+			item.URL = resolveURL(feedURL, link)
+		}
+		image := entry.Image
+		if image == "" {
+			image = entry.BannerImage
+		}
+		if image != "" {
+			item.ImageURL = resolveURL(feedURL, image)
+		}
+		date := entry.DatePublished
+		if date == "" {
+			date = entry.DateModified
+		}
+		item.PublishedAt, _ = parseDate(date)
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+type sitemapDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+		News    struct {
+			Title           string `xml:"title"`
+			PublicationDate string `xml:"publication_date"`
+			Keywords        string `xml:"keywords"`
+		} `xml:"http://www.google.com/schemas/sitemap-news/0.9 news"`
+		Images []struct {
+			Loc string `xml:"loc"`
+		} `xml:"http://www.google.com/schemas/sitemap-image/1.1 image"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"sitemap"`
+}
+
+// sitemapItems lê um sitemap (urlset) ou um índice de sitemaps; do índice, são lidos os
+// sitemaps modificados mais recentemente
+func (s *ScraperService) sitemapItems(ctx context.Context, body []byte, sitemapURL *url.URL) ([]feedItem, error) {
+	doc, err := parseSitemap(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Sitemaps) == 0 {
+		return doc.items(sitemapURL), nil
+	}
+
+	children := doc.Sitemaps
+	sort.SliceStable(children, func(i, j int) bool {
+		a, _ := parseDate(children[i].LastMod)
+		b, _ := parseDate(children[j].LastMod)
+		return a.After(b)
+	})
+	if len(children) > maxChildSitemaps {
+		children = children[:maxChildSitemaps]
+	}
+
+	var items []feedItem
+	for _, child := range children {
+		childURL := resolveURL(sitemapURL, child.Loc)
+		childBody, finalURL, err := s.fetchPage(ctx, childURL)
+		if err != nil {
+			return items, err
+		}
+		childDoc, err := parseSitemap(childBody)
+		if err != nil {
+			return items, err
+		}
+		// Índices dentro de índices não são seguidos
+		items = append(items, childDoc.items(finalURL)...)
+	}
+	return items, nil
+}
+
+func parseSitemap(body []byte) (sitemapDocument, error) {
+	var doc sitemapDocument
+	switch xmlRoot(bytes.TrimSpace(body)) {
+	case "urlset", "sitemapindex":
+	default:
+		return doc, errors.New("sitemap inválido (esperado urlset ou sitemapindex)")
+	}
+	if err := newXMLDecoder(body).Decode(&doc); err != nil {
+		return doc, fmt.Errorf("erro ao ler sitemap: %w", err)
+	}
+	return doc, nil
+}
+
+func (doc sitemapDocument) items(sitemapURL *url.URL) []feedItem {
+	items := make([]feedItem, 0, len(doc.URLs))
+	for _, entry := range doc.URLs {
+		if strings.TrimSpace(entry.Loc) == "" {
+			continue
+		}
+		item := feedItem{
+			Title: entry.News.Title,
+			URL:   resolveURL(sitemapURL, entry.Loc),
+		}
+		date := entry.News.PublicationDate
+		if date == "" {
+			date = entry.LastMod
+		}
+		item.PublishedAt, _ = parseDate(date)
+		if len(entry.Images) > 0 {
+			item.ImageURL = resolveURL(sitemapURL, entry.Images[0].Loc)
+		}
+		if entry.News.Keywords != "" {
+			item.Tags = cleanTags(strings.Split(entry.News.Keywords, ","))
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func cleanTags(tags []string) []string {
+	var cleaned []string
+	for _, tag := range tags {
+		if tag = strings.Join(strings.Fields(tag), " "); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,293 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestParseFeed(t *testing.T) {
+	feedURL := mustParseURL(t, "https://exemplo.com/feed/")
+
+	tests := []struct {
+		name string
+		body string
+		want []feedItem
+	}{
+		{
+			name: "RSS 2.0",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+  <title>Blog</title>
+  <item>
+    <title>Óculos de grau</title>
+    <link>/artigos/oculos</link>
+    <description>Resumo</description>
+    <content:encoded><![CDATA[<p>Texto <b>completo</b></p>]]></content:encoded>
+    <pubDate>Mon, 02 Sep 2024 10:30:00 -0300</pubDate>
+    <category>Saúde</category>
+    <category>  Visão   ocular </category>
+    <enclosure url="https://cdn.exemplo.com/capa.jpg" type="image/jpeg" length="1"/>
+  </item>
+  <item>
+    <title>Sem link</title>
+    <guid>https://exemplo.com/?p=2</guid>
+    <media:content url="/img/2.png" medium="image"/>
+  </item>
+  <item>
+    <title>Miniatura</title>
+    <guid isPermaLink="false">post-3</guid>
+    <media:thumbnail url="https://cdn.exemplo.com/3.jpg"/>
+  </item>
+</channel>
+</rss>`,
+			want: []feedItem{
+				{
+					Title:       "Óculos de grau",
+					URL:         "https://exemplo.com/artigos/oculos",
+					Summary:     "Resumo",
+					Content:     "<p>Texto <b>completo</b></p>",
+					ImageURL:    "https://cdn.exemplo.com/capa.jpg",
+					PublishedAt: time.Date(2024, 9, 2, 13, 30, 0, 0, time.UTC),
+					Tags:        []string{"Saúde", "Visão ocular"},
+				},
+				{Title: "Sem link", URL: "https://exemplo.com/?p=2", ImageURL: "https://exemplo.com/img/2.png"},
+				{Title: "Miniatura", ImageURL: "https://cdn.exemplo.com/3.jpg"},
+			},
+		},
+		{
+			name: "RSS 1.0 (RDF)",
+			body: `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <channel rdf:about="https://exemplo.com/"><title>Blog</title></channel>
+  <item rdf:about="https://exemplo.com/a">
+    <title>Artigo RDF</title>
+    <link>https://exemplo.com/a</link>
+    <dc:date>2024-09-02T10:30:00Z</dc:date>
+  </item>
+</rdf:RDF>`,
+			want: []feedItem{
+				{Title: "Artigo RDF", URL: "https://exemplo.com/a", PublishedAt: time.Date(2024, 9, 2, 10, 30, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name: "RSS em ISO-8859-1",
+			body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<rss version=\"2.0\"><channel><item><title>Vis\xe3o</title><link>https://exemplo.com/v</link></item></channel></rss>",
+			want: []feedItem{
+				{Title: "Visão", URL: "https://exemplo.com/v"},
+			},
+		},
+		{
+			name: "Atom",
+			body: `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Blog</title>
+  <entry>
+    <title type="html">Lentes &lt;em&gt;de contato&lt;/em&gt;</title>
+    <link rel="enclosure" type="image/png" href="/capa.png"/>
+    <link rel="alternate" href="https://exemplo.com/lentes"/>
+    <link rel="alternate" href="https://exemplo.com/outra"/>
+    <summary>Resumo</summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Texto</p></div></content>
+    <updated>2024-09-01T08:00:00Z</updated>
+    <published>2024-08-31T08:00:00Z</published>
+    <category term="saude" label="Saúde"/>
+    <category term="lentes"/>
+  </entry>
+</feed>`,
+			want: []feedItem{
+				{
+					Title:       "Lentes de contato",
+					URL:         "https://exemplo.com/lentes",
+					Summary:     "Resumo",
+					Content:     `<div xmlns="http://www.w3.org/1999/xhtml"><p>Texto</p></div>`,
+					ImageURL:    "https://exemplo.com/capa.png",
+					PublishedAt: time.Date(2024, 8, 31, 8, 0, 0, 0, time.UTC),
+					Tags:        []string{"Saúde", "lentes"},
+				},
+			},
+		},
+		{
+			name: "JSON Feed",
+			body: `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Blog",
+  "items": [
+    {"id": "1", "url": "/json/1", "title": "Com HTML", "content_html": "<p>HTML</p>", "content_text": "texto", "summary": "Resumo", "image": "/1.jpg", "date_published": "2024-09-02T10:30:00Z", "tags": ["saude", " "]},
+    {"id": "https://exemplo.com/json/2", "title": "Só texto", "content_text": "texto", "banner_image": "https://cdn.exemplo.com/2.jpg", "date_modified": "2024-09-03T00:00:00Z"}
+  ]
+}`,
+			want: []feedItem{
+				{
+					Title:       "Com HTML",
+					URL:         "https://exemplo.com/json/1",
+					Summary:     "Resumo",
+					Content:     "<p>HTML</p>",
+					ImageURL:    "https://exemplo.com/1.jpg",
+					PublishedAt: time.Date(2024, 9, 2, 10, 30, 0, 0, time.UTC),
+					Tags:        []string{"saude"},
+				},
+				{
+					Title:       "Só texto",
+					URL:         "https://exemplo.com/json/2",
+					Content:     "texto",
+					ImageURL:    "https://cdn.exemplo.com/2.jpg",
+					PublishedAt: time.Date(2024, 9, 3, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := parseFeed([]byte(tt.body), feedURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(tt.want) {
+				t.Fatalf("%d itens, esperado %d: %+v", len(items), len(tt.want), items)
+			}
+			for i := range items {
+				assertFeedItem(t, i, items[i], tt.want[i])
+			}
+		})
+	}
+}
+
+// assertFeedItem compara os itens, com as datas comparadas como instantes
+func assertFeedItem(t *testing.T, index int, got, want feedItem) {
+	t.Helper()
+	if !got.PublishedAt.Equal(want.PublishedAt) {
+		t.Errorf("item %d: PublishedAt = %s, esperado %s", index, got.PublishedAt, want.PublishedAt)
+	}
+	got.PublishedAt, want.PublishedAt = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("item %d:\n  obtido   %+v\n  esperado %+v", index, got, want)
+	}
+}
+
+func TestParseFeedUnknownFormat(t *testing.T) {
+	feedURL := mustParseURL(t, "https://exemplo.com/feed")
+	for _, body := range []string{
+		"<html><body>Não é feed</body></html>",
+		`{"version": "1.0", "items": []}`,
+		"texto qualquer",
+		"",
+	} {
+		if _, err := parseFeed([]byte(body), feedURL); err == nil {
+			t.Errorf("parseFeed(%q) sem erro", body)
+		}
+	}
+	if _, err := parseFeed([]byte(`{"version": "https://jsonfeed.org/version/1", "items": [`), feedURL); err == nil {
+		t.Error("JSON Feed truncado sem erro")
+	}
+}
+
+func TestParseSitemap(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+        xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"
+        xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>https://exemplo.com/noticia</loc>
+    <lastmod>2024-09-01</lastmod>
+    <news:news>
+      <news:title>Notícia</news:title>
+      <news:publication_date>2024-09-02T10:30:00Z</news:publication_date>
+      <news:keywords>saúde, visão</news:keywords>
+    </news:news>
+    <image:image><image:loc>/foto.jpg</image:loc></image:image>
+  </url>
+  <url><loc>/pagina</loc><lastmod>2024-08-01</lastmod></url>
+  <url><loc>  </loc></url>
+</urlset>`
+
+	doc, err := parseSitemap([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := doc.items(mustParseURL(t, "https://exemplo.com/sitemap.xml"))
+	want := []feedItem{
+		{
+			Title:       "Notícia",
+			URL:         "https://exemplo.com/noticia",
+			ImageURL:    "https://exemplo.com/foto.jpg",
+			PublishedAt: time.Date(2024, 9, 2, 10, 30, 0, 0, time.UTC),
+			Tags:        []string{"saúde", "visão"},
+		},
+		{URL: "https://exemplo.com/pagina", PublishedAt: time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("%d itens, esperado %d: %+v", len(items), len(want), items)
+	}
+	for i := range items {
+		assertFeedItem(t, i, items[i], want[i])
+	}
+
+	if _, err := parseSitemap([]byte("<rss><channel></channel></rss>")); err == nil {
+		t.Error("parseSitemap aceitou um feed RSS")
+	}
+}
+
+func TestSitemapIndex(t *testing.T) {
+	var fetched []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(w, r)
+		case "/sitemap-a.xml", "/sitemap-b.xml", "/sitemap-c.xml", "/sitemap-d.xml":
+			name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/sitemap-"), ".xml")
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%s/%s</loc></url></urlset>`, server.URL, name)
+		default:
+			t.Errorf("caminho inesperado: %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index := `<?xml version="1.0"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-a.xml</loc><lastmod>2024-01-01</lastmod></sitemap>
+  <sitemap><loc>/sitemap-b.xml</loc><lastmod>2024-09-01</lastmod></sitemap>
+  <sitemap><loc>/sitemap-c.xml</loc><lastmod>2024-03-01</lastmod></sitemap>
+  <sitemap><loc>/sitemap-d.xml</loc><lastmod>2024-06-01</lastmod></sitemap>
+</sitemapindex>`
+
+	s := NewScraperService(nil, Config{HostDelay: time.Millisecond})
+	items, err := s.sitemapItems(context.Background(), []byte(index), mustParseURL(t, server.URL+"/sitemap.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Só os três sitemaps modificados mais recentemente são lidos, do mais novo ao mais antigo
+	var urls []string
+	for _, item := range items {
+		urls = append(urls, strings.TrimPrefix(item.URL, server.URL))
+	}
+	if want := []string{"/b", "/d", "/c"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("itens = %v, esperado %v", urls, want)
+	}
+	for _, path := range fetched {
+		if path == "/sitemap-a.xml" {
+			t.Error("sitemap mais antigo foi baixado")
+		}
+	}
+}
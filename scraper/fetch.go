@@ -0,0 +1,238 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ryv-api/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"gorm.io/gorm/clause"
+)
+
+// Valores padrão da configuração do scraper
+const (
+	DefaultUserAgent       = "RYVBot/1.0 (+https://github.com/MarceloBxD/ryv-api)"
+	DefaultHostDelay       = 2 * time.Second
+	DefaultHostConcurrency = 1
+	DefaultWorkers         = 4
+	DefaultDeadline        = 5 * time.Minute
+)
+
+// maxPageSize limita o tamanho das páginas baixadas
+const maxPageSize = 5 * 1024 * 1024
+
+// maxRedirects é o limite de redirecionamentos seguidos, o mesmo do http.Client padrão
+const maxRedirects = 10
+
+// ErrDisallowedByRobots indica que o robots.txt do site não permite coletar a página
+var ErrDisallowedByRobots = errors.New("bloqueado pelo robots.txt do site")
+
+// Config reúne os parâmetros de cortesia e paralelismo do scraper. Campos zerados
+// usam os valores padrão.
+type Config struct {
+	UserAgent       string        // identificação honesta do robô, usada também no robots.txt
+	HostDelay       time.Duration // intervalo mínimo entre requisições ao mesmo site
+	HostConcurrency int           // requisições simultâneas ao mesmo site
+	Workers         int           // fontes coletadas em paralelo
+	Deadline        time.Duration // prazo total de uma execução do scraper
+}
+
+func (c Config) withDefaults() Config {
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	if c.HostDelay <= 0 {
+		c.HostDelay = DefaultHostDelay
+	}
+	if c.HostConcurrency <= 0 {
+		c.HostConcurrency = DefaultHostConcurrency
+	}
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.Deadline <= 0 {
+		c.Deadline = DefaultDeadline
+	}
+	return c
+}
+
+// fetchDocument baixa a página e retorna o documento e a URL final (após redirecionamentos)
+func (s *ScraperService) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, *url.URL, error) {
+	body, finalURL, err := s.fetchPage(ctx, pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, finalURL, nil
+}
+
+// fetchPage baixa a página respeitando o robots.txt e os limites por site. Se a página
+// não mudou desde a última coleta (304), devolve a versão guardada.
+func (s *ScraperService) fetchPage(ctx context.Context, pageURL string) ([]byte, *url.URL, error) {
+	target, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rules, err := s.robots(ctx, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rules.Allowed(target.RequestURI()) {
+		return nil, nil, fmt.Errorf("%s: %w", pageURL, ErrDisallowedByRobots)
+	}
+
+	release, err := s.limiter.wait(ctx, target.Host, s.hostDelay(rules))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	// Os redirecionamentos desta requisição não podem esperar de novo pela vaga que ela ocupa
+	reqCtx := context.WithValue(ctx, heldHostKey{}, target.Host)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("User-Agent", s.config.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+
+	cached, hasCache := s.cachedPage(pageURL)
+	if hasCache {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		finalURL, err := url.Parse(cached.FinalURL)
+		if err != nil || cached.FinalURL == "" {
+			finalURL = target
+		}
+		return []byte(cached.Body), finalURL, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("resposta inesperada de %s: %s", pageURL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	finalURL := resp.Request.URL
+
+	s.storePage(models.FetchedPage{
+		URL:          pageURL,
+		FinalURL:     finalURL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Body:         string(body),
+		FetchedAt:    time.Now(),
+	})
+
+	return body, finalURL, nil
+}
+
+// hostDelay é o intervalo entre requisições ao site: o configurado ou o Crawl-delay do
+// robots.txt, se for maior
+func (s *ScraperService) hostDelay(rules robotsRules) time.Duration {
+	delay := s.config.HostDelay
+	if crawlDelay := min(rules.crawlDelay, MaxCrawlDelay); crawlDelay > delay {
+		delay = crawlDelay
+	}
+	return delay
+}
+
+// heldHostKey guarda no contexto o site cuja vaga de concorrência a requisição já ocupa
+type heldHostKey struct{}
+
+// acquire aguarda a vez de uma requisição ao site, como hostLimiter.wait. Se a requisição
+// em curso já ocupa a vaga do site (um redirecionamento de http para https, por exemplo),
+// apenas respeita o intervalo, para não esperar por si mesma.
+func (s *ScraperService) acquire(ctx context.Context, host string, delay time.Duration) (func(), error) {
+	if held, _ := ctx.Value(heldHostKey{}).(string); held == host {
+		if err := s.limiter.pace(ctx, host, delay); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+	return s.limiter.wait(ctx, host, delay)
+}
+
+// checkRedirect aplica a cada redirecionamento as mesmas regras da requisição original:
+// o destino precisa ser permitido pelo robots.txt do seu site e respeitar o intervalo
+// entre requisições a ele
+func (s *ScraperService) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("interrompido após %d redirecionamentos", maxRedirects)
+	}
+	ctx := req.Context()
+
+	// O próprio robots.txt pode ser redirecionado (RFC 9309); consultar as regras aqui
+	// travaria o cache, que está sendo preenchido por esta requisição
+	if isRobotsRequest(ctx) {
+		return s.limiter.pace(ctx, req.URL.Host, s.config.HostDelay)
+	}
+
+	rules, err := s.robots(ctx, req.URL)
+	if err != nil {
+		return err
+	}
+	if !rules.Allowed(req.URL.RequestURI()) {
+		return fmt.Errorf("%s: %w", req.URL, ErrDisallowedByRobots)
+	}
+	return s.limiter.pace(ctx, req.URL.Host, s.hostDelay(rules))
+}
+
+func (s *ScraperService) cachedPage(pageURL string) (models.FetchedPage, bool) {
+	var page models.FetchedPage
+	if s.db == nil {
+		return page, false
+	}
+	err := s.db.Where("url = ?", pageURL).First(&page).Error
+	return page, err == nil && (page.ETag != "" || page.LastModified != "")
+}
+
+// storePage guarda a página para a próxima requisição condicional. Páginas sem ETag nem
+// Last-Modified não são guardadas, pois não haveria como validá-las.
+func (s *ScraperService) storePage(page models.FetchedPage) {
+	if s.db == nil {
+		return
+	}
+	if page.ETag == "" && page.LastModified == "" {
+		if err := s.db.Where("url = ?", page.URL).Delete(&models.FetchedPage{}).Error; err != nil {
+			log.Printf("Erro ao remover página guardada %s: %v", page.URL, err)
+		}
+		return
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"final_url", "etag", "last_modified", "body", "fetched_at", "updated_at"}),
+	}).Create(&page).Error; err != nil {
+		log.Printf("Erro ao guardar página %s: %v", page.URL, err)
+	}
+}
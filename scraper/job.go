@@ -0,0 +1,309 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ryv-api/models"
+	"ryv-api/textutil"
+
+	"gorm.io/gorm"
+)
+
+// DefaultSourceSchedule é o intervalo entre coletas de uma fonte sem agenda válida
+const DefaultSourceSchedule = 6 * time.Hour
+
+// Situações de uma sugestão na fila de moderação
+const (
+	StatusPending   = "pending"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusDuplicate = "duplicate" // mesmo artigo de outra sugestão ou de um artigo publicado
+)
+
+// ErrJobRunning indica que já existe uma execução do scraper em andamento
+var ErrJobRunning = errors.New("o scraper já está em execução")
+
+// JobResult resume uma execução do scraper
+type JobResult struct {
+	Sources    int   `json:"sources"`
+	Failed     int   `json:"failed"`
+	Skipped    int   `json:"skipped"`
+	Found      int   `json:"found"`
+	Created    int   `json:"created"`
+	Marked     int   `json:"marked_duplicates"` // gravadas como repetidas de outra sugestão ou artigo
+	Duplicates int   `json:"duplicates"`        // já sugeridas antes, ignoradas
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// SuggestionJob coleta as fontes cadastradas e grava os artigos encontrados como sugestões
+// pendentes de moderação. A execução periódica fica a cargo do agendador de tarefas.
+type SuggestionJob struct {
+	db      *gorm.DB
+	service *ScraperService
+
+	running sync.Mutex
+}
+
+func NewSuggestionJob(db *gorm.DB, service *ScraperService) *SuggestionJob {
+	return &SuggestionJob{db: db, service: service}
+}
+
+// Run coleta uma vez as fontes habilitadas cuja agenda venceu e grava as sugestões que
+// ainda não existem. Execuções simultâneas não são permitidas.
+func (j *SuggestionJob) Run(ctx context.Context) (JobResult, error) {
+	if !j.running.TryLock() {
+		return JobResult{}, ErrJobRunning
+	}
+	defer j.running.Unlock()
+
+	return j.run(ctx, false)
+}
+
+// Trigger inicia em segundo plano a coleta de todas as fontes habilitadas, mesmo as que
+// não estão na hora, e retorna imediatamente. Retorna ErrJobRunning se já houver uma
+// execução em andamento.
+func (j *SuggestionJob) Trigger() error {
+	if !j.running.TryLock() {
+		return ErrJobRunning
+	}
+
+	go func() {
+		defer j.running.Unlock()
+
+		result, err := j.run(context.Background(), true)
+		if err != nil {
+			log.Printf("Erro ao executar o scraper: %v", err)
+			return
+		}
+		if result.Sources > 0 {
+			log.Printf("Scraper: %d fontes, %d artigos encontrados, %d novas sugestões, %d repetidas", result.Sources, result.Found, result.Created, result.Marked)
+		}
+	}()
+	return nil
+}
+
+// sourceResult é o resultado da coleta de uma fonte por um dos workers
+type sourceResult struct {
+	source   models.ScraperSource
+	articles []ScrapedArticle
+	err      error
+}
+
+func (j *SuggestionJob) run(parent context.Context, force bool) (JobResult, error) {
+	var result JobResult
+	started := time.Now()
+
+	var sources []models.ScraperSource
+	if err := j.db.Where("enabled = ?", true).Order("id").Find(&sources).Error; err != nil {
+		return result, err
+	}
+	var due []models.ScraperSource
+	for _, source := range sources {
+		if force || SourceDue(source, started) {
+			due = append(due, source)
+		}
+	}
+	if len(due) == 0 {
+		return result, nil
+	}
+
+	// As fontes são baixadas em paralelo dentro do prazo da execução; a gravação fica
+	// nesta goroutine, uma fonte por vez
+	ctx, cancel := context.WithTimeout(parent, j.service.config.Deadline)
+	defer cancel()
+
+	pending := make(chan models.ScraperSource)
+	results := make(chan sourceResult)
+
+	var workers sync.WaitGroup
+	for i := 0; i < min(j.service.config.Workers, len(due)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for source := range pending {
+				articles, err := j.service.ScrapeSource(ctx, source)
+				results <- sourceResult{source: source, articles: articles, err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		for _, source := range due {
+			select {
+			case pending <- source:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	var saveErr error
+	for r := range results {
+		result.Sources++
+
+		// Uma coleta interrompida ainda grava os artigos extraídos até ali
+		var saved SaveResult
+		err := r.err
+		if len(r.articles) > 0 {
+			var dbErr error
+			saved, dbErr = SaveSuggestions(j.db, r.articles)
+			if dbErr != nil {
+				// Falha do banco, não da fonte: a execução termina com erro
+				if saveErr == nil {
+					saveErr = dbErr
+				}
+				if err == nil {
+					err = dbErr
+				}
+			}
+		}
+
+		lastError := ""
+		if err != nil {
+			result.Failed++
+			lastError = err.Error()
+			log.Printf("Erro ao coletar a fonte %s: %v", r.source.URL, err)
+		}
+		result.Found += len(r.articles)
+		result.Created += saved.Created
+		result.Marked += saved.Duplicates
+
+		// Continua lendo os resultados mesmo com erro, para não travar os workers
+		if err := j.db.Model(&r.source).Updates(map[string]interface{}{
+			"last_run_at": time.Now(),
+			"last_found":  len(r.articles),
+			"last_error":  lastError,
+		}).Error; err != nil && saveErr == nil {
+			saveErr = err
+		}
+	}
+
+	// Fontes que não começaram antes do prazo ficam para a próxima verificação
+	result.Skipped = len(due) - result.Sources
+	if result.Skipped > 0 {
+		log.Printf("Scraper: prazo de %s esgotado, %d fontes ficaram para a próxima execução", j.service.config.Deadline, result.Skipped)
+	}
+	result.Duplicates = result.Found - result.Created - result.Marked
+	result.DurationMS = time.Since(started).Milliseconds()
+
+	return result, saveErr
+}
+
+// SourceDue verifica se a agenda da fonte venceu
+func SourceDue(source models.ScraperSource, now time.Time) bool {
+	if source.LastRunAt == nil {
+		return true
+	}
+	return !now.Before(source.LastRunAt.Add(SourceSchedule(source)))
+}
+
+// SourceSchedule retorna o intervalo entre coletas da fonte
+func SourceSchedule(source models.ScraperSource) time.Duration {
+	schedule, err := time.ParseDuration(source.Schedule)
+	if err != nil || schedule <= 0 {
+		return DefaultSourceSchedule
+	}
+	return schedule
+}
+
+// SaveResult resume a gravação de um lote de sugestões
+type SaveResult struct {
+	Created    int // sugestões pendentes criadas
+	Duplicates int // sugestões gravadas como repetidas
+}
+
+// SaveSuggestions grava os artigos como sugestões pendentes, ignorando os que já foram
+// sugeridos antes (mesma URL canônica, ou mesmo título e site quando não há URL).
+// Artigos com a URL de um artigo publicado ou com conteúdo quase igual ao de outra
+// sugestão ou artigo são gravados como repetidos, fora da fila de moderação.
+func SaveSuggestions(db *gorm.DB, articles []ScrapedArticle) (SaveResult, error) {
+	var result SaveResult
+	seen := make(map[string]bool, len(articles))
+
+	index, err := loadDedupeIndex(db)
+	if err != nil {
+		return result, err
+	}
+
+	for _, article := range articles {
+		key := suggestionKey(article)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Sugestões rejeitadas ou removidas também contam, para não voltarem à fila
+		urls := []string{textutil.CanonicalURL(article.CanonicalURL), textutil.CanonicalURL(article.URL)}
+		if article.URL != "" {
+			if index.suggested(urls...) {
+				continue
+			}
+		} else {
+			var count int64
+			if err := db.Unscoped().Model(&models.ScrapedArticle{}).
+				Where("title = ? AND source = ?", article.Title, article.Source).Count(&count).Error; err != nil {
+				return result, err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+
+		suggestion := models.ScrapedArticle{
+			Title:        article.Title,
+			Excerpt:      article.Excerpt,
+			Content:      article.Content,
+			ImageURL:     article.ImageURL,
+			Author:       article.Author,
+			SourceURL:    article.URL,
+			CanonicalURL: article.CanonicalURL,
+			Source:       article.Source,
+			Category:     article.Category,
+			Tags:         strings.Join(article.Tags, ", "),
+			Suggested:    true,
+			Status:       StatusPending,
+		}
+		if !article.PublishedAt.IsZero() {
+			publishedAt := article.PublishedAt
+			suggestion.PublishedAt = &publishedAt
+		}
+		fingerprint := int64(textutil.SimHash(textutil.StripHTML(article.Content)))
+		if match, ok := index.duplicateOf(fingerprint, urls...); ok {
+			suggestion.Status = StatusDuplicate
+			suggestion.Suggested = false
+			suggestion.DuplicateOfID = match.suggestionID
+			suggestion.DuplicateOfArticleID = match.articleID
+		}
+		if err := db.Create(&suggestion).Error; err != nil {
+			return result, err
+		}
+		index.add(suggestion)
+
+		if suggestion.Status == StatusDuplicate {
+			result.Duplicates++
+		} else {
+			result.Created++
+		}
+	}
+
+	return result, nil
+}
+
+func suggestionKey(article ScrapedArticle) string {
+	if canonical := textutil.CanonicalURL(article.URL); canonical != "" {
+		return canonical
+	}
+	if article.URL != "" {
+		return article.URL
+	}
+	return article.Source + "|" + article.Title
+}
@@ -0,0 +1,98 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// hostLimiter limita as requisições simultâneas a cada site e garante um intervalo
+// mínimo entre elas
+type hostLimiter struct {
+	concurrency int
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+type hostSlot struct {
+	sem chan struct{}
+
+	mu   sync.Mutex
+	next time.Time // horário a partir do qual a próxima requisição pode sair
+}
+
+func newHostLimiter(concurrency int) *hostLimiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &hostLimiter{concurrency: concurrency, hosts: make(map[string]*hostSlot)}
+}
+
+func (l *hostLimiter) slot(host string) *hostSlot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{sem: make(chan struct{}, l.concurrency)}
+		l.hosts[host] = slot
+	}
+	return slot
+}
+
+// wait aguarda a vez de fazer uma requisição ao site, respeitando o limite de requisições
+// simultâneas e o intervalo delay desde a anterior. A função retornada libera a vaga.
+func (l *hostLimiter) wait(ctx context.Context, host string, delay time.Duration) (func(), error) {
+	slot := l.slot(host)
+
+	select {
+	case slot.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-slot.sem }
+
+	if err := sleepUntil(ctx, slot.reserve(delay)); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// pace aguarda apenas o intervalo delay desde a requisição anterior ao site, sem ocupar
+// uma vaga de concorrência. Serve aos redirecionamentos, cuja requisição original já
+// ocupa uma vaga.
+func (l *hostLimiter) pace(ctx context.Context, host string, delay time.Duration) error {
+	return sleepUntil(ctx, l.slot(host).reserve(delay))
+}
+
+// reserve reserva o próximo horário livre do site antes de dormir, para que requisições
+// simultâneas ao mesmo site fiquem espaçadas entre si
+func (s *hostSlot) reserve(delay time.Duration) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := s.next
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+	s.next = start.Add(delay)
+	return start
+}
+
+func sleepUntil(ctx context.Context, start time.Time) error {
+	pause := time.Until(start)
+	if pause <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(pause)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHostLimiterSpacing(t *testing.T) {
+	limiter := newHostLimiter(3)
+	const delay = 40 * time.Millisecond
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.wait(context.Background(), "a.com", delay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// A primeira sai na hora; as outras duas esperam um intervalo cada
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("3 requisições em %s, esperado ao menos %s", elapsed, 2*delay)
+	}
+
+	// Outro site não espera pelo intervalo do primeiro
+	start = time.Now()
+	release, err := limiter.wait(context.Background(), "b.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if elapsed := time.Since(start); elapsed > delay {
+		t.Errorf("primeira requisição a outro site esperou %s", elapsed)
+	}
+}
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	limiter := newHostLimiter(1)
+
+	release, err := limiter.wait(context.Background(), "a.com", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Com a única vaga ocupada, a próxima requisição espera até o prazo do contexto
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.wait(ctx, "a.com", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("wait com a vaga ocupada: erro = %v, esperado %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		release, err := limiter.wait(context.Background(), "a.com", 0)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	release()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a vaga liberada não foi aproveitada")
+	}
+}
+
+func TestHostLimiterCancelDuringPause(t *testing.T) {
+	limiter := newHostLimiter(2)
+
+	release, err := limiter.wait(context.Background(), "a.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := limiter.wait(ctx, "a.com", time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("erro = %v, esperado %v", err, context.Canceled)
+	}
+
+	// A vaga ocupada durante a pausa é devolvida ao cancelar
+	if n := len(limiter.slot("a.com").sem); n != 0 {
+		t.Errorf("vagas ocupadas = %d, esperado 0", n)
+	}
+}
+
+func TestHostLimiterPace(t *testing.T) {
+	limiter := newHostLimiter(1)
+	const delay = 40 * time.Millisecond
+
+	release, err := limiter.wait(context.Background(), "a.com", delay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// pace não disputa a vaga ocupada, mas respeita o intervalo
+	start := time.Now()
+	if err := limiter.pace(context.Background(), "a.com", delay); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < delay/2 {
+		t.Errorf("pace esperou %s, esperado cerca de %s", elapsed, delay)
+	}
+}
@@ -0,0 +1,362 @@
+package scraper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ryv-api/models"
+	"ryv-api/textutil"
+)
+
+// DefaultNewsCacheTTL é por quanto tempo o resultado de uma busca de notícias é reaproveitado
+const DefaultNewsCacheTTL = time.Hour
+
+const (
+	// newsAPIEndpoint é o endereço da busca do NewsAPI
+	newsAPIEndpoint = "https://newsapi.org/v2/everything"
+	// newsQuotaBackoff é por quanto tempo as buscas param depois de o limite do provedor ser atingido
+	newsQuotaBackoff = 15 * time.Minute
+	// maxNewsCacheEntries limita quantas buscas ficam guardadas
+	maxNewsCacheEntries = 200
+	// maxNewsResponseSize limita o tamanho da resposta lida do provedor
+	maxNewsResponseSize = 2 * 1024 * 1024
+)
+
+var (
+	// ErrNewsNotConfigured indica que nenhum provedor de notícias foi configurado
+	ErrNewsNotConfigured = errors.New("busca de notícias não configurada")
+	// ErrNewsQuota indica que o limite de requisições do provedor de notícias foi atingido
+	ErrNewsQuota = errors.New("limite de requisições do provedor de notícias atingido")
+)
+
+// NewsQuery descreve uma busca de notícias
+type NewsQuery struct {
+	Query    string // termos da busca
+	Language string // código do idioma, ex.: "pt"
+	Limit    int    // quantidade máxima de resultados
+}
+
+// NewsResult é uma notícia encontrada pelo provedor
+type NewsResult struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	ImageURL    string    `json:"image_url"`
+	Author      string    `json:"author"`
+	SourceName  string    `json:"source_name"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// NewsProvider busca notícias em um serviço externo
+type NewsProvider interface {
+	Name() string
+	Search(ctx context.Context, query NewsQuery) ([]NewsResult, error)
+}
+
+// NewsAPIError é uma resposta de erro do NewsAPI. Erros de limite de requisições
+// correspondem a ErrNewsQuota em errors.Is.
+type NewsAPIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *NewsAPIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("NewsAPI respondeu %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("NewsAPI respondeu %d", e.StatusCode)
+}
+
+func (e *NewsAPIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests,
+		e.Code == "rateLimited", e.Code == "apiKeyExhausted", e.Code == "maximumResultsReached":
+		return ErrNewsQuota
+	}
+	return nil
+}
+
+// NewsAPIProvider busca notícias no NewsAPI (newsapi.org)
+type NewsAPIProvider struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewNewsAPIProvider cria o provedor com a chave da API. endpoint é opcional e substitui
+// o endereço padrão (útil para proxies e testes).
+func NewNewsAPIProvider(apiKey, endpoint string) *NewsAPIProvider {
+	if endpoint == "" {
+		endpoint = newsAPIEndpoint
+	}
+	return &NewsAPIProvider{
+		apiKey:   apiKey,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *NewsAPIProvider) Name() string {
+	return "newsapi"
+}
+
+// Search busca as notícias mais relevantes para os termos no idioma pedido
+func (p *NewsAPIProvider) Search(ctx context.Context, query NewsQuery) ([]NewsResult, error) {
+	params := url.Values{}
+	params.Set("q", query.Query)
+	params.Set("sortBy", "relevancy")
+	if query.Language != "" {
+		params.Set("language", query.Language)
+	}
+	if query.Limit > 0 {
+		params.Set("pageSize", strconv.Itoa(min(query.Limit, 100)))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// A chave vai no cabeçalho para não aparecer em logs de URL
+	req.Header.Set("X-Api-Key", p.apiKey)
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxNewsResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var newsResponse struct {
+		Status   string `json:"status"`
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Articles []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			URL         string `json:"url"`
+			URLToImage  string `json:"urlToImage"`
+			Author      string `json:"author"`
+			PublishedAt string `json:"publishedAt"`
+			Source      struct {
+				Name string `json:"name"`
+			} `json:"source"`
+		} `json:"articles"`
+	}
+	decodeErr := json.Unmarshal(body, &newsResponse)
+
+	if resp.StatusCode != http.StatusOK || newsResponse.Status == "error" {
+		return nil, &NewsAPIError{StatusCode: resp.StatusCode, Code: newsResponse.Code, Message: newsResponse.Message}
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("resposta inválida do NewsAPI: %w", decodeErr)
+	}
+
+	results := make([]NewsResult, 0, len(newsResponse.Articles))
+	for _, article := range newsResponse.Articles {
+		// Notícias retiradas do ar vêm com o título "[Removed]"
+		if article.URL == "" || article.Title == "[Removed]" {
+			continue
+		}
+		result := NewsResult{
+			Title:       article.Title,
+			Description: article.Description,
+			URL:         article.URL,
+			ImageURL:    article.URLToImage,
+			Author:      article.Author,
+			SourceName:  article.Source.Name,
+		}
+		if publishedAt, ok := parseDate(article.PublishedAt); ok {
+			result.PublishedAt = publishedAt
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// newsCache guarda os resultados das buscas e a pausa após o limite do provedor ser atingido
+type newsCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	entries    map[string]newsCacheEntry
+	quotaUntil time.Time
+}
+
+type newsCacheEntry struct {
+	results []NewsResult
+	expires time.Time
+}
+
+func newNewsCache(ttl time.Duration) *newsCache {
+	if ttl <= 0 {
+		ttl = DefaultNewsCacheTTL
+	}
+	return &newsCache{ttl: ttl, entries: make(map[string]newsCacheEntry)}
+}
+
+func (c *newsCache) get(key string, now time.Time) ([]NewsResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		return nil, false
+	}
+	return entry.results, true
+}
+
+func (c *newsCache) put(key string, results []NewsResult, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxNewsCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	// Sem entradas vencidas para liberar, recomeça do zero
+	if len(c.entries) >= maxNewsCacheEntries {
+		c.entries = make(map[string]newsCacheEntry)
+	}
+	c.entries[key] = newsCacheEntry{results: results, expires: now.Add(c.ttl)}
+}
+
+func (c *newsCache) quotaExceeded(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Before(c.quotaUntil)
+}
+
+func (c *newsCache) pause(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.quotaUntil = now.Add(newsQuotaBackoff)
+}
+
+// SetNewsProvider define o provedor usado na busca de notícias relacionadas e por quanto
+// tempo os resultados ficam guardados
+func (s *ScraperService) SetNewsProvider(provider NewsProvider, cacheTTL time.Duration) {
+	s.news = provider
+	s.newsCache = newNewsCache(cacheTTL)
+}
+
+// SearchRelatedContent busca notícias no provedor configurado. Buscas repetidas dentro do
+// prazo do cache não consultam o provedor, e depois de o limite de requisições ser atingido
+// as buscas param por alguns minutos.
+func (s *ScraperService) SearchRelatedContent(ctx context.Context, query NewsQuery) ([]NewsResult, error) {
+	if s.news == nil {
+		return nil, ErrNewsNotConfigured
+	}
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, errors.New("termos da busca não informados")
+	}
+
+	now := time.Now()
+	key := fmt.Sprintf("%s|%s|%d|%s", s.news.Name(), query.Language, query.Limit, strings.ToLower(query.Query))
+	if results, ok := s.newsCache.get(key, now); ok {
+		return results, nil
+	}
+	if s.newsCache.quotaExceeded(now) {
+		return nil, ErrNewsQuota
+	}
+
+	results, err := s.news.Search(ctx, query)
+	if err != nil {
+		if errors.Is(err, ErrNewsQuota) {
+			s.newsCache.pause(now)
+		}
+		return nil, err
+	}
+	s.newsCache.put(key, results, now)
+	return results, nil
+}
+
+// maxQueryTerms limita quantas palavras do título entram na busca de notícias relacionadas
+const maxQueryTerms = 5
+
+// RelatedNewsQuery monta os termos da busca a partir das palavras relevantes do título do
+// artigo (sem stopwords), unidas por OR para o provedor ordenar por relevância
+func RelatedNewsQuery(article models.Article) string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range textutil.Words(strings.ToLower(article.Title)) {
+		folded := textutil.FoldAccents(word)
+		if utf8.RuneCountInString(word) < 3 || textutil.IsStopword(folded) || seen[folded] {
+			continue
+		}
+		seen[folded] = true
+		terms = append(terms, word)
+		if len(terms) == maxQueryTerms {
+			break
+		}
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// RelatedNews busca notícias relacionadas ao artigo e extrai cada uma da página de origem,
+// como se fossem itens de uma fonte do scraper. Notícias já sugeridas são puladas.
+// Retorna quantas notícias o provedor encontrou e os artigos extraídos.
+func (s *ScraperService) RelatedNews(ctx context.Context, article models.Article, query NewsQuery) (int, []ScrapedArticle, error) {
+	results, err := s.SearchRelatedContent(ctx, query)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	items := make([]feedItem, 0, len(results))
+	byURL := make(map[string]NewsResult, len(results))
+	for _, result := range results {
+		items = append(items, feedItem{
+			Title:       result.Title,
+			URL:         result.URL,
+			Summary:     result.Description,
+			ImageURL:    result.ImageURL,
+			PublishedAt: result.PublishedAt,
+		})
+		byURL[result.URL] = result
+	}
+	if items, err = s.withoutKnownItems(items); err != nil {
+		return len(results), nil, err
+	}
+
+	// A categoria vem do artigo de referência
+	source := models.ScraperSource{Category: article.Category, Type: SourceTypeFeed}
+	articles := make([]ScrapedArticle, 0, len(items))
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return len(results), articles, err
+		}
+		scraped := s.followItem(ctx, item, source, nil)
+		result := byURL[item.URL]
+		scraped.Source = result.SourceName
+		if scraped.Author == "" {
+			scraped.Author = result.Author
+		}
+		if scraped.Source == "" {
+			if parsed, err := url.Parse(item.URL); err == nil {
+				scraped.Source = parsed.Hostname()
+			}
+		}
+		if scraped.Title != "" && scraped.Content != "" {
+			articles = append(articles, scraped)
+		}
+	}
+	return len(results), articles, nil
+}
@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewsAPIProviderSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Api-Key"); got != "chave" {
+			t.Errorf("X-Api-Key = %q, esperado chave", got)
+		}
+		query := r.URL.Query()
+		if query.Get("apiKey") != "" {
+			t.Error("chave enviada na URL")
+		}
+		for param, want := range map[string]string{"q": "óculos OR lentes", "language": "pt", "pageSize": "100", "sortBy": "relevancy"} {
+			if got := query.Get(param); got != want {
+				t.Errorf("%s = %q, esperado %q", param, got, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","totalResults":3,"articles":[
+			{"source":{"id":null,"name":"Jornal"},"author":"Ana","title":"Óculos de sol","description":"Resumo",
+			 "url":"https://jornal.example/oculos","urlToImage":"https://jornal.example/a.jpg","publishedAt":"2024-05-01T10:00:00Z"},
+			{"source":{"name":"Removido"},"title":"[Removed]","url":"https://removed.com"},
+			{"source":{"name":"Sem link"},"title":"Sem URL","url":""},
+			{"source":{"name":"Revista"},"title":"Lentes","url":"https://revista.example/lentes","publishedAt":"data inválida"}
+		]}`))
+	}))
+	defer server.Close()
+
+	provider := NewNewsAPIProvider("chave", server.URL)
+	results, err := provider.Search(context.Background(), NewsQuery{Query: "óculos OR lentes", Language: "pt", Limit: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("resultados = %d, esperado 2", len(results))
+	}
+
+	want := NewsResult{
+		Title:       "Óculos de sol",
+		Description: "Resumo",
+		URL:         "https://jornal.example/oculos",
+		ImageURL:    "https://jornal.example/a.jpg",
+		Author:      "Ana",
+		SourceName:  "Jornal",
+		PublishedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if got := results[0]; got.Title != want.Title || got.Description != want.Description || got.URL != want.URL ||
+		got.ImageURL != want.ImageURL || got.Author != want.Author || got.SourceName != want.SourceName || !got.PublishedAt.Equal(want.PublishedAt) {
+		t.Errorf("resultado = %+v, esperado %+v", got, want)
+	}
+	if !results[1].PublishedAt.IsZero() {
+		t.Errorf("data inválida = %s, esperado vazia", results[1].PublishedAt)
+	}
+}
+
+func TestNewsAPIProviderErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		quota     bool
+		apiError  bool
+		errorCode string
+	}{
+		{
+			name:      "limite de requisições",
+			status:    http.StatusTooManyRequests,
+			body:      `{"status":"error","code":"rateLimited","message":"You have made too many requests"}`,
+			quota:     true,
+			apiError:  true,
+			errorCode: "rateLimited",
+		},
+		{
+			name:      "cota diária com status 200",
+			status:    http.StatusOK,
+			body:      `{"status":"error","code":"apiKeyExhausted","message":"Your API key has no more requests available"}`,
+			quota:     true,
+			apiError:  true,
+			errorCode: "apiKeyExhausted",
+		},
+		{
+			name:      "chave inválida",
+			status:    http.StatusUnauthorized,
+			body:      `{"status":"error","code":"apiKeyInvalid","message":"Your API key is invalid"}`,
+			apiError:  true,
+			errorCode: "apiKeyInvalid",
+		},
+		{
+			name:     "erro do servidor sem JSON",
+			status:   http.StatusBadGateway,
+			body:     `<html>Bad Gateway</html>`,
+			apiError: true,
+		},
+		{
+			name:   "resposta malformada",
+			status: http.StatusOK,
+			body:   `{"status":"ok","articles":[{"title":`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewNewsAPIProvider("chave", server.URL).Search(context.Background(), NewsQuery{Query: "óculos"})
+			if err == nil {
+				t.Fatal("sem erro, esperado erro")
+			}
+			if got := errors.Is(err, ErrNewsQuota); got != tt.quota {
+				t.Errorf("errors.Is(ErrNewsQuota) = %v, esperado %v (%v)", got, tt.quota, err)
+			}
+			var apiErr *NewsAPIError
+			if got := errors.As(err, &apiErr); got != tt.apiError {
+				t.Fatalf("NewsAPIError = %v, esperado %v (%v)", got, tt.apiError, err)
+			}
+			if apiErr != nil && (apiErr.StatusCode != tt.status || apiErr.Code != tt.errorCode) {
+				t.Errorf("erro = %d %q, esperado %d %q", apiErr.StatusCode, apiErr.Code, tt.status, tt.errorCode)
+			}
+			if !tt.apiError && !strings.Contains(err.Error(), "resposta inválida") {
+				t.Errorf("erro = %v, esperado resposta inválida", err)
+			}
+		})
+	}
+}
+
+func TestSearchRelatedContentQuotaPause(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status":"error","code":"rateLimited","message":"Too many requests"}`))
+	}))
+	defer server.Close()
+
+	s := NewScraperService(nil, Config{HostDelay: time.Millisecond})
+	s.SetNewsProvider(NewNewsAPIProvider("chave", server.URL), time.Hour)
+
+	// Depois do limite, as buscas seguintes nem consultam o provedor
+	for _, query := range []string{"óculos", "lentes"} {
+		if _, err := s.SearchRelatedContent(context.Background(), NewsQuery{Query: query}); !errors.Is(err, ErrNewsQuota) {
+			t.Errorf("busca %q: erro = %v, esperado ErrNewsQuota", query, err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requisições = %d, esperado 1", got)
+	}
+}
+
+func TestSearchRelatedContentCache(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"status":"ok","articles":[{"title":"Óculos","url":"https://jornal.example/oculos"}]}`))
+	}))
+	defer server.Close()
+
+	s := NewScraperService(nil, Config{HostDelay: time.Millisecond})
+	if _, err := s.SearchRelatedContent(context.Background(), NewsQuery{Query: "óculos"}); !errors.Is(err, ErrNewsNotConfigured) {
+		t.Errorf("sem provedor: erro = %v, esperado ErrNewsNotConfigured", err)
+	}
+	s.SetNewsProvider(NewNewsAPIProvider("chave", server.URL), time.Hour)
+
+	for _, query := range []string{"Óculos", " óculos "} {
+		results, err := s.SearchRelatedContent(context.Background(), NewsQuery{Query: query, Language: "pt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Errorf("resultados = %d, esperado 1", len(results))
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requisições = %d, esperado 1 (a segunda busca vem do cache)", got)
+	}
+}
@@ -0,0 +1,252 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// robotsTTL é por quanto tempo o robots.txt de um site fica em memória
+	robotsTTL = 24 * time.Hour
+	// robotsErrorTTL é o tempo até tentar de novo quando o robots.txt não pôde ser lido
+	robotsErrorTTL = time.Hour
+	// maxRobotsSize limita o tamanho do robots.txt lido (o RFC 9309 exige ao menos 500 KiB)
+	maxRobotsSize = 512 * 1024
+	// MaxCrawlDelay limita o Crawl-delay respeitado, para um site não travar a coleta
+	MaxCrawlDelay = time.Minute
+)
+
+// robotsRule é uma linha Allow ou Disallow do robots.txt
+type robotsRule struct {
+	pattern string
+	allow   bool
+	match   *regexp.Regexp
+}
+
+// robotsRules são as regras do robots.txt que valem para o nosso User-Agent
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+	expires    time.Time
+}
+
+// allowAllRobots vale quando o site não tem robots.txt (ou responde 4xx)
+func allowAllRobots() robotsRules {
+	return robotsRules{}
+}
+
+// disallowAllRobots vale quando o robots.txt está inacessível (5xx ou falha de rede):
+// na dúvida, o site não é coletado até a próxima tentativa
+func disallowAllRobots() robotsRules {
+	return robotsRules{rules: []robotsRule{newRobotsRule("/", false)}}
+}
+
+func newRobotsRule(pattern string, allow bool) robotsRule {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	if strings.HasSuffix(expr, `\$`) {
+		expr = strings.TrimSuffix(expr, `\$`) + "$"
+	}
+	return robotsRule{pattern: pattern, allow: allow, match: regexp.MustCompile("^" + expr)}
+}
+
+// Allowed verifica se o caminho (com a query string) pode ser coletado. Vale a regra
+// mais específica (padrão mais longo); em caso de empate, Allow vence.
+func (r robotsRules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	allowed := true
+	longest := -1
+	for _, rule := range r.rules {
+		if !rule.match.MatchString(path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			longest = len(rule.pattern)
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// parseRobots lê o robots.txt e retorna as regras do grupo do agente informado ou,
+// se não houver, do grupo "*". Grupos repetidos para o mesmo agente são combinados.
+func parseRobots(body io.Reader, agent string) robotsRules {
+	agent = strings.ToLower(agent)
+
+	var specific, wildcard robotsRules
+	var hasSpecific bool
+	var groupAgents []string
+	inRules := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// Um User-agent depois de regras começa um novo grupo
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+			continue
+		}
+		if len(groupAgents) == 0 {
+			continue
+		}
+		inRules = true
+
+		var targets []*robotsRules
+		for _, groupAgent := range groupAgents {
+			switch {
+			case groupAgent == "*":
+				targets = append(targets, &wildcard)
+			case groupAgent != "" && strings.Contains(agent, groupAgent):
+				targets = append(targets, &specific)
+				hasSpecific = true
+			}
+		}
+
+		for _, target := range targets {
+			switch key {
+			case "allow", "disallow":
+				// Disallow vazio não bloqueia nada
+				if value != "" {
+					target.rules = append(target.rules, newRobotsRule(value, key == "allow"))
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					target.crawlDelay = time.Duration(seconds * float64(time.Second))
+				}
+			}
+		}
+	}
+
+	if hasSpecific {
+		return specific
+	}
+	return wildcard
+}
+
+// robotsCache guarda em memória as regras do robots.txt de cada site
+type robotsCache struct {
+	mu    sync.Mutex
+	sites map[string]*robotsEntry
+}
+
+// robotsEntry guarda as regras de um site; o mutex evita que workers simultâneos
+// baixem o mesmo robots.txt
+type robotsEntry struct {
+	mu    sync.Mutex
+	rules robotsRules
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{sites: make(map[string]*robotsEntry)}
+}
+
+func (c *robotsCache) entry(site string) *robotsEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.sites[site]
+	if !ok {
+		entry = &robotsEntry{}
+		c.sites[site] = entry
+	}
+	return entry
+}
+
+// robotsAgent é o nome do robô usado para escolher o grupo do robots.txt
+// (o produto do User-Agent, ex.: "RYVBot" em "RYVBot/1.0 (+https://...)")
+func robotsAgent(userAgent string) string {
+	agent, _, _ := strings.Cut(userAgent, "/")
+	agent, _, _ = strings.Cut(agent, " ")
+	return agent
+}
+
+// robots retorna as regras do robots.txt do site da URL, baixando-o se necessário.
+// Retorna erro apenas se a coleta for cancelada antes de ler o robots.txt.
+func (s *ScraperService) robots(ctx context.Context, pageURL *url.URL) (robotsRules, error) {
+	site := pageURL.Scheme + "://" + pageURL.Host
+
+	entry := s.robotsCache.entry(site)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if time.Now().Before(entry.rules.expires) {
+		return entry.rules, nil
+	}
+
+	rules := s.fetchRobots(ctx, pageURL.Host, site)
+	// Uma coleta cancelada não diz nada sobre o site
+	if err := ctx.Err(); err != nil {
+		return rules, err
+	}
+
+	entry.rules = rules
+	return rules, nil
+}
+
+// robotsRequestKey marca no contexto a requisição que baixa o robots.txt
+type robotsRequestKey struct{}
+
+func isRobotsRequest(ctx context.Context) bool {
+	marked, _ := ctx.Value(robotsRequestKey{}).(bool)
+	return marked
+}
+
+func (s *ScraperService) fetchRobots(ctx context.Context, host, site string) robotsRules {
+	failed := disallowAllRobots()
+	failed.expires = time.Now().Add(robotsErrorTTL)
+
+	release, err := s.acquire(ctx, host, s.config.HostDelay)
+	if err != nil {
+		return failed
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(context.WithValue(ctx, robotsRequestKey{}, true), http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return failed
+	}
+	req.Header.Set("User-Agent", s.config.UserAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return failed
+	}
+	defer resp.Body.Close()
+
+	var rules robotsRules
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		rules = parseRobots(io.LimitReader(resp.Body, maxRobotsSize), robotsAgent(s.config.UserAgent))
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		rules = allowAllRobots()
+	default:
+		return failed
+	}
+	rules.expires = time.Now().Add(robotsTTL)
+	return rules
+}
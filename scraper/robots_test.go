@@ -0,0 +1,274 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.pdf$
+Disallow: /search?
+Allow: /search?q=oculos
+Disallow: /tmp/
+Allow: /tmp/
+`), "ryvbot")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"", true},
+		{"/artigos/oculos", true},
+		// Prefixo: /admin bloqueia tudo que começa com /admin
+		{"/admin", false},
+		{"/admin/users", false},
+		{"/administracao", false},
+		// A regra mais longa vence
+		{"/admin/public", true},
+		{"/admin/public/logo.png", true},
+		// $ ancora o fim; * casa qualquer sequência
+		{"/docs/guia.pdf", false},
+		{"/docs/guia.pdf?download=1", true},
+		{"/docs/guia.pdfx", true},
+		// A query string faz parte do caminho
+		{"/search", true},
+		{"/search?q=lentes", false},
+		{"/search?q=oculos", true},
+		// Empate entre Allow e Disallow: Allow vence
+		{"/tmp/arquivo", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, esperado %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRobotsWildcardInMiddle(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: *\nDisallow: /*/print\nDisallow: /fotos/*.jpg$\n"), "ryvbot")
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/artigo/print", false},
+		{"/2024/artigo/print?page=2", false},
+		{"/print", true},
+		{"/fotos/a/b.jpg", false},
+		{"/fotos/a.jpg.html", true},
+	}
+	for _, tt := range tests {
+		if got := rules.Allowed(tt.path); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, esperado %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		robots     string
+		path       string
+		want       bool
+		crawlDelay time.Duration
+	}{
+		{
+			name:   "grupo específico substitui o grupo *",
+			robots: "User-agent: *\nDisallow: /\n\nUser-agent: RYVBot\nDisallow: /privado\n",
+			path:   "/artigos",
+			want:   true,
+		},
+		{
+			name:   "agente comparado sem diferenciar maiúsculas",
+			robots: "User-agent: ryvbot\nDisallow: /\n",
+			path:   "/artigos",
+			want:   false,
+		},
+		{
+			name:   "grupo de outro robô é ignorado",
+			robots: "User-agent: Googlebot\nDisallow: /\n",
+			path:   "/artigos",
+			want:   true,
+		},
+		{
+			name:   "vários User-agent no mesmo grupo",
+			robots: "User-agent: Googlebot\nUser-agent: RYVBot\nDisallow: /artigos\n",
+			path:   "/artigos",
+			want:   false,
+		},
+		{
+			name:   "grupos repetidos do mesmo agente são combinados",
+			robots: "User-agent: RYVBot\nDisallow: /a\n\nUser-agent: Googlebot\nDisallow: /\n\nUser-agent: RYVBot\nDisallow: /artigos\n",
+			path:   "/artigos",
+			want:   false,
+		},
+		{
+			name:   "Disallow vazio não bloqueia nada",
+			robots: "User-agent: *\nDisallow:\n",
+			path:   "/artigos",
+			want:   true,
+		},
+		{
+			name:   "comentários e linhas inválidas",
+			robots: "# robots\nUser-agent: * # todos\nDisallow: /artigos # bloqueado\nlinha sem dois pontos\n",
+			path:   "/artigos",
+			want:   false,
+		},
+		{
+			name:   "regras antes de qualquer User-agent são ignoradas",
+			robots: "Disallow: /\nUser-agent: *\nDisallow: /privado\n",
+			path:   "/artigos",
+			want:   true,
+		},
+		{
+			name:       "Crawl-delay do grupo",
+			robots:     "User-agent: *\nCrawl-delay: 1.5\n",
+			path:       "/",
+			want:       true,
+			crawlDelay: 1500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(tt.robots), robotsAgent(DefaultUserAgent))
+			if got := rules.Allowed(tt.path); got != tt.want {
+				t.Errorf("Allowed(%q) = %v, esperado %v", tt.path, got, tt.want)
+			}
+			if rules.crawlDelay != tt.crawlDelay {
+				t.Errorf("crawlDelay = %s, esperado %s", rules.crawlDelay, tt.crawlDelay)
+			}
+		})
+	}
+}
+
+func TestRobotsAgent(t *testing.T) {
+	tests := map[string]string{
+		DefaultUserAgent:       "RYVBot",
+		"RYVBot":               "RYVBot",
+		"MeuRobo (+https://x)": "MeuRobo",
+	}
+	for userAgent, want := range tests {
+		if got := robotsAgent(userAgent); got != want {
+			t.Errorf("robotsAgent(%q) = %q, esperado %q", userAgent, got, want)
+		}
+	}
+}
+
+func TestFetchRobotsStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusOK, "User-agent: *\nDisallow: /artigos\n", false},
+		// Sem robots.txt, tudo é permitido
+		{http.StatusNotFound, "", true},
+		// Robots.txt inacessível: nada é coletado até a próxima tentativa
+		{http.StatusServiceUnavailable, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/robots.txt" {
+					t.Errorf("caminho = %s, esperado /robots.txt", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			s := NewScraperService(nil, Config{HostDelay: time.Millisecond})
+			pageURL, _ := url.Parse(server.URL + "/artigos")
+			rules, err := s.robots(context.Background(), pageURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rules.Allowed("/artigos"); got != tt.want {
+				t.Errorf("Allowed = %v, esperado %v", got, tt.want)
+			}
+			if !rules.expires.After(time.Now()) {
+				t.Error("regras sem validade no cache")
+			}
+		})
+	}
+}
+
+func TestFetchPageRedirectDisallowed(t *testing.T) {
+	var blockedHits int
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /privado\n"))
+		default:
+			blockedHits++
+			w.Write([]byte("<html><body>privado</body></html>"))
+		}
+	}))
+	defer blocked.Close()
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			http.Redirect(w, r, blocked.URL+"/privado", http.StatusFound)
+		}
+	}))
+	defer origin.Close()
+
+	s := NewScraperService(nil, Config{HostDelay: time.Millisecond})
+	_, _, err := s.fetchPage(context.Background(), origin.URL+"/artigo")
+	if !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("erro = %v, esperado %v", err, ErrDisallowedByRobots)
+	}
+	if blockedHits != 0 {
+		t.Errorf("página bloqueada foi baixada %d vezes", blockedHits)
+	}
+}
+
+func TestFetchPageRedirectSameHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.Redirect(w, r, "/robots-novo.txt", http.StatusMovedPermanently)
+		case "/robots-novo.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /privado\n"))
+		case "/velho":
+			http.Redirect(w, r, "/novo", http.StatusMovedPermanently)
+		case "/novo":
+			w.Write([]byte("<html><body>novo</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Com uma vaga por site, o redirecionamento não pode esperar pela vaga da própria requisição
+	s := NewScraperService(nil, Config{HostDelay: time.Millisecond, HostConcurrency: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	body, finalURL, err := s.fetchPage(ctx, server.URL+"/velho")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalURL.Path != "/novo" || !strings.Contains(string(body), "novo") {
+		t.Errorf("página final = %s, esperado /novo", finalURL)
+	}
+
+	// As regras vieram do robots.txt redirecionado
+	if _, _, err := s.fetchPage(ctx, server.URL+"/privado"); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("erro = %v, esperado %v", err, ErrDisallowedByRobots)
+	}
+}
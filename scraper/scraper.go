@@ -1,218 +1,237 @@
 package scraper
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"ryv-api/classifier"
+	"ryv-api/models"
+	"ryv-api/translate"
+
 	"github.com/PuerkitoBio/goquery"
+	"gorm.io/gorm"
 )
 
 type ScrapedArticle struct {
-	Title       string    `json:"title"`
-	Content     string    `json:"content"`
-	Excerpt     string    `json:"excerpt"`
-	URL         string    `json:"url"`
-	Source      string    `json:"source"`
-	PublishedAt time.Time `json:"published_at"`
-	Category    string    `json:"category"`
-	Tags        []string  `json:"tags"`
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Excerpt      string    `json:"excerpt"`
+	URL          string    `json:"url"`
+	CanonicalURL string    `json:"canonical_url"` // link canônico declarado pela página do artigo
+	ImageURL     string    `json:"image_url"`
+	Author       string    `json:"author"`
+	Source       string    `json:"source"`
+	PublishedAt  time.Time `json:"published_at"`
+	Category     string    `json:"category"`
+	Tags         []string  `json:"tags"`
 }
 
 type ScraperService struct {
+	db     *gorm.DB
 	client *http.Client
+	config Config
+
+	limiter     *hostLimiter
+	robotsCache *robotsCache
+
+	news       NewsProvider
+	newsCache  *newsCache
+	classifier *classifier.Classifier
+	translator translate.Translator
 }
 
-func NewScraperService() *ScraperService {
-	return &ScraperService{
+// NewScraperService cria o serviço de coleta. Com db, as páginas baixadas são guardadas
+// para as requisições condicionais (ETag/If-Modified-Since) das próximas coletas.
+func NewScraperService(db *gorm.DB, config Config) *ScraperService {
+	config = config.withDefaults()
+	s := &ScraperService{
+		db: db,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		config:      config,
+		limiter:     newHostLimiter(config.HostConcurrency),
+		robotsCache: newRobotsCache(),
+		newsCache:   newNewsCache(DefaultNewsCacheTTL),
 	}
+	s.client.CheckRedirect = s.checkRedirect
+	return s
 }
 
-// Sites para scraping (focados em saúde mental, óptica e bem-estar)
-var targetSites = []struct {
-	URL      string
-	Selector string
-	Category string
-}{
-	{
-		URL:      "https://www.psychologytoday.com/us/blog",
-		Selector: ".blog-post",
-		Category: "Saúde Mental",
-	},
-	{
-		URL:      "https://www.verywellmind.com",
-		Selector: ".article-card",
-		Category: "Saúde Mental",
-	},
-	{
-		URL:      "https://www.allaboutvision.com",
-		Selector: ".article",
-		Category: "Ótica",
-	},
-	{
-		URL:      "https://www.aoa.org/news",
-		Selector: ".news-item",
-		Category: "Optometria",
-	},
-	{
-		URL:      "https://www.healthline.com/health/mental-health",
-		Selector: ".article-card",
-		Category: "Saúde Mental",
-	},
+// Seletores usados quando a fonte não define um seletor para o campo
+const (
+	defaultTitleSelector = "h1, h2, h3, .title, .headline"
+	defaultBodySelector  = "p, .content, .body"
+	defaultLinkSelector  = "a"
+)
+
+// dateLayouts são os formatos de data aceitos no atributo datetime ou no texto do item,
+// nos feeds (RFC 1123 no RSS, RFC 3339 no Atom e no JSON Feed) e nos sitemaps (W3C)
+var dateLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02/01/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
 }
 
-func (s *ScraperService) ScrapeArticles() ([]ScrapedArticle, error) {
-	var articles []ScrapedArticle
+// ScrapeSource coleta os artigos de uma fonte conforme o tipo: itens de uma página HTML
+// pelos seletores configurados ou itens de um feed ou sitemap, seguindo o link de cada um.
+// Se a coleta for interrompida, retorna os artigos extraídos até ali junto com o erro.
+func (s *ScraperService) ScrapeSource(ctx context.Context, source models.ScraperSource) ([]ScrapedArticle, error) {
+	_, collected, err := s.collect(ctx, source, MaxFeedItems, true)
 
-	for _, site := range targetSites {
-		siteArticles, err := s.scrapeSite(site.URL, site.Selector, site.Category)
-		if err != nil {
-			fmt.Printf("Erro ao fazer scraping de %s: %v\n", site.URL, err)
-			continue
+	var articles []ScrapedArticle
+	for _, article := range collected {
+		if article.Title != "" && article.Content != "" {
+			articles = append(articles, article)
 		}
-		articles = append(articles, siteArticles...)
 	}
 
-	return articles, nil
+	return articles, err
 }
 
-func (s *ScraperService) scrapeSite(url, selector, category string) ([]ScrapedArticle, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+// collect lê a listagem da fonte e segue o link de cada item para extrair o artigo
+// completo. Retorna quantos itens a listagem tem e os artigos extraídos, inclusive os
+// incompletos. limit limita quantos links são seguidos e skipKnown pula os que já foram
+// sugeridos.
+func (s *ScraperService) collect(ctx context.Context, source models.ScraperSource, limit int, skipKnown bool) (int, []ScrapedArticle, error) {
+	var items []feedItem
+	var listURL *url.URL
+	var err error
+
+	switch source.Type {
+	case SourceTypeFeed, SourceTypeSitemap:
+		items, listURL, err = s.readFeed(ctx, source)
+	default:
+		var doc *goquery.Document
+		doc, listURL, err = s.fetchDocument(ctx, source.URL)
+		if err == nil {
+			doc.Find(source.ItemSelector).Each(func(i int, selection *goquery.Selection) {
+				items = append(items, extractItem(selection, source, listURL))
+			})
+		}
 	}
-
-	// Headers para simular um navegador real
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-	req.Header.Set("Connection", "keep-alive")
-
-	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
-	defer resp.Body.Close()
+	found := len(items)
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, err
+	// Os mais recentes primeiro; itens sem data ficam por último, na ordem da listagem
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].PublishedAt.After(items[j].PublishedAt)
+	})
+	if skipKnown {
+		if items, err = s.withoutKnownItems(items); err != nil {
+			return found, nil, err
+		}
+	}
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
 	}
 
-	var articles []ScrapedArticle
-
-	doc.Find(selector).Each(func(i int, selection *goquery.Selection) {
-		article := s.extractArticle(selection, category, url)
-		if article.Title != "" && article.Content != "" {
-			articles = append(articles, article)
+	articles := make([]ScrapedArticle, 0, len(items))
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return found, articles, err
 		}
-	})
+		articles = append(articles, s.followItem(ctx, item, source, listURL))
+	}
 
-	return articles, nil
+	return found, articles, nil
 }
 
-func (s *ScraperService) extractArticle(selection *goquery.Selection, category, baseURL string) ScrapedArticle {
-	article := ScrapedArticle{
-		Category: category,
-		Source:   baseURL,
-	}
+// extractItem lê um card da listagem HTML com os seletores da fonte
+func extractItem(selection *goquery.Selection, source models.ScraperSource, pageURL *url.URL) feedItem {
+	var item feedItem
 
 	// Extrair título
-	title := selection.Find("h1, h2, h3, .title, .headline").First().Text()
-	article.Title = strings.TrimSpace(title)
-
-	// Extrair conteúdo
-	content := selection.Find("p, .content, .body").Text()
-	article.Content = strings.TrimSpace(content)
-
-	// Extrair excerpt (primeiros 200 caracteres)
-	if len(article.Content) > 200 {
-		article.Excerpt = article.Content[:200] + "..."
-	} else {
-		article.Excerpt = article.Content
+	item.Title = selection.Find(selectorOr(source.TitleSelector, defaultTitleSelector)).First().Text()
+
+	// Extrair resumo do card; o conteúdo completo vem da página do artigo
+	item.Summary = selection.Find(selectorOr(source.BodySelector, defaultBodySelector)).Text()
+
+	// Extrair URL; o próprio item pode ser o link
+	link := selection.Find(selectorOr(source.LinkSelector, defaultLinkSelector)).First()
+	if goquery.NodeName(selection) == "a" && source.LinkSelector == "" {
+		link = selection
+	}
+	if href, exists := link.Attr("href"); exists {
+		item.URL = resolveURL(pageURL, href)
 	}
 
-	// Extrair URL
-	if href, exists := selection.Find("a").First().Attr("href"); exists {
-		if strings.HasPrefix(href, "http") {
-			article.URL = href
-		} else {
-			article.URL = baseURL + href
+	// Extrair imagem (src ou, em páginas com lazy loading, data-src)
+	if source.ImageSelector != "" {
+		image := selection.Find(source.ImageSelector).First()
+		for _, attr := range []string{"src", "data-src", "content"} {
+			if src, exists := image.Attr(attr); exists && strings.TrimSpace(src) != "" {
+				item.ImageURL = resolveURL(pageURL, src)
+				break
+			}
 		}
 	}
 
 	// Extrair tags
-	tags := selection.Find(".tags, .categories, .keywords").Text()
-	if tags != "" {
-		article.Tags = strings.Split(tags, ",")
-		for i, tag := range article.Tags {
-			article.Tags[i] = strings.TrimSpace(tag)
-		}
+	if source.TagsSelector != "" {
+		item.Tags = cleanTags(strings.Split(selection.Find(source.TagsSelector).Text(), ","))
 	}
 
-	// Definir data de publicação (padrão para agora)
-	article.PublishedAt = time.Now()
+	// Data de publicação; sem data no card, vale a da página do artigo
+	if source.DateSelector != "" {
+		date := selection.Find(source.DateSelector).First()
+		value, exists := date.Attr("datetime")
+		if !exists {
+			value = date.Text()
+		}
+		item.PublishedAt, _ = parseDate(value)
+	}
 
-	return article
+	return item
 }
 
-// Função para buscar artigos relacionados usando APIs externas
-func (s *ScraperService) SearchRelatedContent(query string) ([]ScrapedArticle, error) {
-	// Exemplo usando NewsAPI (você precisará de uma API key)
-	apiKey := "YOUR_NEWS_API_KEY" // Substitua pela sua chave
-	url := fmt.Sprintf("https://newsapi.org/v2/everything?q=%s&language=pt&sortBy=publishedAt&apiKey=%s", 
-		query, apiKey)
-
-	resp, err := s.client.Get(url)
-	if err != nil {
-		return nil, err
+func selectorOr(selector, fallback string) string {
+	if strings.TrimSpace(selector) == "" {
+		return fallback
 	}
-	defer resp.Body.Close()
+	return selector
+}
 
-	body, err := io.ReadAll(resp.Body)
+// resolveURL converte links relativos em absolutos a partir da URL da página
+func resolveURL(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
 	if err != nil {
-		return nil, err
+		return ""
 	}
-
-	var newsResponse struct {
-		Articles []struct {
-			Title       string    `json:"title"`
-			Description string    `json:"description"`
-			URL         string    `json:"url"`
-			PublishedAt time.Time `json:"publishedAt"`
-			Source      struct {
-				Name string `json:"name"`
-			} `json:"source"`
-		} `json:"articles"`
-	}
-
-	if err := json.Unmarshal(body, &newsResponse); err != nil {
-		return nil, err
+	if base == nil {
+		return ref.String()
 	}
+	return base.ResolveReference(ref).String()
+}
 
-	var articles []ScrapedArticle
-	for _, newsArticle := range newsResponse.Articles {
-		article := ScrapedArticle{
-			Title:       newsArticle.Title,
-			Content:     newsArticle.Description,
-			Excerpt:     newsArticle.Description,
-			URL:         newsArticle.URL,
-			Source:      newsArticle.Source.Name,
-			PublishedAt: newsArticle.PublishedAt,
-			Category:    "Notícias Relacionadas",
+func parseDate(value string) (time.Time, bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
 		}
-		articles = append(articles, article)
 	}
-
-	return articles, nil
+	return time.Time{}, false
 }
 
 // Função para limpar e processar conteúdo
@@ -235,44 +254,16 @@ func (s *ScraperService) ProcessContent(content string) string {
 	return content
 }
 
-// Função para categorizar automaticamente o conteúdo
+// CategorizeContent escolhe a categoria do conteúdo com o classificador treinado nos
+// artigos, ou pelas palavras-chave de cada categoria se não houver classificador
 func (s *ScraperService) CategorizeContent(title, content string) string {
-	text := strings.ToLower(title + " " + content)
-
-	keywords := map[string][]string{
-		"Saúde Mental": {
-			"ansiedade", "depressão", "estresse", "bem-estar", "psicologia",
-			"terapia", "meditação", "mindfulness", "saúde mental", "emocional",
-		},
-		"Ótica": {
-			"óculos", "lentes", "visão", "olhos", "óptica", "armação",
-			"proteção uv", "óculos de sol", "lentes progressivas",
-		},
-		"Optometria": {
-			"optometria", "exame ocular", "oftalmologia", "presbiopia",
-			"miopia", "astigmatismo", "catarata", "glaucoma",
-		},
-		"Dicas de Saúde": {
-			"saúde", "bem-estar", "qualidade de vida", "hábitos saudáveis",
-			"prevenção", "cuidados", "dicas", "conselhos",
-		},
-	}
-
-	maxScore := 0
-	bestCategory := "Dicas de Saúde"
-
-	for category, words := range keywords {
-		score := 0
-		for _, word := range words {
-			if strings.Contains(text, word) {
-				score++
-			}
-		}
-		if score > maxScore {
-			maxScore = score
-			bestCategory = category
-		}
+	if s.classifier != nil {
+		return s.classifier.Categorize(title, content)
 	}
+	return classifier.KeywordCategory(title, content)
+}
 
-	return bestCategory
-} 
\ No newline at end of file
+// SetClassifier define o classificador usado para categorizar artigos de fontes sem categoria
+func (s *ScraperService) SetClassifier(c *classifier.Classifier) {
+	s.classifier = c
+}
@@ -0,0 +1,156 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"ryv-api/models"
+
+	"github.com/andybalholm/cascadia"
+)
+
+// MaxTestArticles limita quantos artigos o teste de seletores retorna
+const MaxTestArticles = 10
+
+// Tipos de fonte do scraper
+const (
+	SourceTypeHTML    = "html"    // página com itens extraídos por seletores CSS
+	SourceTypeFeed    = "feed"    // RSS 2.0, RSS 1.0, Atom ou JSON Feed
+	SourceTypeSitemap = "sitemap" // sitemap.xml ou índice de sitemaps
+)
+
+// ValidSourceType verifica se o tipo de fonte é suportado
+func ValidSourceType(sourceType string) bool {
+	switch sourceType {
+	case SourceTypeHTML, SourceTypeFeed, SourceTypeSitemap:
+		return true
+	}
+	return false
+}
+
+// SourceTest é o resultado do teste dos seletores de uma fonte
+type SourceTest struct {
+	URL       string           `json:"url"`
+	Matched   int              `json:"matched"`   // itens encontrados pelo seletor de item
+	Extracted int              `json:"extracted"` // itens com título e conteúdo, que virariam sugestões
+	Articles  []ScrapedArticle `json:"articles"`
+	Warnings  []string         `json:"warnings"`
+}
+
+// ValidateSource verifica o tipo, a URL, os seletores e a agenda de uma fonte antes de gravá-la
+func ValidateSource(source models.ScraperSource) error {
+	if err := ValidateURL(source.URL); err != nil {
+		return err
+	}
+
+	if source.Type != "" && !ValidSourceType(source.Type) {
+		return errors.New("tipo de fonte inválido, use html, feed ou sitemap")
+	}
+	if (source.Type == "" || source.Type == SourceTypeHTML) && strings.TrimSpace(source.ItemSelector) == "" {
+		return errors.New("seletor de item é obrigatório em fontes html")
+	}
+	selectors := []struct {
+		name, value string
+	}{
+		{"item_selector", source.ItemSelector},
+		{"title_selector", source.TitleSelector},
+		{"body_selector", source.BodySelector},
+		{"link_selector", source.LinkSelector},
+		{"date_selector", source.DateSelector},
+		{"image_selector", source.ImageSelector},
+		{"tags_selector", source.TagsSelector},
+	}
+	for _, selector := range selectors {
+		if err := ValidateSelector(selector.name, selector.value); err != nil {
+			return err
+		}
+	}
+
+	if source.Schedule != "" {
+		schedule, err := time.ParseDuration(source.Schedule)
+		if err != nil || schedule < time.Minute {
+			return errors.New("agenda inválida, use uma duração de pelo menos 1m (ex.: 30m, 6h)")
+		}
+	}
+
+	return nil
+}
+
+// ValidateSelector verifica se o seletor CSS é válido; seletores vazios são aceitos
+func ValidateSelector(name, selector string) error {
+	if strings.TrimSpace(selector) == "" {
+		return nil
+	}
+	if _, err := cascadia.ParseGroup(selector); err != nil {
+		return fmt.Errorf("%s inválido: %v", name, err)
+	}
+	return nil
+}
+
+// ValidateURL verifica se o endereço é uma URL http(s) absoluta
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("URL inválida, use http:// ou https://")
+	}
+	return nil
+}
+
+// TestSource baixa a página da fonte e retorna o que seria extraído, sem gravar nada.
+// Em feeds e sitemaps, apenas os primeiros MaxTestArticles links são seguidos.
+func (s *ScraperService) TestSource(ctx context.Context, source models.ScraperSource) (SourceTest, error) {
+	test := SourceTest{URL: source.URL, Articles: []ScrapedArticle{}, Warnings: []string{}}
+
+	matched, articles, err := s.collect(ctx, source, MaxTestArticles, false)
+	if err != nil {
+		return test, err
+	}
+	test.Matched = matched
+
+	missing := map[string]int{}
+	for _, article := range articles {
+		if article.Title == "" {
+			missing["título"]++
+		}
+		if article.Content == "" {
+			missing["conteúdo"]++
+		}
+		if article.URL == "" {
+			missing["link"]++
+		}
+		if (source.ImageSelector != "" || source.Type == SourceTypeFeed) && article.ImageURL == "" {
+			missing["imagem"]++
+		}
+		if article.PublishedAt.IsZero() {
+			missing["data"]++
+		}
+		if article.Title == "" || article.Content == "" {
+			continue
+		}
+
+		test.Extracted++
+		if len(test.Articles) < MaxTestArticles {
+			test.Articles = append(test.Articles, article)
+		}
+	}
+
+	switch {
+	case matched == 0 && (source.Type == SourceTypeFeed || source.Type == SourceTypeSitemap):
+		test.Warnings = append(test.Warnings, "nenhum item encontrado")
+	case matched == 0:
+		test.Warnings = append(test.Warnings, "o seletor de item não encontrou nenhum elemento na página")
+	case len(articles) < matched:
+		test.Warnings = append(test.Warnings, fmt.Sprintf("apenas os %d itens mais recentes de %d foram baixados no teste", len(articles), matched))
+	}
+	for _, field := range []string{"título", "conteúdo", "link", "imagem", "data"} {
+		if count := missing[field]; count > 0 {
+			test.Warnings = append(test.Warnings, fmt.Sprintf("%d de %d itens sem %s", count, len(articles), field))
+		}
+	}
+
+	return test, nil
+}
@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"context"
+	"strings"
+
+	"ryv-api/models"
+	"ryv-api/textutil"
+	"ryv-api/translate"
+)
+
+// Translation é uma sugestão traduzida para o idioma do blog
+type Translation struct {
+	Title          string
+	Excerpt        string
+	Content        string // HTML com a mesma estrutura do original
+	SourceLanguage string
+	Provider       string
+}
+
+// SetTranslator define o provedor usado para traduzir as sugestões aprovadas
+func (s *ScraperService) SetTranslator(t translate.Translator) {
+	s.translator = t
+}
+
+// SuggestionLanguage retorna o idioma da sugestão, detectando-o se ainda não foi gravado.
+// Sugestões sem idioma claro são tratadas como inglês, o idioma dos sites monitorados.
+func SuggestionLanguage(suggestion models.ScrapedArticle) string {
+	language := suggestion.Language
+	if language == "" {
+		language = textutil.DetectLanguage(suggestion.Title + "\n" + suggestion.Excerpt + "\n" + textutil.StripHTML(suggestion.Content))
+	}
+	if language == "" {
+		language = translate.SourceEnglish
+	}
+	return language
+}
+
+// NeedsTranslation indica se a sugestão não está em português
+func NeedsTranslation(suggestion models.ScrapedArticle) bool {
+	return !strings.HasPrefix(SuggestionLanguage(suggestion), "pt")
+}
+
+// TranslateSuggestion traduz título, resumo e conteúdo (já em HTML) da sugestão para
+// pt-BR, mantendo a estrutura do HTML
+func (s *ScraperService) TranslateSuggestion(ctx context.Context, suggestion models.ScrapedArticle, content string) (Translation, error) {
+	if s.translator == nil {
+		return Translation{}, translate.ErrNotConfigured
+	}
+
+	source := SuggestionLanguage(suggestion)
+	texts, err := translate.Texts(ctx, s.translator, []string{suggestion.Title, suggestion.Excerpt}, source, translate.TargetLanguage)
+	if err != nil {
+		return Translation{}, err
+	}
+	translatedContent, err := translate.HTML(ctx, s.translator, content, source, translate.TargetLanguage)
+	if err != nil {
+		return Translation{}, err
+	}
+
+	return Translation{
+		Title:          texts[0],
+		Excerpt:        texts[1],
+		Content:        translatedContent,
+		SourceLanguage: source,
+		Provider:       s.translator.Name(),
+	}, nil
+}
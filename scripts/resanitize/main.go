@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"ryv-api/database"
+	"ryv-api/models"
+	"ryv-api/sanitize"
+	"ryv-api/textutil"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Aplica a política de sanitização ao conteúdo dos artigos já gravados (inclusive os
+// excluídos), para artigos criados antes da política ou depois de uma mudança nela.
+// Só os artigos cujo HTML muda são atualizados.
+//
+//	go run ./scripts/resanitize -dry-run
+func main() {
+	dryRun := flag.Bool("dry-run", false, "só lista os artigos que seriam alterados")
+	batchSize := flag.Int("batch", 200, "artigos lidos por lote")
+	flag.Parse()
+
+	database.InitDatabase()
+	db := database.DB
+	db.Logger = logger.Default.LogMode(logger.Silent)
+
+	policy := sanitize.NewPolicy(sanitize.ParseHosts(os.Getenv("EMBED_HOSTS")))
+
+	var checked, changed int
+	var articles []models.Article
+	err := db.Unscoped().Select("id, title, content, original_content").
+		FindInBatches(&articles, *batchSize, func(tx *gorm.DB, batch int) error {
+			for _, article := range articles {
+				checked++
+				content, report := policy.Sanitize(article.Content)
+				original, originalReport := policy.Sanitize(article.OriginalContent)
+				report.Merge(originalReport)
+				if content == article.Content && original == article.OriginalContent {
+					continue
+				}
+
+				changed++
+				summary := report.Summary()
+				if report.Empty() {
+					// Nada retirado, mas a marcação gravada difere da gerada pela política
+					summary = "HTML normalizado"
+				}
+				fmt.Printf("#%d %s\n   %s\n", article.ID, article.Title, summary)
+				if *dryRun {
+					continue
+				}
+
+				// UpdateColumns não passa pelo BeforeSave, então os campos derivados do
+				// conteúdo são recalculados aqui
+				wordCount := textutil.WordCount(content)
+				if err := db.Unscoped().Model(&models.Article{}).Where("id = ?", article.ID).UpdateColumns(map[string]interface{}{
+					"content":          content,
+					"original_content": original,
+					"word_count":       wordCount,
+					"reading_minutes":  textutil.ReadingMinutes(wordCount),
+					"fingerprint":      int64(textutil.SimHash(textutil.StripHTML(content))),
+				}).Error; err != nil {
+					return fmt.Errorf("artigo %d: %w", article.ID, err)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		log.Fatalf("Erro ao sanitizar artigos: %v", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("\n🔎 %d artigos verificados, %d seriam alterados\n", checked, changed)
+		return
+	}
+	fmt.Printf("\n✅ %d artigos verificados, %d sanitizados\n", checked, changed)
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		err  bool
+	}{
+		{"2026/10/a.jpg", "2026/10/a.jpg", false},
+		{"a.jpg", "a.jpg", false},
+		{"a..b.jpg", "a..b.jpg", false},
+		{"", "", true},
+		{".", "", true},
+		{"..", "", true},
+		{"../a.jpg", "", true},
+		{"2026/../../a.jpg", "", true},
+		{"2026/../a.jpg", "", true},
+		{"./a.jpg", "", true},
+		{"2026//a.jpg", "", true},
+		{"2026/10/", "", true},
+		{"/etc/passwd", "", true},
+		{`..\a.jpg`, "", true},
+		{`2026\a.jpg`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := cleanKey(tt.key)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("cleanKey(%q) = %q, %v, esperado %v", tt.key, got, err, ErrInvalidKey)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("cleanKey(%q) = %q, %v, esperado %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	l, err := NewLocal(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if err := l.Put(ctx, "2026/10/a.jpg", strings.NewReader("imagem"), 6, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := l.Open(ctx, "2026/10/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	reader.Close()
+	if string(body) != "imagem" {
+		t.Errorf("Open = %q, esperado %q", body, "imagem")
+	}
+	if got := l.URL("2026/10/a.jpg"); got != "/uploads/2026/10/a.jpg" {
+		t.Errorf("URL = %s, esperado /uploads/2026/10/a.jpg", got)
+	}
+
+	if err := l.Delete(ctx, "2026/10/a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Open(ctx, "2026/10/a.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open após Delete: erro = %v, esperado %v", err, ErrNotFound)
+	}
+	if err := l.Delete(ctx, "2026/10/a.jpg"); err != nil {
+		t.Errorf("Delete de arquivo inexistente: %v", err)
+	}
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLocal(filepath.Join(root, "uploads"), "/uploads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(root, "segredo.txt")
+	if err := os.WriteFile(secret, []byte("senha"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, key := range []string{"../segredo.txt", "a/../../segredo.txt", secret} {
+		if _, err := l.Open(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Open(%q): erro = %v, esperado %v", key, err, ErrInvalidKey)
+		}
+		if err := l.Put(ctx, key, strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q): erro = %v, esperado %v", key, err, ErrInvalidKey)
+		}
+		if err := l.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q): erro = %v, esperado %v", key, err, ErrInvalidKey)
+		}
+	}
+	if content, err := os.ReadFile(secret); err != nil || string(content) != "senha" {
+		t.Errorf("arquivo fora do diretório alterado: %q, %v", content, err)
+	}
+}
@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// s3UnsignedPayload dispensa o hash do corpo na assinatura, para enviar o arquivo sem
+	// lê-lo duas vezes (aceito pelo S3 e pelos compatíveis, como MinIO e R2)
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// maxS3ErrorSize limita o tamanho da mensagem de erro lida do provedor
+	maxS3ErrorSize = 64 * 1024
+)
+
+// S3Config configura um armazenamento compatível com S3
+type S3Config struct {
+	Bucket    string
+	Region    string // padrão us-east-1
+	Endpoint  string // ex.: https://s3.sa-east-1.amazonaws.com ou http://localhost:9000 (MinIO)
+	AccessKey string
+	SecretKey string
+	PublicURL string // endereço público dos arquivos (CDN); padrão: o endereço do objeto no bucket
+	PathStyle bool   // bucket no caminho (endpoint/bucket/chave) em vez do subdomínio
+}
+
+// S3Error é uma resposta de erro do armazenamento S3
+type S3Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *S3Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("S3 respondeu %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("S3 respondeu %d", e.StatusCode)
+}
+
+// S3 guarda os arquivos em um bucket S3 ou compatível, com requisições assinadas (SigV4)
+type S3 struct {
+	config S3Config
+	base   *url.URL
+	client *http.Client
+}
+
+// NewS3 cria o armazenamento S3. Sem Endpoint, usa o endereço da AWS na região.
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, errors.New("S3 precisa de bucket, access key e secret key")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("endpoint do S3 inválido: %s", config.Endpoint)
+	}
+
+	base := *endpoint
+	if config.PathStyle {
+		base.Path += "/" + config.Bucket
+	} else {
+		base.Host = config.Bucket + "." + base.Host
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = base.String()
+	}
+	return &S3{config: config, base: &base, client: &http.Client{Timeout: 5 * time.Minute}}, nil
+}
+
+func (s *S3) Name() string {
+	return "s3"
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := s.request(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3) URL(key string) string {
+	return joinURL(s.config.PublicURL, escapeKey(key))
+}
+
+// request monta a requisição para o objeto da chave
+func (s *S3) request(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	object := *s.base
+	object.Path += "/" + key
+	object.RawPath = s.base.EscapedPath() + "/" + escapeKey(key)
+	return http.NewRequestWithContext(ctx, method, object.String(), body)
+}
+
+// do assina e envia a requisição. Respostas de erro viram S3Error (404 vira ErrNotFound).
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxS3ErrorSize))
+	return nil, &S3Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+}
+
+// sign acrescenta a assinatura AWS Signature Version 4 à requisição. São assinados o host,
+// o Content-Type, o Range e os headers x-amz-*; sem X-Amz-Content-Sha256, o corpo não
+// entra na assinatura.
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = s3UnsignedPayload
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.Join(values, ",")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// escapeKey codifica cada parte da chave como o S3 espera (RFC 3986), mantendo as barras
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound indica que o arquivo não existe no armazenamento
+var ErrNotFound = errors.New("arquivo não encontrado no armazenamento")
+
+// ErrInvalidKey indica um caminho de arquivo inválido (vazio, absoluto ou com "..")
+var ErrInvalidKey = errors.New("caminho de arquivo inválido")
+
+// Storage guarda os arquivos da biblioteca de mídia. As chaves são caminhos relativos com
+// "/" (ex.: "2026/10/a1b2c3.jpg").
+type Storage interface {
+	// Name identifica o armazenamento (local ou s3), gravado junto com cada arquivo
+	Name() string
+	// Put grava o arquivo, substituindo o que já existir na chave
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Open abre o arquivo para leitura; retorna ErrNotFound se não existir
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete apaga o arquivo; apagar um arquivo que não existe não é erro
+	Delete(ctx context.Context, key string) error
+	// URL é o endereço público do arquivo
+	URL(key string) string
+}
+
+// cleanKey valida a chave e a normaliza
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// joinURL junta o endereço base e a chave com uma única barra
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
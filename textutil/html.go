@@ -0,0 +1,53 @@
+package textutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockTags são elementos cujo conteúdo deve ser separado do texto vizinho
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "section": true, "article": true, "tr": true, "td": true,
+	"th": true, "figcaption": true, "pre": true, "hr": true,
+}
+
+// StripHTML remove as tags HTML, decodifica entidades e normaliza os espaços,
+// descartando o conteúdo de <script> e <style>
+func StripHTML(content string) string {
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+	skipDepth := 0
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// io.EOF indica o fim do documento; HTML malformado é tratado da mesma forma
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.TextToken:
+			if skipDepth == 0 {
+				b.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "script" || tag == "style" {
+				skipDepth++
+			}
+			if blockTags[tag] {
+				b.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if (tag == "script" || tag == "style") && skipDepth > 0 {
+				skipDepth--
+			}
+			if blockTags[tag] {
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
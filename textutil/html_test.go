@@ -0,0 +1,25 @@
+package textutil
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"texto puro", "  cuidado   com os\nolhos ", "cuidado com os olhos"},
+		{"tags inline", "<p>Use <strong>óculos</strong> de <a href=\"/sol\">sol</a></p>", "Use óculos de sol"},
+		{"blocos separados", "<h2>Visão</h2><p>Saúde</p><ul><li>um</li><li>dois</li></ul>", "Visão Saúde um dois"},
+		{"quebra de linha", "linha<br>outra", "linha outra"},
+		{"script e style", "<p>antes</p><script>alert('x')</script><style>p{}</style><p>depois</p>", "antes depois"},
+		{"entidades", "<p>Olhos &amp; lentes &lt;3&gt; &eacute; &#233; &quot;ok&quot;</p>", `Olhos & lentes <3> é é "ok"`},
+		{"nbsp vira espaço", "um&nbsp;dois", "um dois"},
+		{"html malformado", "<p>sem fechar <b>negrito", "sem fechar negrito"},
+	}
+	for _, tt := range tests {
+		if got := StripHTML(tt.content); got != tt.want {
+			t.Errorf("%s: StripHTML = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package textutil
+
+import "strings"
+
+// minLanguageHits é o mínimo de palavras frequentes encontradas para arriscar um idioma
+const minLanguageHits = 5
+
+// Palavras muito frequentes e exclusivas de cada idioma (comparadas sem acentos)
+var (
+	englishWords = wordSet("the", "and", "of", "is", "are", "was", "were", "with", "that", "this",
+		"for", "you", "your", "it", "from", "have", "has", "can", "will", "not", "be", "by", "or",
+		"which", "they", "their", "what", "how", "when", "more", "about")
+	portugueseWords = wordSet("o", "e", "de", "que", "nao", "uma", "um", "para", "com", "os", "as", "do",
+		"da", "dos", "das", "em", "no", "na", "se", "por", "mais", "como", "ao", "ou", "sao",
+		"tambem", "pode", "seu", "sua", "quando", "muito", "isso")
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
+
+// DetectLanguage identifica se o texto está em inglês ("en") ou português ("pt") pela
+// frequência de palavras comuns de cada idioma. Retorna "" quando o texto é curto demais
+// ou não há um idioma claramente predominante.
+func DetectLanguage(text string) string {
+	english, portuguese := 0, 0
+	for _, word := range Words(FoldAccents(strings.ToLower(text))) {
+		if englishWords[word] {
+			english++
+		}
+		if portugueseWords[word] {
+			portuguese++
+		}
+	}
+
+	switch {
+	case english+portuguese < minLanguageHits:
+		return ""
+	case english >= 2*portuguese:
+		return "en"
+	case portuguese >= 2*english:
+		return "pt"
+	}
+	return ""
+}
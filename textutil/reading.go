@@ -0,0 +1,31 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordsPerMinute é a velocidade média de leitura usada para estimar o tempo de leitura
+const WordsPerMinute = 200
+
+// WordCount conta as palavras do conteúdo HTML, ignorando tags, scripts e estilos.
+// Palavras com hífen ("bem-estar") contam como uma; pontuação solta não conta.
+func WordCount(content string) int {
+	count := 0
+	for _, field := range strings.Fields(StripHTML(content)) {
+		if strings.IndexFunc(field, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ReadingMinutes estima o tempo de leitura em minutos, arredondando para cima
+func ReadingMinutes(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
@@ -0,0 +1,43 @@
+package textutil
+
+import "testing"
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{"vazio", "", 0},
+		{"acentos", "<p>Atenção à visão e à saúde dos olhos</p>", 8},
+		{"hífen conta como uma", "<p>O bem-estar começa pelo pós-operatório</p>", 5},
+		{"pontuação solta", "<p>Olhos — e lentes ; ok ?</p>", 4},
+		{"números", "<p>A regra 20-20-20 ajuda</p>", 4},
+		{"blocos separados", "<h2>Título</h2><p>Texto</p><ul><li>um</li><li>dois</li></ul>", 4},
+		{"ignora script e style", `<p>Uma frase</p><script>var x = "não conta";</script><style>p { color: red }</style>`, 2},
+		{"entidades", "<p>Olhos&nbsp;secos &amp; cansados</p>", 3},
+	}
+	for _, tt := range tests {
+		if got := WordCount(tt.content); got != tt.want {
+			t.Errorf("%s: WordCount = %d, esperado %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words, want int
+	}{
+		{0, 0},
+		{-5, 0},
+		{1, 1},
+		{WordsPerMinute, 1},
+		{WordsPerMinute + 1, 2},
+		{WordsPerMinute * 5, 5},
+	}
+	for _, tt := range tests {
+		if got := ReadingMinutes(tt.words); got != tt.want {
+			t.Errorf("ReadingMinutes(%d) = %d, esperado %d", tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package textutil
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+const (
+	// shingleSize é o número de palavras de cada trecho usado na impressão digital
+	shingleSize = 3
+	// minSimHashWords é o mínimo de palavras para a impressão digital ser confiável
+	minSimHashWords = 20
+)
+
+// SimHash calcula a impressão digital de 64 bits do texto a partir de trechos de três
+// palavras. Textos quase iguais (o mesmo artigo republicado com pequenas edições) têm
+// impressões que diferem em poucos bits. Textos curtos demais retornam 0.
+func SimHash(text string) uint64 {
+	words := Words(FoldAccents(strings.ToLower(text)))
+	if len(words) < minSimHashWords {
+		return 0
+	}
+
+	var weights [64]int
+	for i := 0; i+shingleSize <= len(words); i++ {
+		hash := fnv.New64a()
+		hash.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		sum := hash.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// HammingDistance conta os bits diferentes entre duas impressões digitais
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,70 @@
+package textutil
+
+import (
+	"strings"
+	"testing"
+)
+
+const simHashText = `O uso prolongado de telas exige pausas regulares para descansar os olhos e evitar a
+fadiga visual, segundo oftalmologistas ouvidos pela reportagem. A regra 20-20-20 sugere olhar
+para algo a seis metros de distância por vinte segundos a cada vinte minutos. Piscar com
+frequência mantém a lubrificação da córnea, e a iluminação do ambiente deve ser parecida com
+o brilho do monitor. Quem usa óculos deve manter a receita atualizada com consultas anuais.`
+
+func TestSimHashNearDuplicates(t *testing.T) {
+	original := SimHash(simHashText)
+	if original == 0 {
+		t.Fatal("SimHash de um texto longo retornou 0")
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		maxDist int
+	}{
+		{"idêntico", simHashText, 0},
+		{"maiúsculas, acentos e pontuação", strings.ToUpper(FoldAccents(strings.NewReplacer(",", "", ".", " ;").Replace(simHashText))), 0},
+		{"uma palavra trocada", strings.Replace(simHashText, "anuais", "semestrais", 1), 6},
+	}
+	for _, tt := range tests {
+		if distance := HammingDistance(original, SimHash(tt.text)); distance > tt.maxDist {
+			t.Errorf("%s: distância %d, esperado no máximo %d", tt.name, distance, tt.maxDist)
+		}
+	}
+}
+
+func TestSimHashUnrelated(t *testing.T) {
+	other := `A alimentação equilibrada com frutas, verduras e legumes fornece vitaminas importantes
+para o organismo e ajuda a prevenir doenças crônicas ao longo da vida adulta, afirmam
+nutricionistas. Beber água ao longo do dia e reduzir o consumo de ultraprocessados também
+faz diferença, assim como manter horários regulares para as refeições principais.`
+
+	if distance := HammingDistance(SimHash(simHashText), SimHash(other)); distance < 16 {
+		t.Errorf("textos sem relação com distância %d, esperado ao menos 16", distance)
+	}
+}
+
+func TestSimHashShortText(t *testing.T) {
+	for _, text := range []string{"", "Texto curto demais para comparar", strings.Repeat("palavra ", minSimHashWords-1)} {
+		if got := SimHash(text); got != 0 {
+			t.Errorf("SimHash(%q) = %d, esperado 0", text, got)
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xFF, 0x0F, 4},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%x, %x) = %d, esperado %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
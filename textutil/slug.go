@@ -0,0 +1,30 @@
+package textutil
+
+import "strings"
+
+// maxSlugLength limita o tamanho do slug gerado a partir de títulos longos
+const maxSlugLength = 80
+
+// Slugify converte um título em slug: minúsculas, sem acentos e com hífens no lugar de
+// espaços e pontuação ("Visão & Saúde!" -> "visao-saude")
+func Slugify(text string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range FoldAccents(strings.ToLower(text)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug
+}
@@ -0,0 +1,162 @@
+package textutil
+
+import "strings"
+
+// Stemmer leve para português, inspirado no RSLP (Orengo & Huyck).
+// Trabalha sobre palavras minúsculas e sem acentos; o objetivo é agrupar
+// variações como "óculos/ocular", "visão/visões" e "cuidar/cuidados",
+// não produzir radicais linguisticamente perfeitos.
+
+type stemRule struct {
+	suffix      string
+	minStem     int // tamanho mínimo do radical que sobra
+	replacement string
+}
+
+var (
+	pluralRules = []stemRule{
+		{"ns", 1, "m"},
+		{"oes", 3, "ao"},
+		{"aes", 1, "ao"},
+		{"ais", 1, "al"},
+		{"eis", 2, "el"},
+		{"ois", 1, "ol"},
+		{"les", 3, "l"},
+		{"res", 3, "r"},
+		{"s", 2, ""},
+	}
+
+	feminineRules = []stemRule{
+		{"eira", 3, "eiro"},
+		{"ica", 3, "ico"},
+		{"osa", 3, "oso"},
+		{"iva", 3, "ivo"},
+		{"ada", 2, "ado"},
+		{"ida", 3, "ido"},
+		{"ora", 3, "or"},
+		{"ona", 3, "ao"},
+	}
+
+	degreeRules = []stemRule{
+		{"issimo", 3, ""},
+		{"issima", 3, ""},
+		{"zinho", 2, ""},
+		{"zinha", 2, ""},
+		{"inho", 3, ""},
+		{"inha", 3, ""},
+	}
+
+	nounRules = []stemRule{
+		{"amentos", 3, ""},
+		{"imentos", 3, ""},
+		{"amento", 3, ""},
+		{"imento", 3, ""},
+		{"mento", 4, ""},
+		{"acoes", 3, ""},
+		{"icoes", 3, ""},
+		{"acao", 3, ""},
+		{"icao", 3, ""},
+		{"cao", 3, ""},
+		{"idade", 4, ""},
+		{"ancia", 3, ""},
+		{"encia", 3, ""},
+		{"ismo", 3, ""},
+		{"ista", 3, ""},
+		{"avel", 2, ""},
+		{"ivel", 3, ""},
+		{"ante", 3, ""},
+		{"eza", 3, ""},
+		{"oso", 3, ""},
+		{"ico", 3, ""},
+		{"ivo", 3, ""},
+		{"eiro", 3, ""},
+		{"ario", 3, ""},
+		{"ar", 3, ""},
+	}
+
+	verbRules = []stemRule{
+		{"ariam", 2, ""},
+		{"eriam", 2, ""},
+		{"iriam", 3, ""},
+		{"assem", 2, ""},
+		{"essem", 2, ""},
+		{"issem", 3, ""},
+		{"aram", 2, ""},
+		{"eram", 3, ""},
+		{"iram", 3, ""},
+		{"avam", 2, ""},
+		{"arem", 2, ""},
+		{"erem", 2, ""},
+		{"irem", 3, ""},
+		{"ando", 2, ""},
+		{"endo", 3, ""},
+		{"indo", 3, ""},
+		{"ados", 2, ""},
+		{"idos", 3, ""},
+		{"ado", 2, ""},
+		{"ido", 3, ""},
+		{"ava", 2, ""},
+		{"ar", 2, ""},
+		{"er", 2, ""},
+		{"ir", 3, ""},
+		{"ou", 3, ""},
+		{"am", 2, ""},
+		{"em", 2, ""},
+	}
+
+	vowelRules = []stemRule{
+		{"a", 3, ""},
+		{"e", 3, ""},
+		{"o", 3, ""},
+	}
+)
+
+// pluralExceptions são palavras terminadas em "s" que não estão no plural
+var pluralExceptions = map[string]bool{
+	"lapis": true, "onibus": true, "virus": true, "atraves": true,
+	"cais": true, "gas": true, "pais": true, "mes": true, "tres": true, "simples": true,
+}
+
+// Stem reduz uma palavra (minúscula e sem acentos) ao seu radical aproximado
+func Stem(word string) string {
+	if len(word) < minTokenLength {
+		return word
+	}
+
+	if strings.HasSuffix(word, "s") && !pluralExceptions[word] && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") {
+		word, _ = applyRules(word, pluralRules)
+	}
+	if strings.HasSuffix(word, "a") {
+		word, _ = applyRules(word, feminineRules)
+	}
+	if strings.HasSuffix(word, "mente") && len(word)-len("mente") >= 4 {
+		word = strings.TrimSuffix(word, "mente")
+	}
+	word, _ = applyRules(word, degreeRules)
+
+	var changed bool
+	word, changed = applyRules(word, nounRules)
+	if !changed {
+		word, changed = applyRules(word, verbRules)
+	}
+	if !changed {
+		word, _ = applyRules(word, vowelRules)
+	}
+
+	return word
+}
+
+// applyRules aplica a primeira regra cujo sufixo casa e respeita o tamanho mínimo do radical
+func applyRules(word string, rules []stemRule) (string, bool) {
+	for _, rule := range rules {
+		if !strings.HasSuffix(word, rule.suffix) {
+			continue
+		}
+		stem := word[:len(word)-len(rule.suffix)]
+		if len(stem) < rule.minStem {
+			continue
+		}
+		return stem + rule.replacement, true
+	}
+	return word, false
+}
@@ -0,0 +1,83 @@
+package textutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStemGroupsVariations(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"oculos", "ocular"},
+		{"visao", "visoes"},
+		{"cuidar", "cuidados"},
+		{"cuidado", "cuidados"},
+		{"lente", "lentes"},
+		{"olho", "olhinho"},
+		{"olho", "olhos"},
+		{"cansada", "cansado"},
+		{"animal", "animais"},
+		{"correcao", "correcoes"},
+		{"oftalmologista", "oftalmologistas"},
+	}
+	for _, tt := range tests {
+		if a, b := Stem(tt.a), Stem(tt.b); a != b {
+			t.Errorf("Stem(%q) = %q e Stem(%q) = %q, esperado o mesmo radical", tt.a, a, tt.b, b)
+		}
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Palavras curtas não são alteradas
+		{"de", "de"},
+		{"mar", "mar"},
+		// Terminadas em "s" sem estar no plural
+		{"lapis", "lapis"},
+		{"onibus", "onibus"},
+		{"pais", "pais"},
+		{"virus", "virus"},
+		// Plurais irregulares
+		{"animais", "animal"},
+		{"visoes", "visa"},
+		// Advérbios em -mente
+		{"rapidamente", "rapid"},
+		// Sufixos de substantivos
+		{"degeneracao", "degener"},
+		{"saudavel", "saud"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, esperado %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Os óculos de grau e as visões: cuidados com a saúde dos olhos em 2024!", []string{"ocul", "grau", "visa", "cuid", "saud", "olh"}},
+		{"Saúde OCULAR", []string{"saud", "ocul"}},
+		{"a e o de 123", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, esperado %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStopwordsAreFolded(t *testing.T) {
+	for word := range stopwords {
+		if folded := FoldAccents(word); folded != word {
+			t.Errorf("stopword %q tem acento; use %q", word, folded)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package textutil
+
+// stopwords em português, já sem acentos (comparadas após FoldAccents)
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, word := range []string{
+		"a", "ao", "aos", "aquela", "aquelas", "aquele", "aqueles", "aquilo", "as", "ate", "com", "como",
+		"da", "das", "de", "dela", "delas", "dele", "deles", "depois", "do", "dos", "e", "ela", "elas",
+		"ele", "eles", "em", "entre", "era", "eram", "essa", "essas", "esse", "esses", "esta", "estao",
+		"estas", "estava", "estavam", "este", "estes", "estou", "eu", "foi", "fomos", "for", "foram",
+		"fosse", "fossem", "fui", "ha", "isso", "isto", "ja", "lhe", "lhes", "mais", "mas", "me", "mesmo",
+		"meu", "meus", "minha", "minhas", "muito", "muita", "muitos", "muitas", "na", "nao", "nas", "nem",
+		"no", "nos", "nossa", "nossas", "nosso", "nossos", "num", "numa", "o", "os", "ou", "para", "pela",
+		"pelas", "pelo", "pelos", "por", "qual", "quando", "que", "quem", "se", "sem", "ser", "sera",
+		"seu", "seus", "so", "sua", "suas", "tambem", "te", "tem", "tinha", "tinham", "toda", "todas",
+		"todo", "todos", "tu", "tua", "tuas", "teu", "teus", "um", "uma", "umas", "uns", "voce", "voces",
+		"vos", "sao", "seja", "sejam", "pode", "podem", "deve", "devem", "ter", "sobre", "cada", "onde",
+		"ainda", "assim", "bem", "apenas", "outro", "outra", "outros", "outras", "porque", "pois",
+		"tanto", "tao", "estar", "sendo", "sido", "fazer", "faz", "vez", "vezes", "aqui", "ali",
+		"la", "agora", "sempre", "nunca", "tudo", "nada", "algum", "alguma", "alguns", "algumas",
+		"the", "and", "for", "with", "that", "this", "from", "are", "was", "you", "your", "have", "has",
+	} {
+		stopwords[word] = true
+	}
+}
+
+// IsStopword indica se a palavra (minúscula e sem acentos) deve ser ignorada
+func IsStopword(word string) bool {
+	return stopwords[word]
+}
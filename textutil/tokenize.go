@@ -0,0 +1,53 @@
+package textutil
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// minTokenLength descarta tokens muito curtos, que raramente carregam significado
+const minTokenLength = 3
+
+// FoldAccents remove acentos e cedilhas ("saúde" -> "saude", "visão" -> "visao")
+func FoldAccents(text string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, text)
+	if err != nil {
+		return text
+	}
+	return folded
+}
+
+// Words divide o texto em palavras (sequências de letras e dígitos), sem alterar a grafia
+func Words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Tokenize converte o texto em termos normalizados para busca e similaridade:
+// minúsculas, sem acentos, sem stopwords em português e reduzidos ao radical
+func Tokenize(text string) []string {
+	words := Words(FoldAccents(strings.ToLower(text)))
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < minTokenLength || IsStopword(word) || isNumber(word) {
+			continue
+		}
+		tokens = append(tokens, Stem(word))
+	}
+	return tokens
+}
+
+func isNumber(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
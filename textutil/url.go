@@ -0,0 +1,76 @@
+package textutil
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams são parâmetros de campanha e rastreamento que não mudam a página
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"igshid": true, "mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true,
+	"ref": true, "ref_src": true, "ref_url": true, "cmpid": true, "spm": true,
+	"amp": true,
+}
+
+// trackingPrefixes são prefixos de famílias de parâmetros de rastreamento (utm_source, utm_medium...)
+var trackingPrefixes = []string{"utm_", "ga_", "pk_", "mtm_", "hsa_", "vero_", "oly_"}
+
+// CanonicalURL normaliza a URL para comparar links que apontam para a mesma página:
+// esquema https, host em minúsculas sem "www." e sem porta padrão, sem fragmento, sem
+// parâmetros de rastreamento, com os parâmetros restantes em ordem e sem barra final.
+// URLs inválidas ou relativas retornam vazio.
+func CanonicalURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := parsed.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := parsed.EscapedPath()
+	path = strings.TrimSuffix(path, "/")
+	// AMP costuma ser a mesma página em outro endereço
+	path = strings.TrimSuffix(path, "/amp")
+
+	query := parsed.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !isTrackingParam(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		vals := query[key]
+		sort.Strings(vals)
+		for _, value := range vals {
+			values = append(values, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+
+	canonical := "https://" + host + path
+	if len(values) > 0 {
+		canonical += "?" + strings.Join(values, "&")
+	}
+	return canonical
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if trackingParams[key] {
+		return true
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
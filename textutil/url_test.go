@@ -0,0 +1,54 @@
+package textutil
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		// Esquema, host, porta padrão, barra final e fragmento
+		{"http://WWW.Exemplo.com:80/artigo/#topo", "https://exemplo.com/artigo"},
+		{"https://exemplo.com:443/artigo", "https://exemplo.com/artigo"},
+		{"https://exemplo.com:8443/artigo", "https://exemplo.com:8443/artigo"},
+		{"https://exemplo.com/", "https://exemplo.com"},
+		{"  https://exemplo.com/artigo  ", "https://exemplo.com/artigo"},
+		// Parâmetros de rastreamento saem; os demais ficam em ordem
+		{"https://exemplo.com/artigo?utm_source=news&b=2&a=1", "https://exemplo.com/artigo?a=1&b=2"},
+		{"https://exemplo.com/artigo?UTM_Medium=x&fbclid=abc&gclid=1&ref=home", "https://exemplo.com/artigo"},
+		{"https://exemplo.com/artigo?id=2&id=1", "https://exemplo.com/artigo?id=1&id=2"},
+		{"https://exemplo.com/busca?q=a b", "https://exemplo.com/busca?q=a+b"},
+		// Versão AMP é a mesma página
+		{"https://exemplo.com/artigo/amp/", "https://exemplo.com/artigo"},
+		{"https://exemplo.com/campanha", "https://exemplo.com/campanha"},
+		// O caminho mantém a codificação
+		{"https://exemplo.com/s%C3%A3o-paulo", "https://exemplo.com/s%C3%A3o-paulo"},
+		// Inválidas ou relativas
+		{"/artigo", ""},
+		{"exemplo.com/artigo", ""},
+		{"ftp://exemplo.com/artigo", ""},
+		{"javascript:alert(1)", ""},
+		{"", ""},
+		{"http://[::1", ""},
+	}
+	for _, tt := range tests {
+		if got := CanonicalURL(tt.raw); got != tt.want {
+			t.Errorf("CanonicalURL(%q) = %q, esperado %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalURLSamePage(t *testing.T) {
+	urls := []string{
+		"https://www.exemplo.com/saude/oculos?utm_campaign=x",
+		"http://exemplo.com/saude/oculos/",
+		"https://exemplo.com/saude/oculos#comentarios",
+		"https://EXEMPLO.com/saude/oculos/amp",
+	}
+	want := CanonicalURL(urls[0])
+	for _, u := range urls[1:] {
+		if got := CanonicalURL(u); got != want {
+			t.Errorf("CanonicalURL(%q) = %q, esperado %q", u, got, want)
+		}
+	}
+}
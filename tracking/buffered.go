@@ -0,0 +1,239 @@
+package tracking
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultFlushInterval é o intervalo padrão entre gravações do buffer
+const DefaultFlushInterval = 10 * time.Second
+
+type batchKey struct {
+	ArticleID uint
+	Date      string
+}
+
+// BufferedRecorder acumula visualizações e eventos em memória e os grava em lote
+// periodicamente, evitando um UPDATE síncrono a cada leitura de artigo.
+type BufferedRecorder struct {
+	db       *gorm.DB
+	interval time.Duration
+
+	mu         sync.Mutex
+	pending    map[batchKey]*pendingBatch
+	engagement map[batchKey]map[string]Engagement // eventos por visitante + marco
+
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	started bool
+}
+
+type pendingBatch struct {
+	views    int
+	visitors map[string]struct{}
+	category string
+	readers  map[string]readerReads
+}
+
+func NewBufferedRecorder(db *gorm.DB, interval time.Duration) *BufferedRecorder {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	return &BufferedRecorder{
+		db:         db,
+		interval:   interval,
+		pending:    make(map[batchKey]*pendingBatch),
+		engagement: make(map[batchKey]map[string]Engagement),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Record acumula a visualização no buffer; nunca acessa o banco
+func (r *BufferedRecorder) Record(view View) {
+	key := batchKey{ArticleID: view.ArticleID, Date: view.Date}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch, exists := r.pending[key]
+	if !exists {
+		batch = &pendingBatch{visitors: make(map[string]struct{}), readers: make(map[string]readerReads)}
+		r.pending[key] = batch
+	}
+	batch.views++
+	batch.visitors[view.VisitorHash] = struct{}{}
+	if view.Category != "" {
+		batch.category = view.Category
+	}
+	if view.ReaderID != "" {
+		addReader(batch.readers, view.ReaderID, time.Now())
+	}
+}
+
+// RecordEngagement acumula o evento no buffer; nunca acessa o banco. Um marco repetido
+// pelo mesmo visitante já é descartado aqui.
+func (r *BufferedRecorder) RecordEngagement(event Engagement) {
+	key := batchKey{ArticleID: event.ArticleID, Date: event.Date}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events, exists := r.engagement[key]
+	if !exists {
+		events = make(map[string]Engagement)
+		r.engagement[key] = events
+	}
+	eventKey := event.VisitorHash + "|" + event.Milestone()
+	if _, seen := events[eventKey]; !seen {
+		events[eventKey] = event
+	}
+}
+
+// Start inicia a gravação periódica em segundo plano
+func (r *BufferedRecorder) Start() {
+	r.started = true
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Flush(); err != nil {
+					log.Printf("Erro ao gravar visualizações: %v", err)
+				}
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop interrompe a gravação periódica e grava o que restou no buffer
+func (r *BufferedRecorder) Stop() error {
+	r.once.Do(func() {
+		close(r.stop)
+	})
+	if r.started {
+		<-r.done
+	}
+	return r.Flush()
+}
+
+// Flush grava todas as visualizações e eventos pendentes. Em caso de erro, os lotes que não
+// puderam ser gravados voltam para o buffer e serão tentados novamente.
+func (r *BufferedRecorder) Flush() error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	pending, engagement := r.pending, r.engagement
+	r.pending = make(map[batchKey]*pendingBatch)
+	r.engagement = make(map[batchKey]map[string]Engagement)
+	r.mu.Unlock()
+
+	var firstErr error
+	for key, batch := range pending {
+		visitors := make([]string, 0, len(batch.visitors))
+		for hash := range batch.visitors {
+			visitors = append(visitors, hash)
+		}
+
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			return saveBatch(tx, dailyBatch{
+				ArticleID: key.ArticleID,
+				Date:      key.Date,
+				Views:     batch.views,
+				Visitors:  visitors,
+				Category:  batch.category,
+				Readers:   batch.readers,
+			})
+		})
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			r.requeue(key, batch)
+		}
+	}
+
+	for key, events := range engagement {
+		list := make([]Engagement, 0, len(events))
+		for _, event := range events {
+			list = append(list, event)
+		}
+		if err := saveEngagement(r.db, key.ArticleID, key.Date, list); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			r.requeueEngagement(key, events)
+		}
+	}
+
+	return firstErr
+}
+
+// Pending retorna quantas visualizações aguardam gravação
+func (r *BufferedRecorder) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, batch := range r.pending {
+		total += batch.views
+	}
+	return total
+}
+
+// requeue devolve ao buffer um lote que falhou, somando com o que chegou nesse meio tempo
+func (r *BufferedRecorder) requeue(key batchKey, failed *pendingBatch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batch, exists := r.pending[key]
+	if !exists {
+		r.pending[key] = failed
+		return
+	}
+	batch.views += failed.views
+	for hash := range failed.visitors {
+		batch.visitors[hash] = struct{}{}
+	}
+	if batch.category == "" {
+		batch.category = failed.category
+	}
+	for readerID, reads := range failed.readers {
+		merged := batch.readers[readerID]
+		merged.Reads += reads.Reads
+		if reads.LastAt.After(merged.LastAt) {
+			merged.LastAt = reads.LastAt
+		}
+		batch.readers[readerID] = merged
+	}
+}
+
+// requeueEngagement devolve ao buffer os eventos que falharam
+func (r *BufferedRecorder) requeueEngagement(key batchKey, failed map[string]Engagement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events, exists := r.engagement[key]
+	if !exists {
+		r.engagement[key] = failed
+		return
+	}
+	for eventKey, event := range failed {
+		if _, seen := events[eventKey]; !seen {
+			events[eventKey] = event
+		}
+	}
+}
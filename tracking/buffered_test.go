@@ -0,0 +1,200 @@
+package tracking
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ryv-api/models"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// benchmarkVisitors é a quantidade de visitantes distintos simulados nos benchmarks
+const benchmarkVisitors = 500
+
+// openTestDB cria um banco SQLite temporário com um artigo publicado
+func openTestDB(tb testing.TB) (*gorm.DB, models.Article) {
+	tb.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(tb.TempDir(), "tracking.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	// O SQLite aceita um escritor por vez; uma conexão evita erros de banco bloqueado
+	sqlDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Article{}, &models.ArticleView{}, &models.ArticleVisitor{},
+		&models.ArticleEventVisitor{}, &models.ArticleEngagement{}, &models.ReaderHistory{}); err != nil {
+		tb.Fatal(err)
+	}
+
+	now := time.Now()
+	article := models.Article{
+		Title:       "Artigo de teste",
+		Slug:        "artigo-de-teste",
+		Content:     "<p>Conteúdo</p>",
+		IsPublished: true,
+		PublishedAt: &now,
+	}
+	if err := db.Create(&article).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return db, article
+}
+
+// recordedViews soma as visualizações gravadas nos agregados diários
+func recordedViews(tb testing.TB, db *gorm.DB) int64 {
+	tb.Helper()
+
+	var views int64
+	if err := db.Model(&models.ArticleView{}).Select("COALESCE(SUM(views), 0)").Scan(&views).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return views
+}
+
+// benchmarkRecorder registra visualizações em paralelo, como leituras simultâneas do
+// artigo, e confere que nenhuma se perdeu
+func benchmarkRecorder(b *testing.B, newRecorder func(db *gorm.DB) (Recorder, func() error)) {
+	db, article := openTestDB(b)
+	recorder, stop := newRecorder(db)
+
+	var next int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			visitor := atomic.AddInt64(&next, 1) % benchmarkVisitors
+			recorder.Record(NewView(article.ID, "203.0.113.10", fmt.Sprintf("Mozilla/5.0 (visitante %d)", visitor)))
+		}
+	})
+	if err := stop(); err != nil {
+		b.Fatal(err)
+	}
+	b.StopTimer()
+
+	if views := recordedViews(b, db); views != int64(b.N) {
+		b.Fatalf("visualizações gravadas = %d, esperado %d", views, b.N)
+	}
+}
+
+// BenchmarkDirectRecorder grava cada visualização com uma transação própria
+func BenchmarkDirectRecorder(b *testing.B) {
+	benchmarkRecorder(b, func(db *gorm.DB) (Recorder, func() error) {
+		return NewDirectRecorder(db), func() error { return nil }
+	})
+}
+
+// BenchmarkBufferedRecorder acumula as visualizações em memória; o tempo inclui a gravação
+// final do buffer
+func BenchmarkBufferedRecorder(b *testing.B) {
+	benchmarkRecorder(b, func(db *gorm.DB) (Recorder, func() error) {
+		recorder := NewBufferedRecorder(db, time.Second)
+		recorder.Start()
+		return recorder, recorder.Stop
+	})
+}
+
+func TestBufferedRecorderFlush(t *testing.T) {
+	db, article := openTestDB(t)
+	recorder := NewBufferedRecorder(db, time.Hour)
+
+	for i := 0; i < 10; i++ {
+		recorder.Record(NewView(article.ID, "203.0.113.10", fmt.Sprintf("Mozilla/5.0 (visitante %d)", i%3)))
+	}
+	if pending := recorder.Pending(); pending != 10 {
+		t.Fatalf("Pending() = %d, esperado 10", pending)
+	}
+	if err := recorder.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if pending := recorder.Pending(); pending != 0 {
+		t.Fatalf("Pending() após Flush = %d, esperado 0", pending)
+	}
+
+	if views := recordedViews(t, db); views != 10 {
+		t.Errorf("visualizações gravadas = %d, esperado 10", views)
+	}
+	var saved models.Article
+	if err := db.First(&saved, article.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.ViewCount != 3 {
+		t.Errorf("view_count = %d, esperado 3 (visitantes únicos)", saved.ViewCount)
+	}
+}
+
+func TestBufferedRecorderStopWithoutStart(t *testing.T) {
+	db, article := openTestDB(t)
+	recorder := NewBufferedRecorder(db, time.Hour)
+	recorder.Record(NewView(article.ID, "203.0.113.10", "Mozilla/5.0"))
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- recorder.Stop() }()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop sem Start não retornou")
+	}
+	if views := recordedViews(t, db); views != 1 {
+		t.Errorf("visualizações gravadas = %d, esperado 1", views)
+	}
+}
+
+func TestBufferedRecorderEngagementOncePerVisitor(t *testing.T) {
+	db, article := openTestDB(t)
+	recorder := NewBufferedRecorder(db, time.Hour)
+
+	record := func(visitor, eventType string, value int, target string) {
+		t.Helper()
+		event, err := NewEngagement(article.ID, eventType, value, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.VisitorHash = VisitorHash(event.Date, "203.0.113.10", visitor)
+		recorder.RecordEngagement(event)
+	}
+
+	// Repetições do mesmo visitante, inclusive entre gravações, contam uma vez
+	for i := 0; i < 3; i++ {
+		record("a", EventScroll, 50, "")
+		record("a", EventCTAClick, 0, "whatsapp")
+		record("a", EventCTAClick, 0, fmt.Sprintf("cta-%d", i))
+		if err := recorder.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record("b", EventScroll, 50, "")
+	// Eventos de artigos inexistentes são descartados
+	recorder.RecordEngagement(Engagement{ArticleID: article.ID + 100, Date: time.Now().Format(DateLayout), Type: EventScroll, Value: 50, VisitorHash: "c"})
+	if err := recorder.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []models.ArticleEngagement
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("agregados = %d, esperado 1", len(rows))
+	}
+	if got := rows[0]; got.Scroll50 != 2 || got.CTAClicks != 2 || got.WhatsAppClicks != 1 {
+		t.Errorf("scroll_50 = %d, cta_clicks = %d, whatsapp_clicks = %d; esperado 2, 2, 1", got.Scroll50, got.CTAClicks, got.WhatsAppClicks)
+	}
+}
@@ -0,0 +1,39 @@
+package tracking
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DirectRecorder grava cada visualização e evento no banco de forma síncrona
+type DirectRecorder struct {
+	db *gorm.DB
+}
+
+func NewDirectRecorder(db *gorm.DB) *DirectRecorder {
+	return &DirectRecorder{db: db}
+}
+
+// Record grava a visualização imediatamente
+func (r *DirectRecorder) Record(view View) {
+	batch := dailyBatch{ArticleID: view.ArticleID, Date: view.Date, Views: 1, Visitors: []string{view.VisitorHash}, Category: view.Category}
+	if view.ReaderID != "" {
+		batch.Readers = make(map[string]readerReads, 1)
+		addReader(batch.Readers, view.ReaderID, time.Now())
+	}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return saveBatch(tx, batch)
+	})
+	if err != nil {
+		log.Printf("Erro ao registrar visualização do artigo %d: %v", view.ArticleID, err)
+	}
+}
+
+// RecordEngagement grava o evento imediatamente
+func (r *DirectRecorder) RecordEngagement(event Engagement) {
+	if err := saveEngagement(r.db, event.ArticleID, event.Date, []Engagement{event}); err != nil {
+		log.Printf("Erro ao registrar evento do artigo %d: %v", event.ArticleID, err)
+	}
+}
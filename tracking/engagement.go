@@ -0,0 +1,327 @@
+package tracking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ryv-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tipos de eventos de leitura aceitos
+const (
+	EventScroll     = "scroll"       // marco de rolagem: 25, 50, 75 ou 100 (%)
+	EventTimeOnPage = "time_on_page" // tempo na página em segundos
+	EventCTAClick   = "cta_click"    // clique em um CTA; Target identifica qual (ex.: "whatsapp")
+)
+
+// MaxTimeOnPage limita o tempo informado por evento para descartar abas esquecidas abertas
+const MaxTimeOnPage = 30 * 60
+
+var (
+	ErrInvalidEventType  = errors.New("tipo de evento inválido")
+	ErrInvalidScrollMark = errors.New("marco de rolagem inválido, use 25, 50, 75 ou 100")
+	ErrInvalidTimeOnPage = errors.New("tempo na página inválido")
+)
+
+// Engagement representa um evento de leitura de um artigo
+type Engagement struct {
+	ArticleID   uint
+	Date        string // YYYY-MM-DD
+	Type        string
+	Value       int
+	Target      string
+	VisitorHash string // cada marco de leitura conta uma vez por visitante e dia
+}
+
+// Milestone identifica o marco de leitura do evento, contado uma vez por visitante e dia.
+// Cliques em CTAs diferentes do WhatsApp contam juntos, para que variar o alvo não infle
+// os cliques.
+func (e Engagement) Milestone() string {
+	switch e.Type {
+	case EventScroll:
+		return fmt.Sprintf("%s:%d", EventScroll, e.Value)
+	case EventCTAClick:
+		if e.Target == "whatsapp" {
+			return EventCTAClick + ":whatsapp"
+		}
+	}
+	return e.Type
+}
+
+// NewEngagement valida e monta um evento de leitura do dia
+func NewEngagement(articleID uint, eventType string, value int, target string) (Engagement, error) {
+	event := Engagement{
+		ArticleID: articleID,
+		Date:      time.Now().Format(DateLayout),
+		Type:      eventType,
+		Value:     value,
+		Target:    strings.ToLower(strings.TrimSpace(target)),
+	}
+
+	switch eventType {
+	case EventScroll:
+		if value != 25 && value != 50 && value != 75 && value != 100 {
+			return event, ErrInvalidScrollMark
+		}
+	case EventTimeOnPage:
+		if value <= 0 {
+			return event, ErrInvalidTimeOnPage
+		}
+		if value > MaxTimeOnPage {
+			event.Value = MaxTimeOnPage
+		}
+	case EventCTAClick:
+		event.Value = 1
+	default:
+		return event, ErrInvalidEventType
+	}
+
+	return event, nil
+}
+
+// engagementDelta acumula os incrementos de um artigo em um dia
+type engagementDelta struct {
+	Scroll25          int
+	Scroll50          int
+	Scroll75          int
+	Scroll100         int
+	TimeOnPageSeconds int
+	TimeOnPageSamples int
+	CTAClicks         int
+	WhatsAppClicks    int
+}
+
+func (d *engagementDelta) add(event Engagement) {
+	switch event.Type {
+	case EventScroll:
+		switch event.Value {
+		case 25:
+			d.Scroll25++
+		case 50:
+			d.Scroll50++
+		case 75:
+			d.Scroll75++
+		case 100:
+			d.Scroll100++
+		}
+	case EventTimeOnPage:
+		d.TimeOnPageSeconds += event.Value
+		d.TimeOnPageSamples++
+	case EventCTAClick:
+		d.CTAClicks++
+		if event.Target == "whatsapp" {
+			d.WhatsAppClicks++
+		}
+	}
+}
+
+// saveEngagement soma os eventos de um artigo em um dia no agregado diário. Marcos que o
+// visitante já tinha enviado no dia são descartados, assim como eventos de artigos
+// inexistentes ou não publicados.
+func saveEngagement(db *gorm.DB, articleID uint, date string, events []Engagement) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var published int64
+		if err := tx.Model(&models.Article{}).Where("id = ? AND is_published = ?", articleID, true).Count(&published).Error; err != nil {
+			return err
+		}
+		if published == 0 {
+			return nil
+		}
+
+		var delta engagementDelta
+		for _, event := range events {
+			// Conflito significa que o visitante já enviou este marco no dia
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ArticleEventVisitor{
+				ArticleID:   articleID,
+				Date:        date,
+				VisitorHash: event.VisitorHash,
+				Milestone:   event.Milestone(),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				delta.add(event)
+			}
+		}
+		if delta == (engagementDelta{}) {
+			return nil
+		}
+		return saveEngagementDelta(tx, articleID, date, &delta)
+	})
+}
+
+// saveEngagementDelta soma os incrementos no agregado diário do artigo
+func saveEngagementDelta(tx *gorm.DB, articleID uint, date string, delta *engagementDelta) error {
+	row := models.ArticleEngagement{
+		ArticleID:         articleID,
+		Date:              date,
+		Scroll25:          delta.Scroll25,
+		Scroll50:          delta.Scroll50,
+		Scroll75:          delta.Scroll75,
+		Scroll100:         delta.Scroll100,
+		TimeOnPageSeconds: delta.TimeOnPageSeconds,
+		TimeOnPageSamples: delta.TimeOnPageSamples,
+		CTAClicks:         delta.CTAClicks,
+		WhatsAppClicks:    delta.WhatsAppClicks,
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "article_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"scroll25":             gorm.Expr("scroll25 + ?", delta.Scroll25),
+			"scroll50":             gorm.Expr("scroll50 + ?", delta.Scroll50),
+			"scroll75":             gorm.Expr("scroll75 + ?", delta.Scroll75),
+			"scroll100":            gorm.Expr("scroll100 + ?", delta.Scroll100),
+			"time_on_page_seconds": gorm.Expr("time_on_page_seconds + ?", delta.TimeOnPageSeconds),
+			"time_on_page_samples": gorm.Expr("time_on_page_samples + ?", delta.TimeOnPageSamples),
+			"cta_clicks":           gorm.Expr("cta_clicks + ?", delta.CTAClicks),
+			"whats_app_clicks":     gorm.Expr("whats_app_clicks + ?", delta.WhatsAppClicks),
+			"updated_at":           time.Now(),
+		}),
+	}).Create(&row).Error
+}
+
+// EngagementMetrics resume o engajamento de um artigo em um período
+type EngagementMetrics struct {
+	ArticleID         uint    `json:"article_id"`
+	Views             int     `json:"views"`
+	Scroll25Rate      float64 `json:"scroll_25_rate"`
+	Scroll50Rate      float64 `json:"scroll_50_rate"`
+	Scroll75Rate      float64 `json:"scroll_75_rate"`
+	CompletionRate    float64 `json:"completion_rate"` // leitores que chegaram ao fim
+	AvgTimeOnPage     float64 `json:"avg_time_on_page_seconds"`
+	CTAClicks         int     `json:"cta_clicks"`
+	WhatsAppClicks    int     `json:"whatsapp_clicks"`
+	CTAClickRate      float64 `json:"cta_click_rate"`
+	Score             float64 `json:"score"` // 0 a 1, combina leitura, tempo e cliques
+	TimeOnPageSamples int     `json:"-"`
+}
+
+// targetTimeOnPage é o tempo de leitura considerado ideal para o score de engajamento
+const targetTimeOnPage = 180.0
+
+// LoadEngagementMetrics calcula as métricas de engajamento dos artigos entre as datas [from, to).
+// Quando articleIDs é vazio, considera todos os artigos com eventos no período.
+func LoadEngagementMetrics(db *gorm.DB, articleIDs []uint, from, to time.Time) (map[uint]EngagementMetrics, error) {
+	fromDate, toDate := from.Format(DateLayout), to.Format(DateLayout)
+
+	type engagementTotals struct {
+		ArticleID         uint
+		Scroll25          int
+		Scroll50          int
+		Scroll75          int
+		Scroll100         int
+		TimeOnPageSeconds int
+		TimeOnPageSamples int
+		CTAClicks         int
+		WhatsAppClicks    int
+	}
+	engagementQuery := db.Model(&models.ArticleEngagement{}).
+		Select(`article_id, SUM(scroll25) AS scroll25, SUM(scroll50) AS scroll50, SUM(scroll75) AS scroll75,
+			SUM(scroll100) AS scroll100, SUM(time_on_page_seconds) AS time_on_page_seconds,
+			SUM(time_on_page_samples) AS time_on_page_samples, SUM(cta_clicks) AS cta_clicks,
+			SUM(whats_app_clicks) AS whats_app_clicks`).
+		Where("date >= ? AND date < ?", fromDate, toDate).
+		Group("article_id")
+	if len(articleIDs) > 0 {
+		engagementQuery = engagementQuery.Where("article_id IN ?", articleIDs)
+	}
+	var totals []engagementTotals
+	if err := engagementQuery.Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	type viewTotals struct {
+		ArticleID uint
+		Views     int
+	}
+	viewsQuery := db.Model(&models.ArticleView{}).
+		Select("article_id, SUM(views) AS views").
+		Where("date >= ? AND date < ?", fromDate, toDate).
+		Group("article_id")
+	if len(articleIDs) > 0 {
+		viewsQuery = viewsQuery.Where("article_id IN ?", articleIDs)
+	}
+	var views []viewTotals
+	if err := viewsQuery.Scan(&views).Error; err != nil {
+		return nil, err
+	}
+	viewsByArticle := make(map[uint]int, len(views))
+	for _, v := range views {
+		viewsByArticle[v.ArticleID] = v.Views
+	}
+
+	metrics := make(map[uint]EngagementMetrics, len(totals))
+	for _, t := range totals {
+		metrics[t.ArticleID] = computeMetrics(t.ArticleID, viewsByArticle[t.ArticleID], engagementDelta{
+			Scroll25:          t.Scroll25,
+			Scroll50:          t.Scroll50,
+			Scroll75:          t.Scroll75,
+			Scroll100:         t.Scroll100,
+			TimeOnPageSeconds: t.TimeOnPageSeconds,
+			TimeOnPageSamples: t.TimeOnPageSamples,
+			CTAClicks:         t.CTAClicks,
+			WhatsAppClicks:    t.WhatsAppClicks,
+		})
+	}
+	return metrics, nil
+}
+
+func computeMetrics(articleID uint, views int, totals engagementDelta) EngagementMetrics {
+	m := EngagementMetrics{
+		ArticleID:         articleID,
+		Views:             views,
+		CTAClicks:         totals.CTAClicks,
+		WhatsAppClicks:    totals.WhatsAppClicks,
+		TimeOnPageSamples: totals.TimeOnPageSamples,
+	}
+	if totals.TimeOnPageSamples > 0 {
+		m.AvgTimeOnPage = float64(totals.TimeOnPageSeconds) / float64(totals.TimeOnPageSamples)
+	}
+
+	// Sem visualizações registradas (ex.: buffer ainda não gravado) usamos o maior
+	// marco de rolagem como base, para que as taxas fiquem entre 0 e 1
+	base := views
+	for _, reached := range []int{totals.Scroll25, totals.Scroll50, totals.Scroll75, totals.Scroll100} {
+		if reached > base {
+			base = reached
+		}
+	}
+	if base > 0 {
+		m.Scroll25Rate = ratio(totals.Scroll25, base)
+		m.Scroll50Rate = ratio(totals.Scroll50, base)
+		m.Scroll75Rate = ratio(totals.Scroll75, base)
+		m.CompletionRate = ratio(totals.Scroll100, base)
+		m.CTAClickRate = ratio(totals.CTAClicks, base)
+	}
+
+	timeScore := m.AvgTimeOnPage / targetTimeOnPage
+	if timeScore > 1 {
+		timeScore = 1
+	}
+	// Uma taxa de cliques de 10% já é considerada excelente
+	ctaScore := m.CTAClickRate * 10
+	if ctaScore > 1 {
+		ctaScore = 1
+	}
+	m.Score = 0.4*m.Scroll75Rate + 0.3*timeScore + 0.3*ctaScore
+
+	return m
+}
+
+func ratio(value, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	r := float64(value) / float64(total)
+	if r > 1 {
+		return 1
+	}
+	return r
+}
@@ -0,0 +1,108 @@
+package tracking
+
+import (
+	"time"
+
+	"ryv-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dailyBatch agrupa as visualizações de um artigo em um dia
+type dailyBatch struct {
+	ArticleID uint
+	Date      string
+	Views     int
+	Visitors  []string
+	Category  string
+	Readers   map[string]readerReads
+}
+
+// readerReads acumula as leituras de um artigo por um leitor
+type readerReads struct {
+	Reads  int
+	LastAt time.Time
+}
+
+// saveBatch grava um lote de visualizações de um artigo/dia.
+// O ViewCount do artigo só é incrementado pelos visitantes ainda não registrados no dia,
+// para que atualizações de página não inflem o contador.
+func saveBatch(tx *gorm.DB, batch dailyBatch) error {
+	newVisitors := 0
+	if len(batch.Visitors) > 0 {
+		visitors := make([]models.ArticleVisitor, 0, len(batch.Visitors))
+		for _, hash := range batch.Visitors {
+			visitors = append(visitors, models.ArticleVisitor{ArticleID: batch.ArticleID, Date: batch.Date, VisitorHash: hash})
+		}
+		// Conflito significa que o visitante já foi contado neste dia
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&visitors)
+		if result.Error != nil {
+			return result.Error
+		}
+		newVisitors = int(result.RowsAffected)
+	}
+
+	daily := models.ArticleView{ArticleID: batch.ArticleID, Date: batch.Date, Views: batch.Views, UniqueVisitors: newVisitors}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "article_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"views":           gorm.Expr("views + ?", batch.Views),
+			"unique_visitors": gorm.Expr("unique_visitors + ?", newVisitors),
+			"updated_at":      time.Now(),
+		}),
+	}).Create(&daily).Error; err != nil {
+		return err
+	}
+
+	if err := saveReaderHistory(tx, batch); err != nil {
+		return err
+	}
+
+	if newVisitors == 0 {
+		return nil
+	}
+
+	// Incremento atômico, sem ler o valor atual
+	return tx.Model(&models.Article{}).Where("id = ?", batch.ArticleID).
+		UpdateColumn("view_count", gorm.Expr("view_count + ?", newVisitors)).Error
+}
+
+// saveReaderHistory soma as leituras do artigo no histórico de cada leitor
+func saveReaderHistory(tx *gorm.DB, batch dailyBatch) error {
+	if len(batch.Readers) == 0 {
+		return nil
+	}
+
+	history := make([]models.ReaderHistory, 0, len(batch.Readers))
+	for readerID, reads := range batch.Readers {
+		history = append(history, models.ReaderHistory{
+			ReaderID:    readerID,
+			ArticleID:   batch.ArticleID,
+			Category:    batch.Category,
+			Reads:       reads.Reads,
+			FirstReadAt: reads.LastAt,
+			LastReadAt:  reads.LastAt,
+		})
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "reader_id"}, {Name: "article_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"reads":        gorm.Expr("reads + excluded.reads"),
+			"category":     gorm.Expr("excluded.category"),
+			"last_read_at": gorm.Expr("excluded.last_read_at"),
+			"updated_at":   gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&history).Error
+}
+
+// addReader acumula uma leitura do leitor no lote
+func addReader(readers map[string]readerReads, readerID string, at time.Time) {
+	reads := readers[readerID]
+	reads.Reads++
+	if at.After(reads.LastAt) {
+		reads.LastAt = at
+	}
+	readers[readerID] = reads
+}
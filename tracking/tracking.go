@@ -0,0 +1,94 @@
+package tracking
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DateLayout é o formato das datas usadas nos agregados diários
+const DateLayout = "2006-01-02"
+
+// botUserAgentPattern identifica crawlers, geradores de preview e clientes HTTP automatizados
+var botUserAgentPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|scrap|fetch|preview|facebookexternalhit|whatsapp|telegram|headless|lighthouse|pingdom|uptime|monitor|curl|wget|python-requests|go-http-client|java/|okhttp|axios|node-fetch`)
+
+// View representa uma visualização de artigo a ser contabilizada
+type View struct {
+	ArticleID   uint
+	Date        string // YYYY-MM-DD
+	VisitorHash string
+	ReaderID    string // leitor anônimo, quando identificado
+	Category    string // categoria do artigo, usada no histórico do leitor
+}
+
+// Recorder registra visualizações e eventos de leitura de artigos
+type Recorder interface {
+	Record(view View)
+	RecordEngagement(event Engagement)
+}
+
+// IsBot retorna true para User-Agents vazios ou de robôs conhecidos
+func IsBot(userAgent string) bool {
+	userAgent = strings.TrimSpace(userAgent)
+	return userAgent == "" || botUserAgentPattern.MatchString(userAgent)
+}
+
+// NewView monta a visualização do dia para o visitante identificado por IP + User-Agent
+func NewView(articleID uint, ip, userAgent string) View {
+	date := time.Now().Format(DateLayout)
+	return View{
+		ArticleID:   articleID,
+		Date:        date,
+		VisitorHash: VisitorHash(date, ip, userAgent),
+	}
+}
+
+var (
+	visitorSecretMu sync.RWMutex
+	// visitorSecret é a chave dos hashes de visitantes; sem SetVisitorSecret, é sorteada ao
+	// iniciar e vale até o processo terminar
+	visitorSecret = randomSecret()
+)
+
+// SetVisitorSecret define a chave secreta dos hashes de visitantes. Com a mesma chave em
+// todas as instâncias (e entre reinícios), o mesmo visitante conta uma vez por dia.
+func SetVisitorSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	visitorSecretMu.Lock()
+	visitorSecret = []byte(secret)
+	visitorSecretMu.Unlock()
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// VisitorHash gera um identificador aproximado do visitante a partir de IP + User-Agent.
+// É um HMAC com uma chave do dia, derivada da chave secreta: sem o segredo, o hash não pode
+// ser revertido para o IP testando os endereços possíveis, e o mesmo visitante não pode ser
+// rastreado entre dias.
+func VisitorHash(date, ip, userAgent string) string {
+	visitorSecretMu.RLock()
+	dayKey := hmacSHA256(visitorSecret, "visitor|"+date)
+	visitorSecretMu.RUnlock()
+
+	sum := hmacSHA256(dayKey, ip+"|"+userAgent)
+	return hex.EncodeToString(sum[:16])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,146 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// deepLEndpoint e deepLFreeEndpoint são os endereços da API paga e da gratuita
+	deepLEndpoint     = "https://api.deepl.com/v2/translate"
+	deepLFreeEndpoint = "https://api-free.deepl.com/v2/translate"
+	// deepLQuotaExceeded é o código que o DeepL usa quando a cota de caracteres acaba
+	deepLQuotaExceeded = 456
+	// maxDeepLResponseSize limita o tamanho da resposta lida do provedor
+	maxDeepLResponseSize = 4 * 1024 * 1024
+)
+
+// DeepLError é uma resposta de erro do DeepL. Erros de limite de uso correspondem a
+// ErrQuota em errors.Is.
+type DeepLError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *DeepLError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("DeepL respondeu %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("DeepL respondeu %d", e.StatusCode)
+}
+
+func (e *DeepLError) Unwrap() error {
+	if e.StatusCode == http.StatusTooManyRequests || e.StatusCode == deepLQuotaExceeded {
+		return ErrQuota
+	}
+	return nil
+}
+
+// DeepLTranslator traduz textos com a API do DeepL (deepl.com)
+type DeepLTranslator struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewDeepLTranslator cria o provedor com a chave da API. Chaves do plano gratuito
+// (terminadas em ":fx") usam o endereço da API gratuita. endpoint é opcional e substitui
+// o endereço padrão (útil para proxies e testes).
+func NewDeepLTranslator(apiKey, endpoint string) *DeepLTranslator {
+	if endpoint == "" {
+		endpoint = deepLEndpoint
+		if strings.HasSuffix(apiKey, ":fx") {
+			endpoint = deepLFreeEndpoint
+		}
+	}
+	return &DeepLTranslator{
+		apiKey:   apiKey,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *DeepLTranslator) Name() string {
+	return "deepl"
+}
+
+// Translate envia o lote em uma única requisição. Com HTML, o DeepL traduz só o texto e
+// mantém as tags.
+func (t *DeepLTranslator) Translate(ctx context.Context, req Request) ([]string, error) {
+	if len(req.Texts) == 0 {
+		return nil, nil
+	}
+
+	payload := map[string]interface{}{
+		"text":        req.Texts,
+		"target_lang": deepLLanguage(req.Target),
+	}
+	if req.Source != "" {
+		// O idioma de origem não aceita variante regional (PT-BR, EN-US)
+		source, _, _ := strings.Cut(deepLLanguage(req.Source), "-")
+		payload["source_lang"] = source
+	}
+	if req.HTML {
+		payload["tag_handling"] = "html"
+		payload["ignore_tags"] = []string{"code", "kbd", "samp", "pre"}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Authorization", "DeepL-Auth-Key "+t.apiKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxDeepLResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var deepLErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &deepLErr)
+		return nil, &DeepLError{StatusCode: resp.StatusCode, Message: deepLErr.Message}
+	}
+
+	var deepLResponse struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := json.Unmarshal(respBody, &deepLResponse); err != nil {
+		return nil, fmt.Errorf("resposta inválida do DeepL: %w", err)
+	}
+	if len(deepLResponse.Translations) != len(req.Texts) {
+		return nil, fmt.Errorf("DeepL retornou %d traduções para %d textos", len(deepLResponse.Translations), len(req.Texts))
+	}
+
+	translated := make([]string, len(req.Texts))
+	for i, translation := range deepLResponse.Translations {
+		translated[i] = translation.Text
+	}
+	return translated, nil
+}
+
+// deepLLanguage converte o código do idioma para o formato do DeepL (ex.: pt-BR → PT-BR)
+func deepLLanguage(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, "_", "-"))
+}
@@ -0,0 +1,278 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Limites de cada lote enviado ao provedor
+const (
+	maxBatchTexts = 50
+	maxBatchChars = 30000
+)
+
+// blockElements são os elementos traduzidos de uma vez, com a marcação interna, para que
+// o provedor veja a frase inteira mesmo quando ela tem links ou negrito no meio
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Li: true, atom.Blockquote: true, atom.Figcaption: true, atom.Caption: true, atom.Td: true,
+	atom.Th: true, atom.Dt: true, atom.Dd: true, atom.Summary: true,
+}
+
+// skippedElements não são traduzidos (código e conteúdo que não é texto)
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Pre: true, atom.Code: true, atom.Kbd: true,
+	atom.Samp: true, atom.Svg: true, atom.Math: true, atom.Template: true,
+}
+
+// translatedAttributes são os atributos com texto para o leitor
+var translatedAttributes = []string{"alt", "title"}
+
+// Texts traduz textos simples (título, resumo) em lotes. Textos sem letras voltam iguais.
+func Texts(ctx context.Context, t Translator, texts []string, source, target string) ([]string, error) {
+	translated := make([]string, len(texts))
+	copy(translated, texts)
+
+	var pending []string
+	var positions []int
+	for i, text := range texts {
+		if trimmed := strings.TrimSpace(text); hasLetter(trimmed) {
+			pending = append(pending, trimmed)
+			positions = append(positions, i)
+		}
+	}
+
+	results, err := translateBatches(ctx, t, pending, source, target, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, position := range positions {
+		translated[position] = results[i]
+	}
+	return translated, nil
+}
+
+// HTML traduz o conteúdo mantendo a estrutura do HTML: cada bloco (parágrafo, título,
+// item de lista) vai com a marcação interna e, se o provedor devolver uma estrutura
+// diferente, o bloco é traduzido trecho a trecho. Os atributos alt e title também são
+// traduzidos; código e scripts ficam como estão.
+func HTML(ctx context.Context, t Translator, content, source, target string) (string, error) {
+	if strings.TrimSpace(content) == "" {
+		return content, nil
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(content), root)
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+
+	var c collector
+	c.walk(root, false)
+
+	// Atributos primeiro, para que os blocos já sejam enviados com eles traduzidos
+	attributes := make([]string, len(c.attributes))
+	for i, attr := range c.attributes {
+		attributes[i] = attr.node.Attr[attr.index].Val
+	}
+	translatedAttrs, err := Texts(ctx, t, attributes, source, target)
+	if err != nil {
+		return "", err
+	}
+	for i, attr := range c.attributes {
+		attr.node.Attr[attr.index].Val = translatedAttrs[i]
+	}
+
+	blocks := make([]string, len(c.blocks))
+	for i, block := range c.blocks {
+		inner, err := renderChildren(block)
+		if err != nil {
+			return "", err
+		}
+		blocks[i] = inner
+	}
+	translatedBlocks, err := translateBatches(ctx, t, blocks, source, target, true)
+	if err != nil {
+		return "", err
+	}
+
+	textNodes := c.texts
+	for i, block := range c.blocks {
+		replacement, err := html.ParseFragment(strings.NewReader(translatedBlocks[i]), block)
+		if err != nil || structure(replacement) != structure(children(block)) {
+			// A marcação mudou: traduz só os trechos de texto do bloco
+			var fallback collector
+			fallback.walk(block, true)
+			textNodes = append(textNodes, fallback.texts...)
+			continue
+		}
+		for child := block.FirstChild; child != nil; child = block.FirstChild {
+			block.RemoveChild(child)
+		}
+		for _, node := range replacement {
+			block.AppendChild(node)
+		}
+	}
+
+	texts := make([]string, len(textNodes))
+	for i, node := range textNodes {
+		texts[i] = node.Data
+	}
+	translatedTexts, err := Texts(ctx, t, texts, source, target)
+	if err != nil {
+		return "", err
+	}
+	for i, node := range textNodes {
+		// Mantém os espaços das pontas, que separam o texto das tags vizinhas
+		trimmed := strings.TrimSpace(node.Data)
+		start := strings.Index(node.Data, trimmed)
+		node.Data = node.Data[:start] + translatedTexts[i] + node.Data[start+len(trimmed):]
+	}
+
+	return renderChildren(root)
+}
+
+// collector separa os blocos traduzidos inteiros, os trechos de texto soltos e os
+// atributos a traduzir
+type collector struct {
+	blocks     []*html.Node
+	texts      []*html.Node
+	attributes []attributeRef
+}
+
+type attributeRef struct {
+	node  *html.Node
+	index int
+}
+
+// walk percorre os filhos do nó. Com textOnly, blocos não são separados (usado quando o
+// bloco inteiro não pôde ser traduzido de uma vez) e os atributos já foram coletados.
+func (c *collector) walk(n *html.Node, textOnly bool) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			if hasLetter(child.Data) {
+				c.texts = append(c.texts, child)
+			}
+		case html.ElementNode:
+			if skippedElements[child.DataAtom] {
+				continue
+			}
+			if !textOnly {
+				c.addAttributes(child)
+			}
+			if !textOnly && blockElements[child.DataAtom] && !containsBlock(child) {
+				c.blocks = append(c.blocks, child)
+				c.collectAttributes(child)
+				continue
+			}
+			c.walk(child, textOnly)
+		}
+	}
+}
+
+// collectAttributes coleta os atributos dos descendentes de um bloco
+func (c *collector) collectAttributes(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || skippedElements[child.DataAtom] {
+			continue
+		}
+		c.addAttributes(child)
+		c.collectAttributes(child)
+	}
+}
+
+func (c *collector) addAttributes(n *html.Node) {
+	for i, attr := range n.Attr {
+		for _, name := range translatedAttributes {
+			if attr.Namespace == "" && attr.Key == name && hasLetter(attr.Val) {
+				c.attributes = append(c.attributes, attributeRef{node: n, index: i})
+			}
+		}
+	}
+}
+
+// containsBlock indica se há outro bloco dentro do elemento (ex.: parágrafos em um item
+// de lista), caso em que os blocos internos são traduzidos separadamente
+func containsBlock(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (blockElements[child.DataAtom] || containsBlock(child)) {
+			return true
+		}
+	}
+	return false
+}
+
+// structure descreve a árvore de elementos dos nós, para comparar a marcação antes e
+// depois da tradução. O conteúdo dos elementos não traduzidos (código) entra inteiro, para
+// que um provedor que o altere caia na tradução trecho a trecho.
+func structure(nodes []*html.Node) string {
+	var b strings.Builder
+	var write func(n *html.Node)
+	write = func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		if skippedElements[n.DataAtom] {
+			html.Render(&b, n)
+			return
+		}
+		b.WriteString("<" + n.Data + ">")
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			write(child)
+		}
+		b.WriteString("</" + n.Data + ">")
+	}
+	for _, n := range nodes {
+		write(n)
+	}
+	return b.String()
+}
+
+func children(n *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, child)
+	}
+	return nodes
+}
+
+func renderChildren(n *html.Node) (string, error) {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(&b, child); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// translateBatches envia os textos em lotes limitados em quantidade e tamanho
+func translateBatches(ctx context.Context, t Translator, texts []string, source, target string, isHTML bool) ([]string, error) {
+	translated := make([]string, 0, len(texts))
+	for start := 0; start < len(texts); {
+		end, chars := start, 0
+		for end < len(texts) && end-start < maxBatchTexts && (end == start || chars+len(texts[end]) <= maxBatchChars) {
+			chars += len(texts[end])
+			end++
+		}
+
+		batch, err := t.Translate(ctx, Request{Texts: texts[start:end], Source: source, Target: target, HTML: isHTML})
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("%s retornou %d traduções para %d textos", t.Name(), len(batch), end-start)
+		}
+		translated = append(translated, batch...)
+		start = end
+	}
+	return translated, nil
+}
@@ -0,0 +1,127 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "marcação dentro do parágrafo",
+			content: `<p>Olá <strong>mundo</strong>, <a href="/oculos">veja</a>.</p>`,
+			want:    `<p>[en] Olá <strong>[en] mundo</strong>, <a href="/oculos">[en] veja</a>.</p>`,
+		},
+		{
+			name:    "alt e title",
+			content: `<figure><img src="/a.jpg" alt="Óculos de sol" title="Foto"><figcaption>Legenda</figcaption></figure><p><a href="/x" title="Saiba mais">link</a></p>`,
+			want:    `<figure><img src="/a.jpg" alt="[en] Óculos de sol" title="[en] Foto"/><figcaption>[en] Legenda</figcaption></figure><p><a href="/x" title="[en] Saiba mais">[en] link</a></p>`,
+		},
+		{
+			name:    "código não é traduzido",
+			content: `<p>Rode <code>go test</code> agora</p><pre><code>func main() {}</code></pre><p><kbd>Ctrl</kbd> e pronto</p>`,
+			want:    `<p>[en] Rode <code>go test</code> [en] agora</p><pre><code>func main() {}</code></pre><p><kbd>Ctrl</kbd> [en] e pronto</p>`,
+		},
+		{
+			name:    "listas com parágrafos e títulos",
+			content: `<h2>Título</h2><ul><li>Item um</li><li><p>Parágrafo</p><p>Outro</p></li></ul>`,
+			want:    `<h2>[en] Título</h2><ul><li>[en] Item um</li><li><p>[en] Parágrafo</p><p>[en] Outro</p></li></ul>`,
+		},
+		{
+			name:    "texto fora de blocos",
+			content: `texto solto <em>ênfase</em>`,
+			want:    `[en] texto solto <em>[en] ênfase</em>`,
+		},
+		{
+			name:    "scripts e entidades",
+			content: `<script>var x = "oi"</script><p>Lentes &amp; armações</p>`,
+			want:    `<script>var x = "oi"</script><p>[en] Lentes &amp; armações</p>`,
+		},
+		{
+			name:    "sem letras",
+			content: `<p>  </p><p>123</p><img src="/a.jpg" alt="2024">`,
+			want:    `<p>  </p><p>123</p><img src="/a.jpg" alt="2024"/>`,
+		},
+		{
+			name:    "vazio",
+			content: "  ",
+			want:    "  ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(context.Background(), Fake{}, tt.content, "pt", "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("HTML =\n%s\nesperado\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// looseTranslator imita um provedor que não respeita a marcação: em HTML, remove as tags
+// e traduz o código
+type looseTranslator struct{}
+
+func (looseTranslator) Name() string {
+	return "loose"
+}
+
+func (looseTranslator) Translate(ctx context.Context, req Request) ([]string, error) {
+	translated := make([]string, len(req.Texts))
+	for i, text := range req.Texts {
+		if req.HTML {
+			text = strings.NewReplacer("<strong>", "", "</strong>", "", "go test", "GO TEST").Replace(text)
+		}
+		translated[i] = "[x] " + text
+	}
+	return translated, nil
+}
+
+func TestHTMLFallback(t *testing.T) {
+	content := `<p>Olá <strong>mundo</strong></p><p>Rode <code>go test</code> agora</p><p>Simples</p>`
+	want := `<p>[x] Olá <strong>[x] mundo</strong></p><p>[x] Rode <code>go test</code> [x] agora</p><p>[x] Simples</p>`
+
+	got, err := HTML(context.Background(), looseTranslator{}, content, "pt", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("HTML =\n%s\nesperado\n%s", got, want)
+	}
+}
+
+type failingTranslator struct{}
+
+func (failingTranslator) Name() string {
+	return "failing"
+}
+
+func (failingTranslator) Translate(ctx context.Context, req Request) ([]string, error) {
+	return nil, ErrQuota
+}
+
+func TestHTMLError(t *testing.T) {
+	if _, err := HTML(context.Background(), failingTranslator{}, "<p>Olá</p>", "pt", "en"); !errors.Is(err, ErrQuota) {
+		t.Errorf("erro = %v, esperado ErrQuota", err)
+	}
+}
+
+func TestTexts(t *testing.T) {
+	got, err := Texts(context.Background(), Fake{}, []string{" Título ", "", "2024", "Resumo"}, "pt", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"[en] Título", "", "2024", "[en] Resumo"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Texts = %q, esperado %q", got, want)
+	}
+}
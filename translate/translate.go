@@ -0,0 +1,111 @@
+package translate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Idiomas usados pelo blog
+const (
+	// TargetLanguage é o idioma dos artigos do blog
+	TargetLanguage = "pt-BR"
+	// SourceEnglish é o idioma dos sites monitorados pelo scraper
+	SourceEnglish = "en"
+)
+
+var (
+	// ErrNotConfigured indica que nenhum provedor de tradução foi configurado
+	ErrNotConfigured = errors.New("tradução não configurada")
+	// ErrQuota indica que o limite de uso do provedor de tradução foi atingido
+	ErrQuota = errors.New("limite de uso do provedor de tradução atingido")
+)
+
+// Request é um lote de textos a traduzir. Com HTML, os textos podem conter marcação, que o
+// provedor deve manter (só o texto entre as tags é traduzido).
+type Request struct {
+	Texts  []string
+	Source string // código do idioma de origem, ex.: "en"; vazio para detectar
+	Target string // código do idioma de destino, ex.: "pt-BR"
+	HTML   bool
+}
+
+// Translator traduz textos em um serviço externo. A resposta tem um texto traduzido para
+// cada texto do pedido, na mesma ordem.
+type Translator interface {
+	Name() string
+	Translate(ctx context.Context, req Request) ([]string, error)
+}
+
+// Fake é um tradutor determinístico, para testes e desenvolvimento local: antecede cada
+// trecho de texto com o idioma de destino entre colchetes e mantém a marcação e o código.
+type Fake struct{}
+
+func (Fake) Name() string {
+	return "fake"
+}
+
+func (Fake) Translate(ctx context.Context, req Request) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefix := "[" + req.Target + "] "
+	translated := make([]string, len(req.Texts))
+	for i, text := range req.Texts {
+		if !req.HTML {
+			translated[i] = fakeText(prefix, text)
+			continue
+		}
+
+		var b strings.Builder
+		tokenizer := html.NewTokenizer(strings.NewReader(text))
+		skipDepth := 0
+		for {
+			tokenType := tokenizer.Next()
+			if tokenType == html.ErrorToken {
+				break
+			}
+			raw := string(tokenizer.Raw())
+			switch tokenType {
+			case html.StartTagToken, html.EndTagToken:
+				// Como o ignore_tags do DeepL, código não é traduzido
+				name, _ := tokenizer.TagName()
+				if skippedElements[atom.Lookup(name)] {
+					if tokenType == html.StartTagToken {
+						skipDepth++
+					} else if skipDepth > 0 {
+						skipDepth--
+					}
+				}
+			case html.TextToken:
+				if skipDepth > 0 {
+					break
+				}
+				// O texto bruto já vem escapado, então o prefixo é o único acréscimo
+				raw = fakeText(prefix, raw)
+			}
+			b.WriteString(raw)
+		}
+		translated[i] = b.String()
+	}
+	return translated, nil
+}
+
+// fakeText antecede o prefixo ao texto, mantendo os espaços das pontas
+func fakeText(prefix, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !hasLetter(trimmed) {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + prefix + trimmed + text[start+len(trimmed):]
+}
+
+func hasLetter(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}